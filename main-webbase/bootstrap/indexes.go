@@ -52,4 +52,129 @@ func EnsureNotiQueueIndexes(ctx context.Context, queueCol *mongo.Collection) err
 		},
 	})
 	return err
-}
\ No newline at end of file
+}
+// EnsureUploadIndexes speeds up quota sums and the per-user upload list.
+func EnsureUploadIndexes(db *mongo.Database) error {
+	_, err := db.Collection("uploads").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}},
+				Options: options.Index().SetName("user_status"),
+			},
+			{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("status_expires_at"),
+			},
+		},
+	)
+	return err
+}
+
+// EnsureMediaGCIndexes keeps report listing cheap.
+func EnsureMediaGCIndexes(db *mongo.Database) error {
+	_, err := db.Collection("media_gc_reports").Indexes().CreateOne(
+		context.Background(),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "started_at", Value: -1}},
+			Options: options.Index().SetName("started_at_desc"),
+		},
+	)
+	return err
+}
+
+func EnsureMembershipIndexes(db *mongo.Database) error {
+	_, err := db.Collection("memberships").Indexes().CreateMany(
+		context.Background(),
+		[]mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "joined_at", Value: -1}},
+				Options: options.Index().SetName("user_history"),
+			},
+			{
+				Keys:    bson.D{{Key: "org_path", Value: 1}, {Key: "joined_at", Value: -1}},
+				Options: options.Index().SetName("org_history"),
+			},
+			{
+				Keys:    bson.D{{Key: "active", Value: 1}, {Key: "term_end", Value: 1}},
+				Options: options.Index().SetName("term_expiry"),
+			},
+		},
+	)
+	return err
+}
+
+func EnsureOrgJoinIndexes(db *mongo.Database) error {
+	ctx := context.Background()
+	if _, err := db.Collection("org_join_requests").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "org_path", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("queue"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("by_user"),
+		},
+	}); err != nil {
+		return err
+	}
+	_, err := db.Collection("org_invites").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetName("code_unique").SetUnique(true),
+	})
+	return err
+}
+
+// EnsurePolicyVersionIndexes one version number per policy; history is read newest first.
+func EnsurePolicyVersionIndexes(db *mongo.Database) error {
+	_, err := db.Collection("policy_versions").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "policy_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetName("policy_version_unique").SetUnique(true),
+	})
+	return err
+}
+
+// EnsureImpersonationIndexes sessions are listed per target/admin, audit rows per session.
+func EnsureImpersonationIndexes(db *mongo.Database) error {
+	ctx := context.Background()
+	if _, err := db.Collection("impersonation_sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "issued_at", Value: -1}}, Options: options.Index().SetName("target_issued")},
+		{Keys: bson.D{{Key: "admin_id", Value: 1}, {Key: "issued_at", Value: -1}}, Options: options.Index().SetName("admin_issued")},
+	}); err != nil {
+		return err
+	}
+	_, err := db.Collection("impersonation_audit").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "session_id", Value: 1}, {Key: "at", Value: 1}},
+		Options: options.Index().SetName("session_at"),
+	})
+	return err
+}
+
+// EnsurePostRevisionIndexes one revision number per post; history is read newest first.
+func EnsurePostRevisionIndexes(db *mongo.Database) error {
+	_, err := db.Collection("post_revisions").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "revision", Value: -1}},
+		Options: options.Index().SetName("post_revision_unique").SetUnique(true),
+	})
+	return err
+}
+
+// EnsurePostScheduleIndexes lets the scheduled publisher find due posts without scanning the feed.
+func EnsurePostScheduleIndexes(db *mongo.Database) error {
+	_, err := db.Collection("posts").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "publish_at", Value: 1}},
+		Options: options.Index().SetName("post_status_publish_at").
+			SetPartialFilterExpression(bson.M{"status": "scheduled"}),
+	})
+	return err
+}
+
+// EnsurePositionGuardIndexes one guard document per (org_path, position_key); creating the index also
+// creates the collection up front, so the first assignment does not create it inside a transaction.
+func EnsurePositionGuardIndexes(db *mongo.Database) error {
+	_, err := db.Collection("position_guards").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "org_path", Value: 1}, {Key: "position_key", Value: 1}},
+		Options: options.Index().SetName("org_position_unique").SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,120 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/internal/authz"
+	"main-webbase/internal/models"
+)
+
+// label {English, Thai}
+type label struct{ en, th string }
+
+var categoryLabels = map[string]label{
+	"org":        {"Organization", "หน่วยงาน"},
+	"membership": {"Membership", "สมาชิกและสิทธิ์"},
+	"event":      {"Events", "กิจกรรม"},
+	"post":       {"Posts", "โพสต์"},
+	"comment":    {"Comments", "ความคิดเห็น"},
+	"user":       {"User", "ผู้ใช้"},
+	"system":     {"System", "ระบบ"},
+}
+
+var permissionLabels = map[string]label{
+	"organize:create":    {"Manage org units", "จัดการหน่วยงาน"},
+	"position:manage":    {"Manage positions", "จัดการตำแหน่ง"},
+	"membership:assign":  {"Manage members", "จัดการสมาชิก"},
+	"policy:manage":      {"Manage permissions", "จัดการสิทธิ์ของตำแหน่ง"},
+	"membership:request": {"Request to join", "ขอเข้าร่วมหน่วยงาน"},
+	"event:create":       {"Create events", "สร้างกิจกรรม"},
+	"event:update":       {"Edit events", "แก้ไขกิจกรรม"},
+	"event:delete":       {"Delete events", "ลบกิจกรรม"},
+	"event:manage":       {"Manage event participants", "จัดการผู้เข้าร่วมและแบบฟอร์มกิจกรรม"},
+	"event:participate":  {"Join events", "เข้าร่วมกิจกรรม"},
+	"post:create":        {"Create posts", "สร้างโพสต์"},
+	"post:update":        {"Edit posts", "แก้ไขโพสต์"},
+	"post:delete":        {"Delete posts", "ลบโพสต์"},
+	"post:moderate":      {"Moderate posts", "ดูแลโพสต์ของหน่วยงาน"},
+	"post:like":          {"Like posts", "กดถูกใจโพสต์"},
+	"comment:create":     {"Comment", "แสดงความคิดเห็น"},
+	"comment:update":     {"Edit comments", "แก้ไขความคิดเห็น"},
+	"comment:delete":     {"Delete comments", "ลบความคิดเห็น"},
+	"comment:moderate":   {"Moderate comments", "ดูแลความคิดเห็น"},
+	"profile:update":     {"Edit profile", "แก้ไขโปรไฟล์"},
+	"user:delete":        {"Delete users", "ลบผู้ใช้"},
+	"system:admin":       {"System maintenance", "ดูแลระบบ"},
+}
+
+// builtinRoles template ตั้งต้น — seed ครั้งแรกเท่านั้น (แก้ permissions ภายหลังได้ แต่ลบไม่ได้)
+var builtinRoles = []models.Role{
+	{Name: "unit_admin", Label: "Unit admin", LabelTH: "ผู้ดูแลหน่วยงาน", Permissions: []string{
+		"organize:create", "position:manage", "membership:assign", "policy:manage",
+		"event:create", "post:moderate", "comment:moderate",
+	}},
+	{Name: "membership_officer", Label: "Membership officer", LabelTH: "ฝ่ายทะเบียนสมาชิก", Permissions: []string{"membership:assign"}},
+	{Name: "event_organizer", Label: "Event organizer", LabelTH: "ผู้จัดกิจกรรม", Permissions: []string{"event:create"}},
+	{Name: "moderator", Label: "Moderator", LabelTH: "ผู้ดูแลเนื้อหา", Permissions: []string{"post:moderate", "comment:moderate"}},
+}
+
+// SeedPermissionCatalog sync collection permissions กับ action ใน authz registry
+// (key ที่ไม่มีใน registry แล้วจะถูกลบ) และสร้าง role template ตั้งต้นถ้ายังไม่มี
+func SeedPermissionCatalog(db *mongo.Database) error {
+	ctx := context.Background()
+	perms := db.Collection("permissions")
+	if _, err := perms.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_permission_key"),
+	}); err != nil {
+		return err
+	}
+
+	keys := []string{}
+	for _, a := range authz.Actions() {
+		l, ok := permissionLabels[a.Key]
+		if !ok {
+			l = label{a.Description, a.Description}
+		}
+		cat, ok := categoryLabels[a.Category]
+		if !ok {
+			cat = label{a.Category, a.Category}
+		}
+		_, err := perms.UpdateOne(ctx, bson.M{"key": a.Key}, bson.M{"$set": bson.M{
+			"label":             l.en,
+			"label_th":          l.th,
+			"category":          a.Category,
+			"category_label":    cat.en,
+			"category_label_th": cat.th,
+			"kind":              a.Kind,
+		}}, options.UpdateOne().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		keys = append(keys, a.Key)
+	}
+	if _, err := perms.DeleteMany(ctx, bson.M{"key": bson.M{"$nin": keys}}); err != nil {
+		return err
+	}
+
+	roles := db.Collection("role_templates")
+	if _, err := roles.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("uniq_role_name"),
+	}); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, r := range builtinRoles {
+		r.Builtin = true
+		r.CreatedAt, r.UpdatedAt = now, now
+		_, err := roles.UpdateOne(ctx, bson.M{"name": r.Name}, bson.M{"$setOnInsert": r}, options.UpdateOne().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"main-webbase/bootstrap"
 	"main-webbase/config"
 	"main-webbase/database"
+	"main-webbase/internal/accessctx"
 	"main-webbase/internal/middleware"
 	"main-webbase/internal/routes"
 	"main-webbase/internal/services"
@@ -54,6 +55,36 @@ func main() {
 	if err := bootstrap.EnsureLikeIndexes(db); err != nil {
 		log.Fatalf("ensure indexes failed: %v", err)
 	}
+	if err := bootstrap.EnsureUploadIndexes(db); err != nil {
+		log.Fatalf("ensure upload indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsureMediaGCIndexes(db); err != nil {
+		log.Fatalf("ensure media gc indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsureMembershipIndexes(db); err != nil {
+		log.Fatalf("ensure membership indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsureOrgJoinIndexes(db); err != nil {
+		log.Fatalf("ensure org join indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsurePolicyVersionIndexes(db); err != nil {
+		log.Fatalf("ensure policy version indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsureImpersonationIndexes(db); err != nil {
+		log.Fatalf("ensure impersonation indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsurePostRevisionIndexes(db); err != nil {
+		log.Fatalf("ensure post revision indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsurePostScheduleIndexes(db); err != nil {
+		log.Fatalf("ensure post schedule indexes failed: %v", err)
+	}
+	if err := bootstrap.EnsurePositionGuardIndexes(db); err != nil {
+		log.Fatalf("ensure position guard indexes failed: %v", err)
+	}
+	if err := bootstrap.SeedPermissionCatalog(db); err != nil {
+		log.Fatalf("seed permission catalog failed: %v", err)
+	}
 
 	// Setup event reminder ticker
 	loc, _ := time.LoadLocation("Asia/Bangkok")
@@ -75,12 +106,59 @@ func main() {
 		}
 	}()
 
+	// ปิดวาระ membership ที่เลย term_end
+	expireTerms := func() {
+		if n, err := services.ExpireMembershipTerms(context.Background()); err != nil {
+			log.Printf("[membership-expiry] %v", err)
+		} else if n > 0 {
+			log.Printf("[membership-expiry] ended terms for %d users", n)
+		}
+	}
+	expireTerms()
+	termTicker := time.NewTicker(15 * time.Minute)
+	go func() {
+		for range termTicker.C {
+			expireTerms()
+		}
+	}()
+
+	// เผยแพร่โพสต์ตั้งเวลาที่ถึง publish_at
+	publishScheduled := func() {
+		if n, err := services.RunScheduledPublisher(context.Background(), client); err != nil {
+			log.Printf("[post-publisher] %v", err)
+		} else if n > 0 {
+			log.Printf("[post-publisher] published %d scheduled posts", n)
+		}
+	}
+	publishScheduled()
+	publishTicker := time.NewTicker(1 * time.Minute)
+	go func() {
+		for range publishTicker.C {
+			publishScheduled()
+		}
+	}()
+
+	// invalidate access caches when memberships/org_units are written by other instances
+	go accessctx.WatchChanges(context.Background(), db)
+
+	// Orphaned media GC: ลบไฟล์ใน uploads ที่ไม่มีใครอ้างถึงเกิน grace period
+	gcOpts := services.MediaGCOptionsFromConfig(cfg)
+	gcTicker := time.NewTicker(cfg.MediaGCInterval)
+	go func() {
+		for range gcTicker.C {
+			if _, err := services.RunMediaGC(context.Background(), gcOpts); err != nil {
+				log.Printf("[media-gc] %v", err)
+			}
+		}
+	}()
+
 	// Fiber app
 	app := fiber.New()
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // or specify your frontend URL
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins:  "*", // or specify your frontend URL
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Range, Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata",
+		ExposeHeaders: "Location, Tus-Resumable, Upload-Offset, Upload-Length, Accept-Ranges, Content-Range",
 	}))
 
 	// app.Use(func(c *fiber.Ctx) error {
@@ -99,21 +177,28 @@ func main() {
 	routes.SetupAuth(app)
 
 	app.Use(middleware.JWTUidOnly(secret))
+	app.Use(middleware.ImpersonationGuard())
 	app.Use(middleware.InjectViewer(db))
 
 	// Routes
 	routes.SetupRoutesUser(app)
-	// routes.SetupRoutesAbility(app)
-	routes.SetupRoutesOrg(app)
+	routes.SetupRoutesAbility(app)
+	routes.SetupRoutesOrg(app, cfg)
 	routes.SetupRoutesMembership(app)
 	routes.SetupRoutesPosition(app)
 	routes.SetupRoutesPolicy(app)
+	routes.SetupRoutesPermission(app)
+	routes.SetupRoutesAuthz(app)
+	routes.SetupRoutesImpersonation(app)
 	routes.SetupRoutesEvent(app, client)
-	routes.SetupRoutesPost(app, client)
+	routes.SetupRoutesPost(app, client, cfg)
 	routes.SetupRoutesTrending(app, client)
 	routes.CommentRoutes(app, client)
 	routes.LikeRoutes(app, client)
 	routes.NotificationRoutes(app, client)
+	routes.SetupRoutesUpload(app, cfg)
+	routes.SetupRoutesMediaGC(app, cfg)
+	routes.SetupRoutesCache(app)
 
 	// RUN SERVER
 	log.Fatal(app.Listen(":" + cfg.Port))
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +13,18 @@ type Config struct {
 	MongoURI string
 	MongoDB  string
 	Port     string
+
+	// Resumable uploads
+	UploadDir        string
+	UploadQuotaBytes int64
+
+	// Orphaned media GC
+	MediaGCGrace    time.Duration
+	MediaGCInterval time.Duration
+	MediaGCDryRun   bool
+
+	// Posts: แก้ไขได้ภายในกี่นาทีหลังโพสต์ (0 = ไม่จำกัด) หลังจากนั้นเฉพาะ moderator
+	PostEditWindow time.Duration
 }
 
 const (
@@ -18,6 +32,19 @@ const (
 	MaxLimitComments     = 20
 )
 
+const (
+	DefaultUploadDir        = "/var/www/html/uploads"
+	DefaultUploadQuotaBytes = int64(2 << 30)   // 2 GiB ต่อ user
+	MaxUploadSizeBytes      = int64(500 << 20) // 500 MiB ต่อไฟล์
+	MaxUploadChunkBytes     = 4 << 20          // ต้องไม่เกิน BodyLimit ของ fiber (default 4 MiB)
+	UploadExpiry            = 24 * time.Hour   // upload ที่ค้างไม่เสร็จเกินนี้ถือว่าหมดอายุ
+)
+
+const (
+	DefaultMediaGCGraceHours    = 72 // ไฟล์ต้องไม่มีใครอ้างถึงต่อเนื่องนานเท่านี้ก่อนถูกลบ
+	DefaultMediaGCIntervalHours = 6
+)
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -25,6 +52,26 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt64(key string, fallback int64) int64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+		log.Printf("invalid %s=%q, using default %d", key, value, fallback)
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+		log.Printf("invalid %s=%q, using default %t", key, value, fallback)
+	}
+	return fallback
+}
+
 func LoadConfig() Config {
 	// Load .env file
 	err := godotenv.Load()
@@ -36,6 +83,18 @@ func LoadConfig() Config {
 		MongoURI: getEnv("MONGO_URI", "mongodf://localhost:27017"),
 		MongoDB:  getEnv("MONGO_DB", "creatorDatabase"),
 		Port:     getEnv("PORT", "3000"),
+
+		UploadDir:        getEnv("UPLOAD_DIR", DefaultUploadDir),
+		UploadQuotaBytes: getEnvInt64("UPLOAD_QUOTA_BYTES", DefaultUploadQuotaBytes),
+
+		MediaGCGrace:    time.Duration(getEnvInt64("MEDIA_GC_GRACE_HOURS", DefaultMediaGCGraceHours)) * time.Hour,
+		MediaGCInterval: time.Duration(getEnvInt64("MEDIA_GC_INTERVAL_HOURS", DefaultMediaGCIntervalHours)) * time.Hour,
+		MediaGCDryRun:   getEnvBool("MEDIA_GC_DRY_RUN", false),
+
+		PostEditWindow: time.Duration(getEnvInt64("POST_EDIT_WINDOW_MINUTES", 0)) * time.Minute,
+	}
+	if cfg.MediaGCInterval <= 0 {
+		cfg.MediaGCInterval = DefaultMediaGCIntervalHours * time.Hour
 	}
 	return cfg
 }
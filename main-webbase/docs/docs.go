@@ -1616,7 +1616,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Updates policy actions for a position. Only the actions sent will be kept. Supported actions:",
+                "description": "Updates policy actions for a position. Only the actions sent will be kept; sending fewer actions will remove the rest. Every action must exist in the permission catalog (GET /permissions). The position must rank strictly below the caller's own, and only actions the caller holds can be granted. Every change is recorded as a version (see GET /policies/{id}/history).",
                 "consumes": [
                     "application/json"
                 ],
@@ -1,8 +1,41 @@
 package dto
 
+import "main-webbase/internal/authz"
+
 // Response structure for /abilities
+// OwnerAbilities = action ที่ทำได้เฉพาะกับเป้าหมายที่ตัวเองเป็นเจ้าของ (โพสต์/คอมเมนต์/event ของตัวเอง)
 type AbilitiesResponse struct {
-	OrgPath   string          `json:"org_path"`
-	Abilities map[string]bool `json:"abilities"`
-	Version   string          `json:"version,omitempty"`
+	OrgPath        string          `json:"org_path"`
+	Abilities      map[string]bool `json:"abilities"`
+	OwnerAbilities map[string]bool `json:"owner_abilities,omitempty"`
+	Version        string          `json:"version,omitempty"`
+}
+
+// UserAbilitiesResponse effective actions ต่อ org_path (subtree ถูกขยายแล้ว)
+// OwnerAbilities = action ที่ทำได้กับเป้าหมายของตัวเองใน org ที่ไม่อยู่ใน Orgs
+// Version = ETag ของ response (เปลี่ยนเมื่อ membership/policy/org tree เปลี่ยนผลลัพธ์)
+type UserAbilitiesResponse struct {
+	UserID         string              `json:"user_id"`
+	Version        string              `json:"version"`
+	OwnerAbilities []string            `json:"owner_abilities"`
+	Orgs           []AbilitiesResponse `json:"orgs"`
+}
+
+// AuthzExplainMembership membership ที่นำมาคิด พร้อมจำนวน policy ที่ได้จากตำแหน่งนั้น
+// CoversTarget = org_path เป้าหมายอยู่ใต้ (หรือเท่ากับ) org ของ membership
+type AuthzExplainMembership struct {
+	OrgPath      string `json:"org_path"`
+	PositionKey  string `json:"position_key"`
+	CoversTarget bool   `json:"covers_target"`
+	Policies     int    `json:"policies"`
+}
+
+// AuthzExplainResponse ผลของ GET /authz/explain
+type AuthzExplainResponse struct {
+	UserID      string                   `json:"user_id"`
+	Action      string                   `json:"action"`
+	OrgPath     string                   `json:"org_path"`
+	Attrs       map[string]string        `json:"attrs,omitempty"`
+	Memberships []AuthzExplainMembership `json:"memberships"`
+	Trace       authz.Trace              `json:"trace"`
 }
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"main-webbase/internal/models"
+)
+
 // Object in reequest body
 type PostAs struct {
 	OrgPath     string `bson:"org_path,omitempty"     json:"org_path,omitempty"`
@@ -7,9 +13,20 @@ type PostAs struct {
 	Tag         string `bson:"label,omitempty"        json:"label,omitempty"` // e.g., "Head • SMO"
 }
 
+// Visibility ของโพสต์
+//   - public: ทุกคน
+//   - private: Audience = org_path ที่เห็นได้ (รวม subtree) แบบเดิม
+//   - org: กำหนดกลุ่มแบบเดียวกับ event — Targets (exact|subtree), ตำแหน่งที่รวม/ยกเว้น, user ที่อนุญาต/ห้ามเพิ่ม
+//     Targets ว่าง = ทุกหน่วยงาน (ใช้คู่กับ IncludePositions เช่น "head ทุกหน่วยงาน")
 type Visibility struct {
 	Access   string   `json:"access"`
 	Audience []string `json:"audience"`
+
+	Targets          []models.AudienceItem `json:"targets,omitempty"`
+	IncludePositions []string              `json:"include_positions,omitempty"`
+	ExcludePositions []string              `json:"exclude_positions,omitempty"`
+	AllowUserIDs     []string              `json:"allow_user_ids,omitempty"`
+	DenyUserIDs      []string              `json:"deny_user_ids,omitempty"`
 }
 
 // ===== Request =====
@@ -23,6 +40,15 @@ type CreatePostDTO struct {
 	PostAs       PostAs     `json:"postAs" validate:"required"`     // เดิมคือ rolePath
 	Visibility   Visibility `json:"visibility" validate:"required"` // เดิมคือ roleIds
 	OrgOfContent string     `json:"org_of_content"`
+
+	// Status ว่าง = เผยแพร่ทันที, draft = ร่าง, scheduled = ตั้งเวลาตาม PublishAt (RFC3339)
+	Status    string `json:"status,omitempty" form:"status"`
+	PublishAt string `json:"publishAt,omitempty" form:"publishAt"`
+}
+
+// PostScheduleDTO ตั้ง/เลื่อนเวลาเผยแพร่ของโพสต์ร่างหรือโพสต์ที่ตั้งเวลาไว้
+type PostScheduleDTO struct {
+	PublishAt time.Time `json:"publishAt"`
 }
 
 /* { request example
@@ -60,6 +86,7 @@ type CreatePostDTO struct {
 
 // ===== Success Response =====
 type PostResponse struct {
+	PostID       string     `json:"postId,omitempty" example:"68be742243c7f21d8421a0e7"`
 	UserID       string     `json:"userId"        example:"66c6248b98c56c39f018e7d2"`
 	Name         string     `json:"name"          example:"JY"`
 	Username     string     `json:"username"      example:"jy_smo"`
@@ -74,7 +101,9 @@ type PostResponse struct {
 	OrgOfContent string     `json:"org_of_content"` // this content belongs to which org (org_path)
 	CreatedAt    string     `json:"createdAt"     example:"2025-09-07T13:47:47Z"`
 	UpdatedAt    string     `json:"updatedAt"     example:"2025-09-07T13:47:47Z"`
+	EditedAt     string     `json:"editedAt,omitempty" example:"2025-09-08T09:00:00Z"` // มีค่าเมื่อโพสต์ถูกแก้ไขหลังสร้าง
 	Status       string     `json:"status" example:"active"`
+	PublishAt    string     `json:"publishAt,omitempty" example:"2025-09-10T08:00:00Z"` // status = scheduled
 	Isliked       bool       `json:"is_liked" example:"false"`
 }
 
@@ -0,0 +1,102 @@
+package dto
+
+import "time"
+
+type MembershipHolder struct {
+	MembershipID string `json:"membership_id"`
+	UserID       string `json:"user_id"`
+}
+
+// MembershipConflictResponse ตอบ 409 เมื่อตำแหน่ง exclusive_per_org มีผู้ดำรงอยู่แล้ว
+type MembershipConflictResponse struct {
+	Error       string             `json:"error"`
+	OrgPath     string             `json:"org_path"`
+	PositionKey string             `json:"position_key"`
+	Holders     []MembershipHolder `json:"holders"`
+	Resolution  string             `json:"resolution"`
+}
+
+type JoinRequestCreateDTO struct {
+	OrgPath     string `json:"org_path"`
+	PositionKey string `json:"position_key"`
+	Message     string `json:"message"`
+}
+
+type JoinRequestDecisionDTO struct {
+	Note        string     `json:"note"`
+	EndPrevious bool       `json:"end_previous"`
+	TermEnd     *time.Time `json:"term_end,omitempty"`
+}
+
+// OrgInviteCreateDTO ExpiresInHours ไม่ระบุ = 7 วัน, MaxUses 0 = ไม่จำกัด
+type OrgInviteCreateDTO struct {
+	OrgPath        string     `json:"org_path"`
+	PositionKey    string     `json:"position_key"`
+	ExpiresInHours int        `json:"expires_in_hours"`
+	MaxUses        int        `json:"max_uses"`
+	TermEnd        *time.Time `json:"term_end,omitempty"`
+}
+
+type HandoverAssignment struct {
+	UserID      string `json:"user_id"`
+	PositionKey string `json:"position_key"`
+}
+
+// HandoverDTO ส่งมอบตำแหน่งของ org_path ทั้งชุด
+// Positions ว่าง = ตำแหน่งทั้งหมดที่อยู่ใน Assignments (ตำแหน่งอื่นของ org ไม่ถูกแตะ)
+type HandoverDTO struct {
+	OrgPath     string               `json:"org_path"`
+	Assignments []HandoverAssignment `json:"assignments"`
+	Positions   []string             `json:"positions,omitempty"`
+	JoinedAt    *time.Time           `json:"joined_at,omitempty"`
+	TermEnd     *time.Time           `json:"term_end,omitempty"`
+	Note        string               `json:"note,omitempty"`
+}
+
+type HandoverPlanItem struct {
+	Action       string `json:"action"` // start | end | continue
+	UserID       string `json:"user_id"`
+	PositionKey  string `json:"position_key"`
+	MembershipID string `json:"membership_id,omitempty"`
+}
+
+type HandoverPlan struct {
+	OrgPath    string             `json:"org_path"`
+	Applied    bool               `json:"applied"`
+	HandoverID string             `json:"handover_id,omitempty"`
+	Items      []HandoverPlanItem `json:"items"`
+	Summary    map[string]int     `json:"summary"`
+}
+
+// UserMembershipItem ตำแหน่งหนึ่งของผู้ใช้พร้อม label ตามภาษาและชื่อหน่วยงาน
+type UserMembershipItem struct {
+	MembershipID  string            `json:"membership_id"`
+	OrgPath       string            `json:"org_path"`
+	OrgName       string            `json:"org_name"`
+	OrgShortName  string            `json:"org_short_name,omitempty"`
+	PositionKey   string            `json:"position_key"`
+	PositionLabel string            `json:"position_label"`
+	PositionRank  int               `json:"position_rank"`
+	Display       map[string]string `json:"display,omitempty"`
+	Active        bool              `json:"active"`
+	Current       bool              `json:"current"` // อยู่ในวาระตอนนี้
+	JoinedAt      *time.Time        `json:"joined_at,omitempty"`
+	TermEnd       *time.Time        `json:"term_end,omitempty"`
+	EndedAt       *time.Time        `json:"ended_at,omitempty"`
+	EndReason     string            `json:"end_reason,omitempty"`
+}
+
+// PostAsOption ตัวเลือก "โพสต์ในนาม" (org_path + position_key)
+type PostAsOption struct {
+	OrgPath       string `json:"org_path"`
+	OrgName       string `json:"org_name"`
+	PositionKey   string `json:"position_key"`
+	PositionLabel string `json:"position_label"`
+}
+
+type UserMembershipsResponse struct {
+	UserID          string               `json:"user_id"`
+	Memberships     []UserMembershipItem `json:"memberships"`
+	VisibleOrgPaths []string             `json:"visible_org_paths,omitempty"` // subtree ที่มองเห็น (เฉพาะตัวเอง/root)
+	PostAs          []PostAsOption       `json:"post_as,omitempty"`
+}
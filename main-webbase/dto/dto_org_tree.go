@@ -0,0 +1,62 @@
+package dto
+
+import "time"
+
+// OrgTreeDocument เอกสาร export/import โครงสร้างองค์กร (JSON หรือ YAML) ของ subtree หนึ่ง
+type OrgTreeDocument struct {
+	Version     int                  `json:"version" yaml:"version"`
+	Root        string               `json:"root" yaml:"root"`
+	ExportedAt  *time.Time           `json:"exported_at,omitempty" yaml:"exported_at,omitempty"`
+	Units       []OrgTreeUnit        `json:"units" yaml:"units"`
+	Positions   []OrgTreePosition    `json:"positions,omitempty" yaml:"positions,omitempty"`
+	Policies    []OrgTreePolicy      `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Memberships *[]OrgTreeMembership `json:"memberships,omitempty" yaml:"memberships,omitempty"` // nil = ไม่จัดการ membership
+}
+
+type OrgTreeUnit struct {
+	OrgPath string `json:"org_path" yaml:"org_path"`
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Status  string `json:"status,omitempty" yaml:"status,omitempty"` // active (default) | archived
+}
+
+type OrgTreePosition struct {
+	Key             string            `json:"key" yaml:"key"`
+	OrgPath         string            `json:"org_path" yaml:"org_path"`
+	Display         map[string]string `json:"display,omitempty" yaml:"display,omitempty"`
+	Rank            int               `json:"rank" yaml:"rank"`
+	Inherit         bool              `json:"inherit,omitempty" yaml:"inherit,omitempty"`
+	ExclusivePerOrg bool              `json:"exclusive_per_org,omitempty" yaml:"exclusive_per_org,omitempty"`
+	Status          string            `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+type OrgTreePolicy struct {
+	PositionKey string            `json:"position_key" yaml:"position_key"`
+	OrgPrefix   string            `json:"org_prefix" yaml:"org_prefix"`
+	Scope       string            `json:"scope" yaml:"scope"` // exact | subtree
+	Actions     []string          `json:"actions" yaml:"actions"`
+	Enabled     bool              `json:"enabled" yaml:"enabled"`
+	Effect      string            `json:"effect,omitempty" yaml:"effect,omitempty"` // allow (ค่าว่าง) | deny
+	Conditions  map[string]string `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+type OrgTreeMembership struct {
+	UserID      string `json:"user_id" yaml:"user_id"`
+	OrgPath     string `json:"org_path" yaml:"org_path"`
+	PositionKey string `json:"position_key" yaml:"position_key"`
+}
+
+// OrgTreePlanItem หนึ่งการเปลี่ยนแปลงที่ import จะทำ
+type OrgTreePlanItem struct {
+	Action  string   `json:"action"` // create | update | archive | restore
+	Kind    string   `json:"kind"`   // unit | position | policy | membership
+	Key     string   `json:"key"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type OrgTreePlan struct {
+	Root    string            `json:"root"`
+	Applied bool              `json:"applied"`
+	Items   []OrgTreePlanItem `json:"items"`
+	Summary map[string]int    `json:"summary"` // "create" -> n ...
+}
@@ -1,5 +1,11 @@
 package dto
 
+import (
+	"time"
+
+	"main-webbase/internal/models"
+)
+
 type OrgUnitNode struct {
 	OrgPath   string            `json:"org_path"`
 	Type      string            `json:"type,omitempty"`
@@ -24,8 +30,9 @@ type OrgUnitReport struct {
 }
 
 type OrgUnitTreeQuery struct {
-	Start string `query:"start"`
-	Depth int    `query:"depth"`
+	Start           string `query:"start"`
+	Depth           int    `query:"depth"`
+	IncludeArchived bool   `query:"include_archived"`
 }
 
 type OrgUnitTree struct {
@@ -33,6 +40,85 @@ type OrgUnitTree struct {
 	Type      string         `json:"type,omitempty"`
 	Label     string         `json:"label,omitempty"`
 	ShortName string         `json:"short_name,omitempty"`
+	Status    string         `json:"status,omitempty"`
 	Children  []*OrgUnitTree `json:"children,omitempty"`
 	Sort      int            `json:"-"`
-}
\ No newline at end of file
+}
+
+type OrgUnitMoveDTO struct {
+	OrgPath       string `json:"org_path"`                  // หน่วยงานที่จะย้าย/เปลี่ยนชื่อ
+	NewParentPath string `json:"new_parent_path,omitempty"` // ว่าง = parent เดิม
+	NewSlug       string `json:"new_slug,omitempty"`        // ว่าง = slug เดิม
+	NewName       string `json:"new_name,omitempty"`        // ว่าง = ชื่อเดิม
+}
+
+type OrgUnitMoveReport struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	NodesMoved int              `json:"nodes_moved"`
+	Updated    map[string]int64 `json:"updated"` // "collection.field" -> จำนวนเอกสารที่ถูกแก้
+}
+
+type OrgPathResolveResponse struct {
+	Requested  string `json:"requested"`
+	OrgPath    string `json:"org_path"`
+	Redirected bool   `json:"redirected"`
+}
+
+type OrgUnitArchiveDTO struct {
+	OrgPath string `json:"org_path"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type OrgUnitProfile struct {
+	OrgPath     string             `json:"org_path"`
+	ParentPath  string             `json:"parent_path"`
+	Name        string             `json:"name"`
+	DisplayName string             `json:"display_name"` // ชื่อตาม ?lang= (fallback เป็น name)
+	Names       map[string]string  `json:"names,omitempty"`
+	ShortName   string             `json:"short_name"`
+	Slug        string             `json:"slug,omitempty"`
+	Type        string             `json:"type"`
+	Status      string             `json:"status"`
+	Description string             `json:"description,omitempty"`
+	LogoURL     string             `json:"logo_url,omitempty"`
+	Contact     *models.OrgContact `json:"contact,omitempty"`
+	Social      map[string]string  `json:"social,omitempty"`
+	CanEdit     bool               `json:"can_edit"`
+
+	Stats          OrgUnitStats           `json:"stats"`
+	UpcomingEvents []OrgUnitUpcomingEvent `json:"upcoming_events"`
+}
+
+type OrgUnitStats struct {
+	MemberCount       int64                  `json:"member_count"`
+	MembersByPosition []OrgUnitPositionCount `json:"members_by_position"`
+	PostCount         int64                  `json:"post_count"`
+	EventCount        int64                  `json:"event_count"`
+	ChildCount        int64                  `json:"child_count"`
+}
+
+type OrgUnitPositionCount struct {
+	PositionKey string            `json:"position_key"`
+	Display     map[string]string `json:"display,omitempty"`
+	Count       int64             `json:"count"`
+}
+
+type OrgUnitUpcomingEvent struct {
+	EventID    string    `json:"event_id"`
+	Topic      string    `json:"topic"`
+	PictureURL *string   `json:"picture_url,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Location   *string   `json:"location,omitempty"`
+}
+
+// OrgUnitProfileUpdateDTO ส่งเฉพาะ field ที่ต้องการแก้ (nil = ไม่แก้)
+type OrgUnitProfileUpdateDTO struct {
+	Name        *string            `json:"name,omitempty"`
+	Names       map[string]string  `json:"names,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	LogoURL     *string            `json:"logo_url,omitempty"`
+	Contact     *models.OrgContact `json:"contact,omitempty"`
+	Social      map[string]string  `json:"social,omitempty"`
+}
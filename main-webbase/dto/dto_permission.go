@@ -0,0 +1,19 @@
+package dto
+
+import "main-webbase/internal/models"
+
+// PermissionCategory หมวดของ permission catalog พร้อม label อังกฤษ/ไทย
+type PermissionCategory struct {
+	Category    string              `json:"category"`
+	Label       string              `json:"label"`
+	LabelTH     string              `json:"label_th"`
+	Permissions []models.Permission `json:"permissions"`
+}
+
+// RoleTemplateDTO สร้าง/แก้ role template (ชุด action สำเร็จรูปสำหรับตำแหน่งใหม่)
+type RoleTemplateDTO struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	LabelTH     string   `json:"label_th"`
+	Permissions []string `json:"permissions"`
+}
@@ -5,4 +5,19 @@ type PolicyUpdateDTO struct {
 	Key    		string 	 `json:"key"`
 	Actions   	[]string `json:"actions"`
 	Enabled   	bool     `json:"enabled"`
-}
\ No newline at end of file
+}
+
+// PolicyRuleCreateDTO สร้างกฎเพิ่มเติมของตำแหน่ง เช่น deny หรือ allow ที่มีเงื่อนไข
+type PolicyRuleCreateDTO struct {
+	PositionKey string            `json:"position_key"`
+	OrgPrefix   string            `json:"org_prefix"`
+	Scope       string            `json:"scope"`  // exact | subtree
+	Effect      string            `json:"effect"` // allow | deny
+	Actions     []string          `json:"actions"`
+	Conditions  map[string]string `json:"conditions,omitempty"`
+}
+
+// PolicyRollbackDTO version ที่ต้องการคืนค่า (ดูได้จาก GET /policies/:id/history)
+type PolicyRollbackDTO struct {
+	Version int `json:"version"`
+}
@@ -16,5 +16,30 @@ type PositionCreateDTO struct {
 		Type        string    `bson:"type" json:"type"` // "exact" or "subtree"
 		Actions     []string  `bson:"actions" json:"actions"`
 		Enabled     bool      `bson:"enabled" json:"enabled"`
+		Role        string    `bson:"role,omitempty" json:"role,omitempty"` // ชื่อ role template (GET /permissions/roles) — action ถูกรวมเข้ากับ actions
 	} `bson:"policy" json:"policy"`
-}
\ No newline at end of file
+}
+
+// PositionUpdateDTO แก้เฉพาะ field ที่ส่งมา
+type PositionUpdateDTO struct {
+	Key         *string             `json:"key,omitempty"`
+	Display     map[string]string   `json:"display,omitempty"`
+	Rank        *int                `json:"rank,omitempty"`
+	Constraints *models.Constraints `json:"constraints,omitempty"`
+	Scope       *models.Scope       `json:"scope,omitempty"`
+	Status      *string             `json:"status,omitempty"` // active | deprecated
+}
+
+// PositionChangeReport ผลของการแก้/ลบตำแหน่ง พร้อมจำนวนเอกสารที่ถูก cascade
+type PositionChangeReport struct {
+	Position    *models.Position `json:"position,omitempty"`
+	Deleted     bool             `json:"deleted,omitempty"`
+	Replacement string           `json:"replacement,omitempty"`
+	Memberships int64            `json:"memberships"`
+	Ended       int64            `json:"ended,omitempty"` // ผู้ถือที่มีตำแหน่งแทนอยู่แล้ว → ปิด membership เดิม
+	Policies    int64            `json:"policies"`
+	Posts       int64            `json:"posts"`
+	Events      int64            `json:"events"`
+	Requests    int64            `json:"requests"`
+	Invites     int64            `json:"invites"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+type UploadReport struct {
+	ID        string `json:"id" example:"6710c1d2e3f4a5b6c7d8e9f0"`
+	FileName  string `json:"file_name" example:"clip.mp4"`
+	MimeType  string `json:"mime_type" example:"video/mp4"`
+	Length    int64  `json:"length" example:"10485760"`
+	Offset    int64  `json:"offset" example:"4194304"`
+	Status    string `json:"status" example:"uploading" enums:"uploading,completed,aborted"`
+	StreamURL string `json:"stream_url,omitempty" example:"/media/videos/6710c1d2e3f4a5b6c7d8e9f0"`
+	PublicURL string `json:"public_url,omitempty" example:"http://45.144.166.252:46602/uploads/66c6_1760359209163.mp4"`
+}
+
+type UploadQuotaResponse struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+}
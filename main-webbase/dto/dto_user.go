@@ -28,4 +28,22 @@ type UserProfileDTO struct {
 
 type ErrorResponse struct {
 	Error string `json:"error" example:"invalid body"`
-}
\ No newline at end of file
+}
+// ImpersonationCreateDTO ขอ token ดูในมุมของผู้ใช้ (root เท่านั้น)
+type ImpersonationCreateDTO struct {
+	UserID     string `json:"user_id"`
+	Reason     string `json:"reason"`
+	TTLMinutes int    `json:"ttl_minutes,omitempty"` // ค่าเริ่มต้น 15, สูงสุด 60
+}
+
+// ImpersonationTokenResponse token อ่านอย่างเดียวของ session
+type ImpersonationTokenResponse struct {
+	AccessToken string                       `json:"accessToken"`
+	Session     models.ImpersonationSession `json:"session"`
+}
+
+// ImpersonationSessionView session พร้อม request ทั้งหมดที่ใช้ token นั้น
+type ImpersonationSessionView struct {
+	models.ImpersonationSession
+	Requests []models.ImpersonationAudit `json:"requests"`
+}
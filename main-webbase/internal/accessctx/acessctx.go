@@ -2,12 +2,13 @@ package accessctx
 
 import (
 	"context"
-	"slices"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
 )
 
 type MembershipSummary struct {
@@ -27,12 +28,15 @@ type ViewerAccess struct {
 // -------------------------
 
 // BuildViewerAccess สร้างภาพรวมการเข้าถึงของผู้ใช้ปัจจุบัน
+// ใช้ org tree จาก cache (LoadOrgTree) จึงเหลือ query เดียวคือ memberships ของ user
+// ส่วน middleware ควรเรียกผ่าน CachedViewerAccess
 func BuildViewerAccess(ctx context.Context, db *mongo.Database, userID bson.ObjectID) (*ViewerAccess, error) {
 	mCol     := db.Collection("memberships")
-	nodeCol  := db.Collection("org_units")
 
-	// 2) memberships ที่ active ของ user
-	cur, err := mCol.Find(ctx, bson.M{"user_id": userID, "active": true})
+	// 2) memberships ที่ active และอยู่ในวาระของ user (term_end ที่เลยแล้วหลุดออกเองแม้ job ยังไม่รัน)
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["user_id"] = userID
+	cur, err := mCol.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +55,11 @@ func BuildViewerAccess(ctx context.Context, db *mongo.Database, userID bson.Obje
 		}, nil
 	}
 
+	tree, err := LoadOrgTree(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
 	// 3) หา node_id ของแต่ละ org_path ที่ user เป็นสมาชิก
 	//    และคำนวณ subtree: nodes ที่ path == org_path หรือ ancestors มี org_path
 	//    ทำเป็น union จากทุก membership
@@ -60,8 +69,7 @@ func BuildViewerAccess(ctx context.Context, db *mongo.Database, userID bson.Obje
 
 	for _, m := range ms {
 		// 3.1 หา node ของ path นี้เพื่อเก็บ node_id ใน summary
-		var node models.OrgUnitNode
-		if err := nodeCol.FindOne(ctx, bson.M{"org_path": m.OrgPath, "status": "active"}).Decode(&node); err == nil {
+		if node, ok := tree.ByPath[m.OrgPath]; ok && node.Status == "active" {
 			summaries = append(summaries, MembershipSummary{
 				NodeID:  node.ID,
 				OrgPath: m.OrgPath,
@@ -75,46 +83,37 @@ func BuildViewerAccess(ctx context.Context, db *mongo.Database, userID bson.Obje
 			})
 		}
 
-		// 3.2 ดึงซับทรีสำหรับ path นี้
-		//     filter: { $or: [ {path: m.OrgPath}, {ancestors: m.OrgPath} ] }
-		subCur, err := nodeCol.Find(ctx, bson.M{
-			"status": "active",
-			"$or": bson.A{
-				bson.M{"org_path": m.OrgPath},
-				bson.M{"ancestors": m.OrgPath},
-			},
-		})
-		if err != nil {
-			return nil, err
-		}
-		var subNodes []models.OrgUnitNode
-		if err := subCur.All(ctx, &subNodes); err != nil {
-			return nil, err
-		}
-		for _, n := range subNodes {
+		// 3.2 ซับทรีสำหรับ path นี้ (เฉพาะ node ที่ active)
+		for _, n := range tree.Subtree(m.OrgPath) {
+			if n.Status != "active" {
+				continue
+			}
 			pathSet[n.OrgPath] = struct{}{}
 			nodeIDSet[n.ID] = struct{}{}
 		}
 	}
 
-	paths := make([]string, 0, len(pathSet))
-	for p := range pathSet {
-		paths = append(paths, p)
-	}
 	nodeIDs := make([]bson.ObjectID, 0, len(nodeIDSet))
 	for id := range nodeIDSet {
 		nodeIDs = append(nodeIDs, id)
 	}
-	// ทำให้อ่านง่าย / deterministic
-	slices.Sort(paths)
 
 	return &ViewerAccess{
 		Memberships:     summaries,
-		SubtreePaths:    paths,
+		SubtreePaths:    sortedPaths(pathSet), // ทำให้อ่านง่าย / deterministic
 		SubtreeNodeIDs:  nodeIDs,
 	}, nil
 }
 
+// Roles membership ของผู้ดูในรูปที่ feed ใช้จับคู่กับ targeting ของโพสต์
+func (v *ViewerAccess) Roles() []models.ViewerRole {
+	out := make([]models.ViewerRole, 0, len(v.Memberships))
+	for _, m := range v.Memberships {
+		out = append(out, models.ViewerRole{OrgPath: m.OrgPath, PositionKey: m.PosKey})
+	}
+	return out
+}
+
 // VisibilityMatch สร้างเงื่อนไขกรองโพสต์:
 // - public: คือโพสต์ที่ "ไม่มี" role_visibility
 // - private: role_visibility ∈ viewer.SubtreeNodeIDs
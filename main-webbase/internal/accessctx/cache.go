@@ -0,0 +1,278 @@
+package accessctx
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+)
+
+// ViewerCacheTTL กันข้อมูลค้างกรณีมีการเขียนจากที่อื่นที่ไม่ผ่าน event bus และ change stream ใช้ไม่ได้
+const ViewerCacheTTL = 5 * time.Minute
+
+// -------------------------
+// Metrics
+// -------------------------
+
+type cacheCounters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+type CacheStats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	Invalidations uint64  `json:"invalidations"`
+	HitRate       float64 `json:"hit_rate"`
+	Entries       int     `json:"entries"`
+}
+
+func (c *cacheCounters) stats(entries int) CacheStats {
+	h, m := c.hits.Load(), c.misses.Load()
+	s := CacheStats{Hits: h, Misses: m, Invalidations: c.invalidations.Load(), Entries: entries}
+	if h+m > 0 {
+		s.HitRate = float64(h) / float64(h+m)
+	}
+	return s
+}
+
+// -------------------------
+// Org tree cache
+// -------------------------
+
+// OrgTree คือ snapshot ของ org_units ทั้งหมด (ทุกสถานะ) เรียงตาม org_path
+type OrgTree struct {
+	Nodes    []models.OrgUnitNode
+	ByPath   map[string]*models.OrgUnitNode
+	LoadedAt time.Time
+}
+
+// Subtree คืน node ที่ org_path == root หรือมี root อยู่ใน ancestors (เหมือน repo.FindByPrefix)
+func (t *OrgTree) Subtree(root string) []models.OrgUnitNode {
+	out := []models.OrgUnitNode{}
+	for _, n := range t.Nodes {
+		if n.OrgPath == root || slices.Contains(n.Ancestors, root) {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+var (
+	treeMu      sync.RWMutex
+	tree        *OrgTree
+	treeGen     uint64
+	treeMetrics cacheCounters
+)
+
+// LoadOrgTree คืน org tree จาก cache (โหลดใหม่ถ้าถูก invalidate)
+func LoadOrgTree(ctx context.Context, db *mongo.Database) (*OrgTree, error) {
+	treeMu.RLock()
+	t, gen := tree, treeGen
+	treeMu.RUnlock()
+	if t != nil {
+		treeMetrics.hits.Add(1)
+		return t, nil
+	}
+	treeMetrics.misses.Add(1)
+
+	cur, err := db.Collection("org_units").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "org_path", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var nodes []models.OrgUnitNode
+	if err := cur.All(ctx, &nodes); err != nil {
+		return nil, err
+	}
+	t = &OrgTree{Nodes: nodes, ByPath: make(map[string]*models.OrgUnitNode, len(nodes)), LoadedAt: time.Now()}
+	for i := range t.Nodes {
+		t.ByPath[t.Nodes[i].OrgPath] = &t.Nodes[i]
+	}
+
+	treeMu.Lock()
+	if treeGen == gen { // ไม่มีการ invalidate ระหว่างโหลด
+		tree = t
+	}
+	treeMu.Unlock()
+	return t, nil
+}
+
+func InvalidateOrgTree() {
+	treeMu.Lock()
+	tree = nil
+	treeGen++
+	treeMu.Unlock()
+	treeMetrics.invalidations.Add(1)
+}
+
+// -------------------------
+// Viewer access cache
+// -------------------------
+
+type viewerEntry struct {
+	v       *ViewerAccess
+	expires time.Time
+}
+
+var (
+	viewerMu      sync.RWMutex
+	viewers       = map[bson.ObjectID]viewerEntry{}
+	viewerGen     uint64
+	viewerMetrics cacheCounters
+)
+
+// CachedViewerAccess คืน ViewerAccess จาก cache หรือสร้างใหม่ด้วย BuildViewerAccess
+// ค่าที่คืนถูกแชร์ระหว่าง request ห้ามแก้ไข
+func CachedViewerAccess(ctx context.Context, db *mongo.Database, userID bson.ObjectID) (*ViewerAccess, error) {
+	now := time.Now()
+	viewerMu.RLock()
+	e, ok := viewers[userID]
+	gen := viewerGen
+	viewerMu.RUnlock()
+	if ok && now.Before(e.expires) {
+		viewerMetrics.hits.Add(1)
+		return e.v, nil
+	}
+	viewerMetrics.misses.Add(1)
+
+	v, err := BuildViewerAccess(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerMu.Lock()
+	if viewerGen == gen {
+		viewers[userID] = viewerEntry{v: v, expires: now.Add(ViewerCacheTTL)}
+	}
+	viewerMu.Unlock()
+	return v, nil
+}
+
+func InvalidateViewer(userIDs ...bson.ObjectID) {
+	viewerMu.Lock()
+	for _, id := range userIDs {
+		delete(viewers, id)
+	}
+	viewerGen++
+	viewerMu.Unlock()
+	viewerMetrics.invalidations.Add(1)
+}
+
+func InvalidateAllViewers() {
+	viewerMu.Lock()
+	viewers = map[bson.ObjectID]viewerEntry{}
+	viewerGen++
+	viewerMu.Unlock()
+	viewerMetrics.invalidations.Add(1)
+}
+
+// Metrics คืนสถิติของทั้งสอง cache (ใช้ใน /admin/cache/metrics)
+func Metrics() map[string]CacheStats {
+	treeMu.RLock()
+	treeEntries := 0
+	if tree != nil {
+		treeEntries = len(tree.Nodes)
+	}
+	treeMu.RUnlock()
+
+	viewerMu.RLock()
+	viewerEntries := len(viewers)
+	viewerMu.RUnlock()
+
+	return map[string]CacheStats{
+		"org_tree":      treeMetrics.stats(treeEntries),
+		"viewer_access": viewerMetrics.stats(viewerEntries),
+	}
+}
+
+func handleChange(e changebus.Event) {
+	switch e.Topic {
+	case changebus.TopicOrgUnits:
+		// subtree ของทุกคนขึ้นกับ tree
+		InvalidateOrgTree()
+		InvalidateAllViewers()
+	case changebus.TopicMemberships:
+		if len(e.UserIDs) == 0 {
+			InvalidateAllViewers()
+		} else {
+			InvalidateViewer(e.UserIDs...)
+		}
+	}
+}
+
+func init() {
+	changebus.Subscribe(handleChange)
+}
+
+// WatchChanges ฟัง change stream ของ memberships/org_units (ต้องเป็น replica set)
+// เพื่อ invalidate cache เมื่อมีการเขียนจาก process อื่น ถ้าใช้ไม่ได้จะ retry เป็นระยะ
+// ระหว่างนั้น cache ยังถูก invalidate จาก event bus ใน process และหมดอายุตาม TTL
+func WatchChanges(ctx context.Context, db *mongo.Database) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"ns.coll": bson.M{"$in": bson.A{"memberships", "org_units"}}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	for {
+		stream, err := db.Watch(ctx, pipeline, opts)
+		if err != nil {
+			log.Printf("[accessctx] change stream unavailable (%v); relying on in-process invalidation", err)
+		} else {
+			for stream.Next(ctx) {
+				var ev struct {
+					NS struct {
+						Coll string `bson:"coll"`
+					} `bson:"ns"`
+					FullDocument struct {
+						UserID bson.ObjectID `bson:"user_id"`
+					} `bson:"fullDocument"`
+				}
+				if err := stream.Decode(&ev); err != nil {
+					continue
+				}
+				if ev.NS.Coll == "org_units" {
+					handleChange(changebus.Event{Topic: changebus.TopicOrgUnits})
+				} else if ev.FullDocument.UserID.IsZero() {
+					handleChange(changebus.Event{Topic: changebus.TopicMemberships})
+				} else {
+					handleChange(changebus.Event{Topic: changebus.TopicMemberships, UserIDs: []bson.ObjectID{ev.FullDocument.UserID}})
+				}
+			}
+			if err := stream.Err(); err != nil && ctx.Err() == nil {
+				log.Printf("[accessctx] change stream closed: %v", err)
+			}
+			stream.Close(context.Background())
+			// ระหว่างที่ stream หลุดอาจพลาด event -> ล้างทั้งหมดให้ชัวร์
+			InvalidateOrgTree()
+			InvalidateAllViewers()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// sortedPaths helper ให้ผลลัพธ์ deterministic
+func sortedPaths(set map[string]struct{}) []string {
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
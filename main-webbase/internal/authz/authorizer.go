@@ -0,0 +1,229 @@
+package authz
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// Subject ผู้ขอสิทธิ์: policy ปัจจุบัน + เป็น root (membership ที่ "/") หรือไม่
+type Subject struct {
+	UserID   bson.ObjectID
+	Policies []models.Policy
+	IsRoot   bool
+}
+
+// Target เป้าหมายของ action: org path ที่ใช้ตัดสิน, เจ้าของ (ถ้ามี) และ attribute
+// สำหรับเงื่อนไขของ policy (เช่น status ของ event)
+// OrgPath ว่าง = ไม่ผูกกับ org ใด → ต้องมี policy ที่ให้ action นี้ที่ไหนก็ได้
+type Target struct {
+	OrgPath string
+	Owners  []bson.ObjectID
+	Attrs   map[string]string
+}
+
+// Reasons ของ Decision
+const (
+	ReasonRoot          = "root"
+	ReasonPolicy        = "policy"
+	ReasonOwner         = "owner"
+	ReasonSelf          = "self"
+	ReasonUnknownAction = "unknown action"
+	ReasonNoPolicy      = "no matching policy"
+	ReasonRootOnly      = "root only"
+	ReasonDenied        = "explicit deny"
+)
+
+type Decision struct {
+	Allowed bool           `json:"allowed"`
+	Action  string         `json:"action"`
+	OrgPath string         `json:"org_path"`
+	Reason  string         `json:"reason"`
+	Policy  *models.Policy `json:"policy,omitempty"` // policy ที่ให้สิทธิ์ (Reason = policy) หรือที่ห้าม (Reason = explicit deny)
+}
+
+// Elevated = ได้สิทธิ์จาก root หรือ policy (ไม่ใช่แค่เป็นเจ้าของ) ใช้เปิดทางให้ moderator แก้ของคนอื่น
+func (d Decision) Elevated() bool {
+	return d.Allowed && (d.Reason == ReasonRoot || d.Reason == ReasonPolicy)
+}
+
+// Authorize ตัวตัดสินสิทธิ์กลางของทั้งระบบ ลำดับการตัดสิน (ตายตัว):
+//  1. action ที่ไม่รู้จัก → ปฏิเสธ
+//  2. root → อนุญาต (ไม่สน deny)
+//  3. action แบบ system → root เท่านั้น, แบบ self → อนุญาต
+//  4. deny policy ที่ครอบคลุมเป้าหมาย (ตรง action, เงื่อนไขตรง) → ปฏิเสธ แม้จะมี allow หรือเป็นเจ้าของ
+//  5. allow policy ที่ครอบคลุม (action เอง หรือ action ที่ imply) → อนุญาต
+//     ถ้ามีหลายอัน เลือกอันที่เฉพาะเจาะจงที่สุด: org_prefix ลึกกว่า > exact ก่อน subtree > _id น้อยกว่า
+//  6. เป็นเจ้าของและ action ยอมให้เจ้าของ → อนุญาต
+//  7. นอกนั้นปฏิเสธ
+//
+// policy ถูกมองว่า "ครอบคลุม" เมื่อ enabled, scope exact ตรง path หรือ subtree จาก path เอง/ancestor
+// และ conditions ทุกข้อตรงกับ Target.Attrs (attribute ที่ไม่มีถือว่าไม่ตรง)
+func Authorize(s Subject, action string, t Target) Decision {
+	d := Decision{Action: action, OrgPath: t.OrgPath}
+
+	a, ok := Lookup(action)
+	if !ok {
+		d.Reason = ReasonUnknownAction
+		return d
+	}
+	if s.IsRoot {
+		d.Allowed, d.Reason = true, ReasonRoot
+		return d
+	}
+
+	switch a.Kind {
+	case KindSystem:
+		d.Reason = ReasonRootOnly
+		return d
+	case KindSelf:
+		d.Allowed, d.Reason = true, ReasonSelf
+		return d
+	}
+
+	grantable := append([]string{action}, a.ImpliedBy...)
+	if t.OrgPath == "" {
+		// "ที่ไหนก็ได้": allow ตัวแรก (ตามลำดับ specificity) ที่ไม่ถูก deny ณ org ของมันเอง
+		for _, p := range matching(s.Policies, grantable, t, false) {
+			if DenyingPolicy(s.Policies, action, Target{OrgPath: p.OrgPrefix, Attrs: t.Attrs}) == nil {
+				d.Allowed, d.Reason, d.Policy = true, ReasonPolicy, p
+				return d
+			}
+		}
+	} else {
+		if p := DenyingPolicy(s.Policies, action, t); p != nil {
+			d.Reason, d.Policy = ReasonDenied, p
+			return d
+		}
+		if ps := matching(s.Policies, grantable, t, false); len(ps) > 0 {
+			d.Allowed, d.Reason, d.Policy = true, ReasonPolicy, ps[0]
+			return d
+		}
+	}
+	if a.OwnerAllowed && !s.UserID.IsZero() && slices.Contains(t.Owners, s.UserID) {
+		if t.OrgPath == "" || DenyingPolicy(s.Policies, action, t) == nil {
+			d.Allowed, d.Reason = true, ReasonOwner
+			return d
+		}
+	}
+	d.Reason = ReasonNoPolicy
+	return d
+}
+
+// GrantingPolicies allow policy ทั้งหมดที่ให้ action (หรือ action ที่ imply) ครอบคลุม t.OrgPath
+// เรียงตาม specificity — ว่างถ้าโดน deny ที่เป้าหมาย
+func GrantingPolicies(policies []models.Policy, action string, t Target) []*models.Policy {
+	a, ok := Lookup(action)
+	if !ok || t.OrgPath == "" || DenyingPolicy(policies, action, t) != nil {
+		return nil
+	}
+	return matching(policies, append([]string{action}, a.ImpliedBy...), t, false)
+}
+
+// DenyingPolicy deny policy ที่เฉพาะเจาะจงที่สุดซึ่งห้าม action นี้ที่เป้าหมาย (nil = ไม่มี)
+// deny จับคู่กับ action ตรงตัวเท่านั้น ไม่ขยายตาม ImpliedBy
+func DenyingPolicy(policies []models.Policy, action string, t Target) *models.Policy {
+	if t.OrgPath == "" {
+		return nil
+	}
+	if ps := matching(policies, []string{action}, t, true); len(ps) > 0 {
+		return ps[0]
+	}
+	return nil
+}
+
+// matching หา policy (allow หรือ deny ตาม deny) ที่ enabled, มี action ใด action หนึ่ง,
+// ครอบคลุม t.OrgPath และเงื่อนไขตรง เรียงตาม specificity — orgPath ว่าง = ที่ไหนก็ได้
+func matching(policies []models.Policy, actions []string, t Target, deny bool) []*models.Policy {
+	var covering []string
+	if t.OrgPath != "" {
+		covering = append(utils.OrgAncestors(t.OrgPath), t.OrgPath)
+	}
+	var out []*models.Policy
+	for i := range policies {
+		if skipReason(&policies[i], actions, t, covering, deny) == "" {
+			out = append(out, &policies[i])
+		}
+	}
+	slices.SortStableFunc(out, compareSpecificity)
+	return out
+}
+
+// เหตุผลที่ policy ไม่ถูกนับ (ใช้ร่วมกันระหว่าง matching และ Explain)
+const (
+	SkipDisabled   = "disabled"
+	SkipEffect     = "effect not considered"
+	SkipAction     = "missing action"
+	SkipConditions = "conditions not met"
+	SkipScope      = "wrong scope"
+)
+
+// skipReason "" = policy ครอบคลุมเป้าหมาย, นอกนั้นคือเหตุผลแรกที่ไม่ผ่าน
+func skipReason(p *models.Policy, actions []string, t Target, covering []string, deny bool) string {
+	if !p.Enabled {
+		return SkipDisabled
+	}
+	if p.IsDeny() != deny {
+		return SkipEffect
+	}
+	if !slices.ContainsFunc(actions, func(act string) bool { return slices.Contains(p.Actions, act) }) {
+		return SkipAction
+	}
+	if !conditionsMatch(p.Conditions, t.Attrs) {
+		return SkipConditions
+	}
+	switch {
+	case t.OrgPath == "":
+	case p.Scope == "exact" && p.OrgPrefix == t.OrgPath:
+	case p.Scope == "subtree" && slices.Contains(covering, p.OrgPrefix):
+	default:
+		return SkipScope
+	}
+	return ""
+}
+
+func conditionsMatch(cond, attrs map[string]string) bool {
+	for k, v := range cond {
+		got, ok := attrs[k]
+		if !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// compareSpecificity org_prefix ลึกกว่าก่อน, exact ก่อน subtree, มีเงื่อนไขก่อนไม่มี, แล้ว _id
+func compareSpecificity(a, b *models.Policy) int {
+	if da, db := depth(a.OrgPrefix), depth(b.OrgPrefix); da != db {
+		return db - da
+	}
+	if a.Scope != b.Scope {
+		if a.Scope == "exact" {
+			return -1
+		}
+		return 1
+	}
+	if la, lb := len(a.Conditions), len(b.Conditions); la != lb {
+		return lb - la
+	}
+	return strings.Compare(a.ID.Hex(), b.ID.Hex())
+}
+
+func depth(path string) int {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return 0
+	}
+	return strings.Count(path, "/") + 1
+}
+
+// LogDenied log การปฏิเสธในรูปแบบเดียวกันทุกที่
+func LogDenied(s Subject, d Decision, where string) {
+	log.Printf("[authz] deny user=%s action=%s org=%q reason=%q at=%s",
+		s.UserID.Hex(), d.Action, d.OrgPath, d.Reason, where)
+}
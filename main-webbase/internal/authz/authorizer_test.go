@@ -0,0 +1,368 @@
+package authz
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/models"
+)
+
+func testID(n byte) bson.ObjectID {
+	var id bson.ObjectID
+	id[11] = n
+	return id
+}
+
+func allow(n byte, prefix, scope string, actions ...string) models.Policy {
+	return models.Policy{ID: testID(n), PositionKey: "p", OrgPrefix: prefix, Scope: scope, Actions: actions, Enabled: true}
+}
+
+func deny(n byte, prefix, scope string, actions ...string) models.Policy {
+	p := allow(n, prefix, scope, actions...)
+	p.Effect = models.PolicyEffectDeny
+	return p
+}
+
+func when(p models.Policy, cond map[string]string) models.Policy {
+	p.Conditions = cond
+	return p
+}
+
+func disabled(p models.Policy) models.Policy {
+	p.Enabled = false
+	return p
+}
+
+func TestAuthorize(t *testing.T) {
+	owner := testID(100)
+	other := testID(101)
+
+	cases := []struct {
+		name     string
+		subject  Subject
+		action   string
+		target   Target
+		allowed  bool
+		reason   string
+		policyID byte // 0 = ไม่เช็ค Decision.Policy
+	}{
+		{
+			name:   "unknown action",
+			action: "nope:nope",
+			target: Target{OrgPath: "/fac"},
+			reason: ReasonUnknownAction,
+		},
+		{
+			name:    "root ignores deny",
+			subject: Subject{IsRoot: true, Policies: []models.Policy{deny(1, "/", "subtree", "event:create")}},
+			action:  "event:create",
+			target:  Target{OrgPath: "/fac"},
+			allowed: true,
+			reason:  ReasonRoot,
+		},
+		{
+			name:    "system action is root only",
+			subject: Subject{Policies: []models.Policy{allow(1, "/", "subtree", "system:admin")}},
+			action:  "system:admin",
+			reason:  ReasonRootOnly,
+		},
+		{
+			name:    "self action",
+			action:  "post:like",
+			allowed: true,
+			reason:  ReasonSelf,
+		},
+
+		// deny ชนะ allow
+		{
+			name: "deny beats allow at the same prefix",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac/eng", "subtree", "event:create"),
+				deny(2, "/fac/eng", "subtree", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng/smo"},
+			reason:   ReasonDenied,
+			policyID: 2,
+		},
+		{
+			name: "deny at an ancestor beats a deeper exact allow",
+			subject: Subject{Policies: []models.Policy{
+				deny(1, "/fac", "subtree", "event:create"),
+				allow(2, "/fac/eng/smo", "exact", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng/smo"},
+			reason:   ReasonDenied,
+			policyID: 1,
+		},
+		{
+			name: "exact deny at an ancestor does not reach the child",
+			subject: Subject{Policies: []models.Policy{
+				deny(1, "/fac", "exact", "event:create"),
+				allow(2, "/fac", "subtree", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+		{
+			name: "deny beats ownership",
+			subject: Subject{UserID: owner, Policies: []models.Policy{
+				deny(1, "/fac", "subtree", "post:update"),
+			}},
+			action:   "post:update",
+			target:   Target{OrgPath: "/fac/eng", Owners: []bson.ObjectID{owner}},
+			reason:   ReasonDenied,
+			policyID: 1,
+		},
+		{
+			name: "disabled deny is ignored",
+			subject: Subject{Policies: []models.Policy{
+				disabled(deny(1, "/fac", "subtree", "event:create")),
+				allow(2, "/fac", "subtree", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+
+		// specificity
+		{
+			name: "exact before subtree at the same prefix",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac/eng", "subtree", "event:create"),
+				allow(2, "/fac/eng", "exact", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+		{
+			name: "deeper prefix first",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "event:create"),
+				allow(2, "/fac/eng", "subtree", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng/smo"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+		{
+			name: "lower _id breaks ties",
+			subject: Subject{Policies: []models.Policy{
+				allow(2, "/fac", "subtree", "event:create"),
+				allow(1, "/fac", "subtree", "event:create"),
+			}},
+			action:   "event:create",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "exact allow does not cover the child",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "exact", "event:create"),
+			}},
+			action: "event:create",
+			target: Target{OrgPath: "/fac/eng"},
+			reason: ReasonNoPolicy,
+		},
+
+		// conditions
+		{
+			name: "conditional deny applies when the attribute matches",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "event:create"),
+				when(deny(2, "/fac", "subtree", "event:update"), map[string]string{"status": "draft"}),
+			}},
+			action:   "event:update",
+			target:   Target{OrgPath: "/fac/eng", Attrs: map[string]string{"status": "draft"}},
+			reason:   ReasonDenied,
+			policyID: 2,
+		},
+		{
+			name: "conditional deny skipped when the attribute differs",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "event:create"),
+				when(deny(2, "/fac", "subtree", "event:update"), map[string]string{"status": "draft"}),
+			}},
+			action:   "event:update",
+			target:   Target{OrgPath: "/fac/eng", Attrs: map[string]string{"status": "active"}},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "missing attribute does not match a conditional deny",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "event:create"),
+				when(deny(2, "/fac", "subtree", "event:update"), map[string]string{"status": "draft"}),
+			}},
+			action:   "event:update",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "missing attribute does not match a conditional allow",
+			subject: Subject{Policies: []models.Policy{
+				when(allow(1, "/fac", "subtree", "event:update"), map[string]string{"status": "draft"}),
+			}},
+			action: "event:update",
+			target: Target{OrgPath: "/fac/eng"},
+			reason: ReasonNoPolicy,
+		},
+		{
+			name: "conditional allow before unconditional at the same prefix and scope",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "event:update"),
+				when(allow(2, "/fac", "subtree", "event:update"), map[string]string{"status": "draft"}),
+			}},
+			action:   "event:update",
+			target:   Target{OrgPath: "/fac", Attrs: map[string]string{"status": "draft"}},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+
+		// ImpliedBy
+		{
+			name: "implied action grants",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "post:moderate"),
+			}},
+			action:   "post:update",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "deny of the implying action does not deny the implied one",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "post:moderate"),
+				deny(2, "/fac", "subtree", "post:moderate"),
+			}},
+			action:   "post:update",
+			target:   Target{OrgPath: "/fac/eng"},
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "implication is one way",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac", "subtree", "post:update"),
+			}},
+			action: "post:moderate",
+			target: Target{OrgPath: "/fac/eng"},
+			reason: ReasonNoPolicy,
+		},
+
+		// OwnerAllowed
+		{
+			name:    "owner without policy",
+			subject: Subject{UserID: owner},
+			action:  "post:update",
+			target:  Target{OrgPath: "/fac/eng", Owners: []bson.ObjectID{owner}},
+			allowed: true,
+			reason:  ReasonOwner,
+		},
+		{
+			name:    "not the owner",
+			subject: Subject{UserID: other},
+			action:  "post:update",
+			target:  Target{OrgPath: "/fac/eng", Owners: []bson.ObjectID{owner}},
+			reason:  ReasonNoPolicy,
+		},
+		{
+			name:    "owner of an action without OwnerAllowed",
+			subject: Subject{UserID: owner},
+			action:  "event:create",
+			target:  Target{OrgPath: "/fac/eng", Owners: []bson.ObjectID{owner}},
+			reason:  ReasonNoPolicy,
+		},
+		{
+			name:    "zero user id is never the owner",
+			subject: Subject{},
+			action:  "post:update",
+			target:  Target{OrgPath: "/fac/eng", Owners: []bson.ObjectID{{}}},
+			reason:  ReasonNoPolicy,
+		},
+
+		// OrgPath == "" (ที่ไหนก็ได้)
+		{
+			name: "anywhere: any allow grants",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/fac/eng", "exact", "event:create"),
+			}},
+			action:   "event:create",
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 1,
+		},
+		{
+			name: "anywhere: allow denied at its own org does not count",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/a", "subtree", "event:create"),
+				deny(2, "/a", "exact", "event:create"),
+			}},
+			action: "event:create",
+			reason: ReasonNoPolicy,
+		},
+		{
+			name: "anywhere: falls through to the next allow that is not denied",
+			subject: Subject{Policies: []models.Policy{
+				allow(1, "/a", "subtree", "event:create"),
+				allow(2, "/b", "subtree", "event:create"),
+				deny(3, "/a", "exact", "event:create"),
+			}},
+			action:   "event:create",
+			allowed:  true,
+			reason:   ReasonPolicy,
+			policyID: 2,
+		},
+		{
+			name:    "anywhere: owner",
+			subject: Subject{UserID: owner},
+			action:  "post:update",
+			target:  Target{Owners: []bson.ObjectID{owner}},
+			allowed: true,
+			reason:  ReasonOwner,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := func(t *testing.T, s Subject) {
+				d := Authorize(s, tc.action, tc.target)
+				if d.Allowed != tc.allowed || d.Reason != tc.reason {
+					t.Fatalf("got allowed=%v reason=%q, want allowed=%v reason=%q", d.Allowed, d.Reason, tc.allowed, tc.reason)
+				}
+				if tc.policyID != 0 && (d.Policy == nil || d.Policy.ID != testID(tc.policyID)) {
+					t.Fatalf("got policy %v, want %s", d.Policy, testID(tc.policyID).Hex())
+				}
+			}
+			check(t, tc.subject)
+
+			// ลำดับของ policy ที่ส่งเข้ามาต้องไม่มีผลกับผล
+			reversed := tc.subject
+			reversed.Policies = slices.Clone(tc.subject.Policies)
+			slices.Reverse(reversed.Policies)
+			check(t, reversed)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package authz
+
+import (
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// PolicyTrace ผลการพิจารณา policy หนึ่งตัวของ subject
+//   - Matched: ครอบคลุมเป้าหมาย (allow ที่ให้ action/ImpliedBy หรือ deny ที่ห้าม action ตรงตัว)
+//   - Skipped: เหตุผลที่ไม่นับ (disabled, missing action, conditions not met, wrong scope, ...)
+//   - Decisive: เป็น policy ที่ Decision อ้างถึง
+type PolicyTrace struct {
+	Policy   models.Policy `json:"policy"`
+	Effect   string        `json:"effect"`
+	Matched  bool          `json:"matched"`
+	Skipped  string        `json:"skipped,omitempty"`
+	Decisive bool          `json:"decisive,omitempty"`
+}
+
+// Trace คำอธิบายการตัดสินทั้งหมด — Decision มาจาก Authorize ตัวเดียวกับที่ handler ใช้
+type Trace struct {
+	Decision  Decision      `json:"decision"`
+	Kind      string        `json:"kind,omitempty"`
+	IsRoot    bool          `json:"is_root"`
+	Grantable []string      `json:"grantable,omitempty"` // action + ImpliedBy ที่ allow policy ให้แทนได้
+	Policies  []PolicyTrace `json:"policies"`
+}
+
+// SkipDeniedAtPrefix allow ที่ครอบคลุมแต่ถูก deny ณ org_prefix ของมันเอง (กรณีเป้าหมายไม่ผูก org)
+const SkipDeniedAtPrefix = "denied at its org_prefix"
+
+// Explain ตัดสินด้วย Authorize แล้วไล่ทุก policy ของ subject ด้วยเงื่อนไขเดียวกับ matching
+func Explain(s Subject, action string, t Target) Trace {
+	tr := Trace{
+		Decision: Authorize(s, action, t),
+		IsRoot:   s.IsRoot,
+		Policies: []PolicyTrace{},
+	}
+	a, ok := Lookup(action)
+	if !ok {
+		return tr
+	}
+	tr.Kind = a.Kind
+	tr.Grantable = append([]string{action}, a.ImpliedBy...)
+
+	var covering []string
+	if t.OrgPath != "" {
+		covering = append(utils.OrgAncestors(t.OrgPath), t.OrgPath)
+	}
+	for i := range s.Policies {
+		p := &s.Policies[i]
+		pt := PolicyTrace{Policy: *p, Effect: models.PolicyEffectAllow}
+		if p.IsDeny() {
+			pt.Effect = models.PolicyEffectDeny
+			pt.Skipped = skipReason(p, []string{action}, t, covering, true)
+		} else {
+			pt.Skipped = skipReason(p, tr.Grantable, t, covering, false)
+			if pt.Skipped == "" && t.OrgPath == "" &&
+				DenyingPolicy(s.Policies, action, Target{OrgPath: p.OrgPrefix, Attrs: t.Attrs}) != nil {
+				pt.Skipped = SkipDeniedAtPrefix
+			}
+		}
+		pt.Matched = pt.Skipped == ""
+		pt.Decisive = tr.Decision.Policy == p
+		tr.Policies = append(tr.Policies, pt)
+	}
+	return tr
+}
@@ -0,0 +1,106 @@
+package authz
+
+import (
+	"sort"
+	"sync"
+)
+
+// Kind บอกว่า action ตัดสินจากอะไร
+const (
+	KindOrg    = "org"    // ต้องมี policy ที่ให้ action นี้ครอบคลุม org path ของเป้าหมาย
+	KindSelf   = "self"   // ผู้ใช้ที่ login แล้วทำได้ (handler/service ตรวจความเป็นเจ้าของเอง)
+	KindSystem = "system" // root เท่านั้น
+)
+
+// Action หนึ่งรายการใน registry
+//   - ImpliedBy: action เดิมที่ให้สิทธิ์นี้ไปด้วย (policy เก่ามีแค่ event:create ก็ยังแก้ event ได้)
+//   - OwnerAllowed: เจ้าของเป้าหมาย (ผู้สร้างโพสต์/คอมเมนต์, organizer ของ event) ทำได้แม้ไม่มี policy
+type Action struct {
+	Key          string   `json:"key"`
+	Category     string   `json:"category"`
+	Kind         string   `json:"kind"`
+	Description  string   `json:"description"`
+	ImpliedBy    []string `json:"implied_by,omitempty"`
+	OwnerAllowed bool     `json:"owner_allowed,omitempty"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Action{}
+)
+
+// Register เพิ่ม/แทนที่ action ใน registry
+func Register(actions ...Action) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, a := range actions {
+		if a.Kind == "" {
+			a.Kind = KindOrg
+		}
+		registry[a.Key] = a
+	}
+}
+
+func Lookup(key string) (Action, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	a, ok := registry[key]
+	return a, ok
+}
+
+func IsKnown(key string) bool {
+	_, ok := Lookup(key)
+	return ok
+}
+
+// Actions คืน action ทั้งหมดเรียงตาม category แล้ว key
+func Actions() []Action {
+	registryMu.RLock()
+	out := make([]Action, 0, len(registry))
+	for _, a := range registry {
+		out = append(out, a)
+	}
+	registryMu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Category != out[j].Category {
+			return out[i].Category < out[j].Category
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+func init() {
+	Register(
+		// org / membership / policy
+		Action{Key: "organize:create", Category: "org", Description: "Create, move, archive, import and edit org units"},
+		Action{Key: "position:manage", Category: "org", Description: "Create and edit positions", ImpliedBy: []string{"organize:create"}},
+		Action{Key: "membership:assign", Category: "membership", Description: "Assign and end memberships, review join requests, manage invitations"},
+		Action{Key: "policy:manage", Category: "membership", Description: "Edit and delete policies", ImpliedBy: []string{"membership:assign"}},
+		Action{Key: "membership:request", Category: "membership", Kind: KindSelf, Description: "Request to join an org unit or redeem an invitation"},
+
+		// events
+		Action{Key: "event:create", Category: "event", Description: "Create events for an org unit"},
+		Action{Key: "event:update", Category: "event", Description: "Edit and re-activate events", ImpliedBy: []string{"event:create"}, OwnerAllowed: true},
+		Action{Key: "event:delete", Category: "event", Description: "Delete events", ImpliedBy: []string{"event:create"}, OwnerAllowed: true},
+		Action{Key: "event:manage", Category: "event", Description: "Manage forms, participants and Q&A answers", ImpliedBy: []string{"event:create"}, OwnerAllowed: true},
+		Action{Key: "event:participate", Category: "event", Kind: KindSelf, Description: "Join events, answer forms and ask questions"},
+
+		// posts / comments
+		Action{Key: "post:create", Category: "post", Kind: KindSelf, Description: "Create posts (post-as is checked against memberships)"},
+		Action{Key: "post:update", Category: "post", Description: "Edit posts", ImpliedBy: []string{"post:moderate"}, OwnerAllowed: true},
+		Action{Key: "post:delete", Category: "post", Description: "Delete posts", ImpliedBy: []string{"post:moderate"}, OwnerAllowed: true},
+		Action{Key: "post:moderate", Category: "post", Description: "Edit or delete any post of an org unit"},
+		Action{Key: "post:like", Category: "post", Kind: KindSelf, Description: "Like and unlike posts"},
+		Action{Key: "comment:create", Category: "comment", Kind: KindSelf, Description: "Comment on posts"},
+		Action{Key: "comment:update", Category: "comment", Description: "Edit own comments", OwnerAllowed: true},
+		Action{Key: "comment:delete", Category: "comment", Description: "Delete comments", ImpliedBy: []string{"comment:moderate"}, OwnerAllowed: true},
+		Action{Key: "comment:moderate", Category: "comment", Description: "Delete any comment under an org unit's posts"},
+
+		// user / system
+		Action{Key: "profile:update", Category: "user", Kind: KindSelf, Description: "Edit own profile and upload media"},
+		Action{Key: "user:delete", Category: "system", Kind: KindSystem, Description: "Delete users"},
+		Action{Key: "system:admin", Category: "system", Kind: KindSystem, Description: "Maintenance endpoints (media GC, cache)"},
+	)
+}
@@ -0,0 +1,60 @@
+package changebus
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Topic คือชนิดข้อมูลที่เปลี่ยน (ใช้ชื่อ collection)
+type Topic string
+
+const (
+	TopicMemberships Topic = "memberships"
+	TopicOrgUnits    Topic = "org_units"
+	TopicPolicies    Topic = "policies"
+	TopicPositions   Topic = "positions"
+)
+
+// Event แจ้งว่ามีการเขียนข้อมูล UserIDs ว่าง = กระทบทุกคน
+type Event struct {
+	Topic   Topic
+	UserIDs []bson.ObjectID
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers []func(Event)
+)
+
+// Subscribe ลงทะเบียน handler (เรียกแบบ synchronous ควรทำงานเร็ว เช่น ลบ cache)
+func Subscribe(fn func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, fn)
+}
+
+// Publish ส่ง event ให้ทุก subscriber ใน process นี้
+func Publish(e Event) {
+	mu.RLock()
+	subs := subscribers
+	mu.RUnlock()
+	for _, fn := range subs {
+		fn(e)
+	}
+}
+
+// MembershipsChanged แจ้งว่า membership ของ user เหล่านี้เปลี่ยน (ไม่ส่ง id = ไม่รู้ว่าใคร)
+func MembershipsChanged(userIDs ...bson.ObjectID) {
+	Publish(Event{Topic: TopicMemberships, UserIDs: userIDs})
+}
+
+// OrgUnitsChanged แจ้งว่าโครงสร้าง/สถานะของ org_units เปลี่ยน
+func OrgUnitsChanged() {
+	Publish(Event{Topic: TopicOrgUnits})
+}
+
+// PoliciesChanged แจ้งว่า policies หรือ positions เปลี่ยน
+func PoliciesChanged() {
+	Publish(Event{Topic: TopicPolicies})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+)
+
+// sendAbilities ตอบพร้อม ETag และคืน 304 ถ้า If-None-Match ตรงกัน
+func sendAbilities(c *fiber.Ctx, userID bson.ObjectID) error {
+	resp, err := services.ComputeAbilities(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderETag, resp.Version)
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	if c.Get(fiber.HeaderIfNoneMatch) == resp.Version {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(resp)
+}
+
+// GetMyAbilitiesHandler godoc
+// @Summary      My effective abilities
+// @Description  Effective actions per org path computed from the current user's memberships and policies with the same rules as enforcement (subtree scopes expanded to every active unit below, implied actions included, explicit denies applied). owner_abilities lists actions allowed only on the user's own posts, comments and events. Send If-None-Match with the previous ETag to get 304 when nothing changed.
+// @Tags         Abilities
+// @Produce      json
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200  {object}  dto.UserAbilitiesResponse
+// @Success      304  "not modified"
+// @Failure      401  {object}  dto.ErrorResponse "unauthorized"
+// @Router       /abilities/me [get]
+func GetMyAbilitiesHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, err := middleware.UIDFromLocals(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		oid, err := bson.ObjectIDFromHex(uid)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		return sendAbilities(c, oid)
+	}
+}
+
+// GetUserAbilitiesHandler godoc
+// @Summary      Effective abilities of a user (root only)
+// @Tags         Abilities
+// @Produce      json
+// @Param        userId         path    string  true   "User ID"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
+// @Success      200  {object}  dto.UserAbilitiesResponse
+// @Success      304  "not modified"
+// @Failure      400  {object}  dto.ErrorResponse "invalid userId"
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Router       /abilities/{userId} [get]
+func GetUserAbilitiesHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !isRootByPath(viewerFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+		}
+		oid, err := bson.ObjectIDFromHex(c.Params("userId"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid userId"})
+		}
+		return sendAbilities(c, oid)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/services"
+)
+
+// ExplainAuthzHandler godoc
+// @Summary      Explain an authorization decision (root only)
+// @Description  Dry-runs action for user at org_path with the same engine RequireAction uses and returns the decision
+//               with a trace: memberships considered, every policy of the user (matched or skipped with the reason:
+//               disabled, missing action, wrong scope, conditions not met, ...) and which one decided.
+//               Leave org_path empty for "anywhere". Condition attributes can be passed as attr.<name>=<value>
+//               (e.g. attr.status=draft). Owner-based grants are not evaluated since no resource is given.
+// @Tags         Authz
+// @Produce      json
+// @Param        user      query     string  true   "User ID"
+// @Param        action    query     string  true   "Action key, e.g. event:update"
+// @Param        org_path  query     string  false  "Target org path"
+// @Success      200  {object}  dto.AuthzExplainResponse
+// @Failure      400  {object}  dto.ErrorResponse "invalid user or missing action"
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Failure      404  {object}  dto.ErrorResponse "user not found"
+// @Router       /authz/explain [get]
+func ExplainAuthzHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := bson.ObjectIDFromHex(c.Query("user"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user"})
+		}
+		action := strings.TrimSpace(c.Query("action"))
+		if action == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "action is required"})
+		}
+
+		var attrs map[string]string
+		c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+			if name, ok := strings.CutPrefix(string(k), "attr."); ok && name != "" {
+				if attrs == nil {
+					attrs = map[string]string{}
+				}
+				attrs[name] = string(v)
+			}
+		})
+
+		resp, err := services.ExplainAuthorization(c.Context(), userID, action, strings.TrimSpace(c.Query("org_path")), attrs)
+		if errors.Is(err, services.ErrExplainUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(resp)
+	}
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"main-webbase/dto"
+	"main-webbase/internal/accessctx"
+)
+
+// GetCacheMetricsHandler godoc
+// @Summary      Access cache metrics (root only)
+// @Description  Hit/miss/invalidation counters and hit rate for the in-process org tree and viewer access caches.
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]accessctx.CacheStats
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /admin/cache/metrics [get]
+func GetCacheMetricsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !isRootByPath(viewerFrom(c)) {
+			return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Error: "forbidden"})
+		}
+		return c.JSON(accessctx.Metrics())
+	}
+}
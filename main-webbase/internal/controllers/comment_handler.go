@@ -5,6 +5,7 @@ import (
 	"main-webbase/dto"
 	"main-webbase/internal/accessctx"
 	"main-webbase/internal/middleware"
+	"main-webbase/internal/models"
 	"main-webbase/internal/repository"
 	"main-webbase/internal/utils"
 	"net/http"
@@ -23,6 +24,14 @@ func viewerFrom(c *fiber.Ctx) *accessctx.ViewerAccess {
 	return v
 }
 
+// viewerRoles membership ของผู้ดู สำหรับ targeting ของโพสต์ (ไม่มี viewer = ไม่มีตำแหน่ง)
+func viewerRoles(c *fiber.Ctx) []models.ViewerRole {
+	if v := viewerFrom(c); v != nil {
+		return v.Roles()
+	}
+	return nil
+}
+
 // เป็น root ไหม: ถ้ามี OrgPath == "/" หรือ (ถ้ามี field is_root ใน User)
 // Root/Admin = มี OrgPath == "/"
 func isRootByPath(v *accessctx.ViewerAccess) bool {
@@ -245,7 +254,7 @@ func (h *CommentHandler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(dto.ErrorResponse{Error: "invalid comment id"})
 	}
-	isRoot := isRootByPath(viewerFrom(c))
+	isRoot := middleware.AuthzDecision(c).Elevated() // root หรือ comment:moderate
 	okDel, err := h.Repo.Delete(c.Context(), cid, uid, isRoot)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -112,9 +112,8 @@ func CreateEventQAHandler(client *mongo.Client) fiber.Handler {
 func AnswerEventQAHandler(client *mongo.Client) fiber.Handler {
     return func(c *fiber.Ctx) error {
         colEventQA := database.DB.Collection("event_qa")
-        colParticipant := database.DB.Collection("event_participant")
 
-        // 1) current user (must be organizer of the event)
+        // 1) current user (สิทธิ์ event:manage ตรวจที่ route แล้ว)
         uidStr, err := m.UIDFromLocals(c)
         if err != nil {
             return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
@@ -146,16 +145,7 @@ func AnswerEventQAHandler(client *mongo.Client) fiber.Handler {
             return fiber.NewError(fiber.StatusForbidden, "cannot answer")
         }
 
-        // 5) permission: must be organizer of this event
-        if err := colParticipant.FindOne(c.Context(), bson.M{
-            "event_id": qa.EventID,
-            "user_id":  uid,
-            "role":     "organizer",
-        }).Err(); err != nil {
-            return fiber.NewError(fiber.StatusForbidden, "cannot answer")
-        }
-
-        // 6) update answer
+        // 5) update answer
         res := colEventQA.FindOneAndUpdate(
             c.Context(),
             bson.M{"_id": qaID, "status": "pending", "answer_text": bson.M{"$eq": nil}},
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
@@ -141,6 +142,9 @@ func CreateEventHandler() fiber.Handler {
 
 		// --- create event ---
 		result, err := services.CreateEventWithSchedules(body, c.Context())
+		if errors.Is(err, services.ErrOrgArchived) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -59,6 +59,7 @@ func FeedHandler(client *mongo.Client) fiber.Handler {
 			UntilID:        until,
 			ViewerID:       viewerID,              // อาจเป็น zero value ถ้าไม่มีใน Locals
 			AllowedNodeIDs: viewer.SubtreeNodeIDs, // ใช้จาก ViewerAccess รุ่นใหม่
+			ViewerRoles:    viewer.Roles(),        // targeting (access = org)
 		}
 
 		var (
@@ -41,6 +41,7 @@ func GetPostsVisibilityCursor(client *mongo.Client) fiber.Handler {
 		}
 
 		curStr := c.Query("cursor")
+		viewerID, _ := userIDFromLocals(c)
 
 		// ⬇️ ดึงสิทธิ์ของผู้ดูจาก Locals (ต้องมี middleware InjectViewer วางก่อนแล้ว)
 		v, ok := c.Locals("viewer").(*accessctx.ViewerAccess)
@@ -50,6 +51,7 @@ func GetPostsVisibilityCursor(client *mongo.Client) fiber.Handler {
 
 		items, next, err := repository.ListAllPostsVisibleToViewer(
 			c.Context(), client, curStr, limit, v.SubtreeNodeIDs, // ⬅️ ส่งสิทธิ์ของผู้ดู
+			viewerID, v.Roles(),
 		)
 		if err != nil {
 			return c.Status(http.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
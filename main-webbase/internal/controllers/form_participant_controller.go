@@ -11,6 +11,7 @@ import (
 
 	"main-webbase/database"
 	"main-webbase/dto"
+	"main-webbase/internal/authz"
 	"main-webbase/internal/middleware"
 	"main-webbase/internal/models"
 	repo "main-webbase/internal/repository"
@@ -101,19 +102,6 @@ func CreateFormQuestionHandler() fiber.Handler {
 			return fiber.NewError(fiber.StatusNotFound, "form not found")
 		}
 
-		uid, err := middleware.UIDFromLocals(c)
-		if err != nil {
-			return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
-		}
-		userPolicy, err := services.MyUserPolicy(c.Context(), uid)
-		if err != nil {
-			return fiber.NewError(fiber.StatusNotFound, "target policy not found")
-		}
-
-		if err := services.CanManageEvent(c.Context(), userPolicy, form.Event_ID.Hex()); err != nil {
-			return fiber.NewError(fiber.StatusForbidden, "no permission to manage this event")
-		}
-
 		Questions_list, err := services.CreateFormQuestion(form.ID, body, c.Context())
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -271,7 +259,7 @@ func UpdateParticipantStatusHandler() fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "user_id, event_id, and status are required")
 		}
 
-		// Require organizer permission for this event
+		// สิทธิ์ event:manage ตรวจที่ route (RequireAction) แล้ว
 		uid, err := middleware.UIDFromLocals(c)
 		if err != nil {
 			return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
@@ -284,15 +272,6 @@ func UpdateParticipantStatusHandler() fiber.Handler {
 		if err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid event_id")
 		}
-		// Must be organizer of this event
-		if err := database.DB.Collection("event_participant").FindOne(c.Context(), bson.M{
-			"event_id": eventObjID,
-			"user_id":  userObjID,
-			"role":     "organizer",
-		}).Err(); err != nil {
-			return fiber.NewError(fiber.StatusForbidden, "no permission to manage this event")
-		}
-
 		if err := services.UpdateParticipantStatus(c.Context(), body); err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, "failed to update user status")
 		}
@@ -449,6 +428,19 @@ func ListEventParticipantsHandler() fiber.Handler {
 		}
 		defer cur.Close(c.Context())
 
+		// organizer เรียงตาม rank ของตำแหน่งในหน่วยงานของ event (สูงก่อน)
+		var ranks *services.PositionRanks
+		eventOrg := ""
+		if role == "organizer" {
+			if ev, err := repo.GetEventByID(c.Context(), eid); err == nil {
+				if node, err := repo.GetOrgByID(c.Context(), ev.NodeID); err == nil {
+					eventOrg = node.OrgPath
+					ranks = services.NewPositionRanks(c.Context())
+				}
+			}
+		}
+		rankOf := map[string]int{}
+
 		// Build response with user info
 		out := make([]fiber.Map, 0, 20)
 		colUsers := database.DB.Collection("users")
@@ -463,7 +455,7 @@ func ListEventParticipantsHandler() fiber.Handler {
 				Last  string        `bson:"lastname"`
 			}
 			_ = colUsers.FindOne(c.Context(), bson.M{"_id": p.User_ID}).Decode(&user)
-			out = append(out, fiber.Map{
+			row := fiber.Map{
 				"user_id":    p.User_ID.Hex(),
 				"first_name": user.First,
 				"last_name":  user.Last,
@@ -476,6 +468,16 @@ func ListEventParticipantsHandler() fiber.Handler {
 						return p.Response_ID.Hex()
 					}
 				}(),
+			}
+			if ranks != nil {
+				rankOf[p.User_ID.Hex()] = ranks.UserRankNear(p.User_ID, eventOrg)
+				row["position_rank"] = rankOf[p.User_ID.Hex()]
+			}
+			out = append(out, row)
+		}
+		if ranks != nil {
+			sort.SliceStable(out, func(i, j int) bool {
+				return rankOf[out[i]["user_id"].(string)] > rankOf[out[j]["user_id"].(string)]
 			})
 		}
 		return c.JSON(out)
@@ -500,7 +502,7 @@ func ManageableOrgsHandler() fiber.Handler {
 		// Collect org prefixes with create permission
 		prefixes := make(map[string]struct{})
 		for _, p := range policies {
-			if !p.Enabled {
+			if !p.Enabled || p.IsDeny() || len(p.Conditions) > 0 {
 				continue
 			}
 			hasCreate := false
@@ -510,7 +512,7 @@ func ManageableOrgsHandler() fiber.Handler {
 					break
 				}
 			}
-			if hasCreate && p.OrgPrefix != "" {
+			if hasCreate && p.OrgPrefix != "" && authz.DenyingPolicy(policies, "event:create", authz.Target{OrgPath: p.OrgPrefix}) == nil {
 				prefixes[p.OrgPrefix] = struct{}{}
 			}
 		}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+)
+
+// CreateImpersonationHandler godoc
+// @Summary      Issue a "view as user" token (root only)
+// @Description  Returns a short-lived (default 15, max 60 minutes) read-only token for the target user. Requests made
+//               with it see exactly what the user sees (their ViewerAccess and policies); any non-GET route is rejected
+//               with 403. Every request is audited and visible to the target user at GET /users/me/impersonations.
+// @Tags         Impersonation
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ImpersonationCreateDTO  true  "Target user and reason"
+// @Success      201   {object}  dto.ImpersonationTokenResponse
+// @Failure      400   {object}  dto.ErrorResponse "invalid request"
+// @Failure      403   {object}  dto.ErrorResponse "forbidden"
+// @Failure      404   {object}  dto.ErrorResponse "target user not found"
+// @Router       /impersonations [post]
+func CreateImpersonationHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.ImpersonationCreateDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		admin, err := middleware.UIDObjectID(c)
+		if err != nil {
+			return err
+		}
+		resp, err := services.IssueImpersonationToken(c.Context(), admin, body)
+		switch {
+		case errors.Is(err, services.ErrImpersonationInvalid):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrImpersonationTarget):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case err != nil:
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.Status(fiber.StatusCreated).JSON(resp)
+	}
+}
+
+// ListImpersonationsHandler godoc
+// @Summary      Impersonation audit log (root only)
+// @Description  Latest impersonation sessions with every request made, optionally filtered by admin_id or target_id.
+// @Tags         Impersonation
+// @Produce      json
+// @Param        admin_id   query  string  false  "Admin user ID"
+// @Param        target_id  query  string  false  "Target user ID"
+// @Success      200  {array}   dto.ImpersonationSessionView
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Router       /impersonations [get]
+func ListImpersonationsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter := bson.M{}
+		for _, f := range []string{"admin_id", "target_id"} {
+			if v := c.Query(f); v != "" {
+				id, err := bson.ObjectIDFromHex(v)
+				if err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, "invalid "+f)
+				}
+				filter[f] = id
+			}
+		}
+		out, err := services.ImpersonationSessions(c.Context(), filter)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(out)
+	}
+}
+
+// MyImpersonationsHandler godoc
+// @Summary      Who viewed the app as me
+// @Description  Impersonation sessions where the current user was the target: admin, reason, validity and every request made.
+// @Tags         Impersonation
+// @Produce      json
+// @Success      200  {array}   dto.ImpersonationSessionView
+// @Failure      401  {object}  dto.ErrorResponse "unauthorized"
+// @Router       /users/me/impersonations [get]
+func MyImpersonationsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, err := middleware.UIDObjectID(c)
+		if err != nil {
+			return err
+		}
+		out, err := services.ImpersonationSessions(c.Context(), bson.M{"target_id": uid})
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(out)
+	}
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/repository"
+	"main-webbase/internal/services"
+)
+
+type MediaGCHandler struct {
+	Opts services.MediaGCOptions
+}
+
+func NewMediaGCHandler(opts services.MediaGCOptions) *MediaGCHandler {
+	return &MediaGCHandler{Opts: opts}
+}
+
+// Run godoc
+// @Summary      Run orphaned media GC now (root only)
+// @Description  Scans the upload directory for files no post, event, comment or profile references. Files unreferenced for longer than the grace period are deleted. dry_run defaults to true for manual runs.
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        dry_run  query  bool  false  "Report only, do not delete (default true)"
+// @Success      200  {object}  models.MediaGCReport
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /admin/media/gc [post]
+func (h *MediaGCHandler) Run(c *fiber.Ctx) error {
+	opts := h.Opts
+	opts.Trigger = "manual"
+	opts.DryRun = true
+	if v := c.Query("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "dry_run must be true or false"})
+		}
+		opts.DryRun = b
+	}
+
+	report, err := services.RunMediaGC(c.Context(), opts)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(report)
+}
+
+// ListReports godoc
+// @Summary      List media GC reports (root only)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit  query  int  false  "Max reports (default 20, max 100)"
+// @Success      200  {array}   models.MediaGCReport
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /admin/media/gc/reports [get]
+func (h *MediaGCHandler) ListReports(c *fiber.Ctx) error {
+	if !isRootByPath(viewerFrom(c)) {
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Error: "forbidden"})
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	reports, err := repository.ListMediaGCReports(c.Context(), int64(limit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(reports)
+}
+
+// GetReport godoc
+// @Summary      Get one media GC report (root only)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Report ID"
+// @Success      200  {object}  models.MediaGCReport
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /admin/media/gc/reports/{id} [get]
+func (h *MediaGCHandler) GetReport(c *fiber.Ctx) error {
+	if !isRootByPath(viewerFrom(c)) {
+		return c.Status(fiber.StatusForbidden).JSON(dto.ErrorResponse{Error: "forbidden"})
+	}
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "invalid report id"})
+	}
+	report, err := repository.FindMediaGCReport(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	if report == nil {
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: "report not found"})
+	}
+	return c.JSON(report)
+}
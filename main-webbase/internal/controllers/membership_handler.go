@@ -3,21 +3,30 @@ package controllers
 import (
     "github.com/gofiber/fiber/v2"
     "main-webbase/internal/models"
-    repo "main-webbase/internal/repository"
     "main-webbase/database"
+    "main-webbase/dto"
+    "main-webbase/internal/middleware"
+    "main-webbase/internal/services"
     "go.mongodb.org/mongo-driver/v2/bson"
+    "main-webbase/internal/utils"
     "context"
+    "errors"
+    "sort"
+    "time"
 )
 
 // CreateMembership godoc
 // @Summary      Create a new membership
-// @Description  Assigns a user to an organization and position
+// @Description  Assigns a user to an organization and position. Requires membership:assign on the target org path; the position must be scoped to that org (or inherited from an ancestor). Exclusive positions return 409 while held unless end_previous=true, which ends the current holder's term. The position must rank strictly below the caller's own position in that subtree (403 otherwise).
 // @Tags         Memberships
 // @Accept       json
 // @Produce      json
 // @Param        body  body      models.MembershipRequestDTO  true  "Membership data"
-// @Success      200   {object}  models.MembershipRequestDTO "membership created"
-// @Failure      400   {object}  dto.ErrorResponse "invalid body"
+// @Success      200   {object}  models.Membership "membership created"
+// @Failure      400   {object}  dto.ErrorResponse "invalid body / position not usable here"
+// @Failure      403   {object}  dto.ErrorResponse "no permission"
+// @Failure      404   {object}  dto.ErrorResponse "org unit or position not found"
+// @Failure      409   {object}  dto.MembershipConflictResponse "exclusive position already held"
 // @Failure      500   {object}  dto.ErrorResponse "internal server error"
 // @Router       /memberships [post]
 func CreateMembership() fiber.Handler {
@@ -26,11 +35,56 @@ func CreateMembership() fiber.Handler {
 		if err := c.BodyParser(&req); err != nil {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
 		}
-		if err := repo.InsertMembership(c.Context(), req); err != nil {
+
+		uid, err := middleware.UIDFromLocals(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+		}
+		userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
-		return c.JSON(fiber.Map{"message": "membership created", "data": req})
+
+		m, err := services.AssignMembership(c.Context(), userPolicy, req)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(fiber.Map{"message": "membership created", "data": m})
+	}
+}
+
+func membershipError(c *fiber.Ctx, err error) error {
+	var conflict *services.MembershipConflictError
+	if errors.As(err, &conflict) {
+		holders := make([]dto.MembershipHolder, 0, len(conflict.Holders))
+		for _, h := range conflict.Holders {
+			holders = append(holders, dto.MembershipHolder{MembershipID: h.ID.Hex(), UserID: h.UserID.Hex()})
+		}
+		return c.Status(fiber.StatusConflict).JSON(dto.MembershipConflictResponse{
+			Error:       conflict.Error(),
+			OrgPath:     conflict.OrgPath,
+			PositionKey: conflict.PositionKey,
+			Holders:     holders,
+			Resolution:  "resend with end_previous=true to end the current holder's term",
+		})
 	}
+	switch {
+	case errors.Is(err, services.ErrMembershipInvalid), errors.Is(err, services.ErrPositionOutOfScope):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMembershipNoPermission), errors.Is(err, services.ErrRankTooHigh):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMembershipNotFound), errors.Is(err, services.ErrPositionNotFound),
+		errors.Is(err, services.ErrOrgUnitNotFound), errors.Is(err, services.ErrJoinRequestNotFound),
+		errors.Is(err, services.ErrInviteNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMembershipExists), errors.Is(err, services.ErrOrgArchived), errors.Is(err, services.ErrPositionDeprecated),
+		errors.Is(err, services.ErrJoinRequestExists), errors.Is(err, services.ErrJoinRequestClosed),
+		errors.Is(err, services.ErrHandoverStale):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInviteUnavailable):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 }
 
 // ListMemberships 
@@ -52,12 +106,13 @@ func CreateMembership() fiber.Handler {
 
 // ListMembershipsWithUsers
 // @Summary      List memberships at an org with user details
-// @Description  Returns active memberships at exact org_path, joined with basic user info
+// @Description  Returns active memberships at exact org_path, joined with basic user info, ordered by position rank (highest first)
 // @Tags         memberships
 // @Accept       json
 // @Produce      json
 // @Param        org_path query string true "Organization path"
-// @Param        active   query string false "active|all (default: active)"
+// @Param        active   query string false "active|all (default: active = currently within term)"
+// @Param        as_of    query string false "members as of a date (RFC3339 or YYYY-MM-DD); overrides active"
 // @Success      200 {array} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      500 {object} map[string]string
@@ -71,10 +126,17 @@ func ListMembershipsWithUsers() fiber.Handler {
         active := c.Query("active", "active")
 
         colMem := database.DB.Collection("memberships")
-        filter := bson.M{"org_path": orgPath}
-        if active != "all" {
-            filter["active"] = true
+        filter := bson.M{}
+        if asOf := c.Query("as_of"); asOf != "" {
+            t, err := utils.ParseAsOf(asOf)
+            if err != nil {
+                return fiber.NewError(fiber.StatusBadRequest, err.Error())
+            }
+            filter = utils.MembershipAsOfFilter(t)
+        } else if active != "all" {
+            filter = utils.CurrentMembershipFilter(time.Now())
         }
+        filter["org_path"] = orgPath
 
         ctx := context.Background()
         cur, err := colMem.Find(ctx, filter)
@@ -89,6 +151,9 @@ func ListMembershipsWithUsers() fiber.Handler {
             OrgPath     string        `bson:"org_path" json:"org_path"`
             PositionKey string        `bson:"position_key" json:"position_key"`
             Active      bool          `bson:"active" json:"active"`
+            JoinedAt    *time.Time    `bson:"joined_at" json:"joined_at"`
+            TermEnd     *time.Time    `bson:"term_end" json:"term_end"`
+            EndedAt     *time.Time    `bson:"ended_at" json:"ended_at"`
         }
 
         var mems []Row
@@ -132,6 +197,19 @@ func ListMembershipsWithUsers() fiber.Handler {
             }
         }
 
+        // เรียงตาม rank ของตำแหน่ง (สูงก่อน) แล้ววันที่เริ่มวาระ
+        ranks := services.NewPositionRanks(ctx)
+        sort.SliceStable(mems, func(i, j int) bool {
+            ri, rj := ranks.Of(mems[i].PositionKey, mems[i].OrgPath), ranks.Of(mems[j].PositionKey, mems[j].OrgPath)
+            if ri != rj {
+                return ri > rj
+            }
+            if mems[i].JoinedAt != nil && mems[j].JoinedAt != nil {
+                return mems[i].JoinedAt.Before(*mems[j].JoinedAt)
+            }
+            return mems[i].JoinedAt != nil
+        })
+
         // build output
         out := make([]fiber.Map, 0, len(mems))
         for _, m := range mems {
@@ -140,7 +218,11 @@ func ListMembershipsWithUsers() fiber.Handler {
                 "_id":          m.ID.Hex(),
                 "org_path":     m.OrgPath,
                 "position_key": m.PositionKey,
+                "position_rank": ranks.Of(m.PositionKey, m.OrgPath),
                 "active":       m.Active,
+                "joined_at":    m.JoinedAt,
+                "term_end":     m.TermEnd,
+                "ended_at":     m.EndedAt,
                 "user_id":      m.UserID.Hex(),
                 "user": fiber.Map{
                     "_id":        u.ID.Hex(),
@@ -157,13 +239,15 @@ func ListMembershipsWithUsers() fiber.Handler {
 
 // DeactivateMembership godoc
 // @Summary      Deactivate membership
-// @Description  Set membership active=false by id
+// @Description  Set membership active=false by id. Requires membership:assign on the membership's org path.
 // @Tags         memberships
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Membership ID"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Router       /memberships/{id} [patch]
 func DeactivateMembership() fiber.Handler {
@@ -173,11 +257,77 @@ func DeactivateMembership() fiber.Handler {
         if err != nil {
             return fiber.NewError(fiber.StatusBadRequest, "invalid id")
         }
-        col := database.DB.Collection("memberships")
-        _, err = col.UpdateOne(c.Context(), bson.M{"_id": oid}, bson.M{"$set": bson.M{"active": false}})
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
         if err != nil {
             return fiber.NewError(fiber.StatusInternalServerError, err.Error())
         }
+
+        if _, err := services.DeactivateMembership(c.Context(), userPolicy, oid); err != nil {
+            return membershipError(c, err)
+        }
         return c.JSON(fiber.Map{"_id": idHex, "active": false})
     }
 }
+
+// MembershipHistory godoc
+// @Summary      Membership history
+// @Description  All terms (including ended ones) of a user and/or an org unit, newest first. Pass as_of to get only terms held at that moment. Users may read their own history; org history requires membership:assign on org_path.
+// @Tags         memberships
+// @Produce      json
+// @Param        user_id  query string false "User ID"
+// @Param        org_path query string false "Organization path"
+// @Param        as_of    query string false "RFC3339 or YYYY-MM-DD"
+// @Success      200 {array}  models.Membership
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Router       /memberships/history [get]
+func MembershipHistory() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+
+        var q services.MembershipHistoryQuery
+        q.OrgPath = c.Query("org_path")
+        if s := c.Query("user_id"); s != "" {
+            oid, err := bson.ObjectIDFromHex(s)
+            if err != nil {
+                return fiber.NewError(fiber.StatusBadRequest, "invalid user_id")
+            }
+            q.UserID = &oid
+        }
+        if s := c.Query("as_of"); s != "" {
+            t, err := utils.ParseAsOf(s)
+            if err != nil {
+                return fiber.NewError(fiber.StatusBadRequest, err.Error())
+            }
+            q.AsOf = &t
+        }
+
+        if !isRootByPath(viewerFrom(c)) {
+            if q.OrgPath != "" {
+                userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+                if err != nil {
+                    return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+                }
+                if err := services.CanManageOrg(userPolicy, "membership:assign", q.OrgPath); err != nil {
+                    return membershipError(c, services.ErrMembershipNoPermission)
+                }
+            } else if q.UserID == nil || q.UserID.Hex() != uid {
+                return membershipError(c, services.ErrMembershipNoPermission)
+            }
+        }
+
+        out, err := services.MembershipHistory(c.Context(), q)
+        if err != nil {
+            return membershipError(c, err)
+        }
+        return c.JSON(out)
+    }
+}
@@ -2,15 +2,24 @@ package controllers
 
 import (
     "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "os"
+    "path/filepath"
     "time"
 
     "github.com/gofiber/fiber/v2"
     "go.mongodb.org/mongo-driver/v2/bson"
     "go.mongodb.org/mongo-driver/v2/mongo/options"
     "main-webbase/dto"
+    "main-webbase/internal/middleware"
     "main-webbase/internal/services"
     "main-webbase/database"
     "strconv"
+    "strings"
 )
 
 // CreateOrgUnitHandler godoc
@@ -56,6 +65,7 @@ func CreateOrgUnitHandler() fiber.Handler {
 // @Produce      json
 // @Param        start  query     string  true   "Starting org path"
 // @Param        depth  query     int     false  "Depth of tree to fetch"
+// @Param        include_archived  query  bool  false  "Include archived units"
 // @Success      200    {array}   dto.OrgUnitTree
 // @Failure      400   {object}  dto.ErrorResponse "invalid query parameters"
 // @Failure      500   {object}  dto.ErrorResponse "internal server error"
@@ -66,6 +76,12 @@ func GetOrgTree() fiber.Handler {
         if err := c.QueryParser(&query); err != nil {
             return fiber.NewError(fiber.StatusBadRequest, "invalid query parameters")
         }
+        // path เดิมของหน่วยงานที่ถูกย้ายไปแล้ว -> ใช้ path ปัจจุบัน
+        if query.Start != "" {
+            if current, _, err := services.ResolveOrgPath(c.Context(), query.Start); err == nil {
+                query.Start = current
+            }
+        }
 
         tree, err := services.BuildOrgTree(context.Background(), query)
         if err != nil {
@@ -85,6 +101,7 @@ func GetOrgTree() fiber.Handler {
 // @Param        start   query string false "Org prefix"
 // @Param        search  query string false "Search by name or path (case-insensitive)"
 // @Param        limit   query int    false "Limit results (default 50)"
+// @Param        include_archived query bool false "Include archived units"
 // @Success      200    {array}   dto.OrgUnitTree
 // @Router       /org/units [get]
 func ListOrgUnits() fiber.Handler {
@@ -97,7 +114,11 @@ func ListOrgUnits() fiber.Handler {
             if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 500 { limit = int64(n) }
         }
 
-        filter := bson.M{"status": bson.M{"$ne": "inactive"}}
+        hidden := bson.A{"inactive", "archived"}
+        if c.QueryBool("include_archived") {
+            hidden = bson.A{"inactive"}
+        }
+        filter := bson.M{"status": bson.M{"$nin": hidden}}
         if start != "" {
             filter["$or"] = []bson.M{
                 {"ancestors": start},
@@ -120,6 +141,7 @@ func ListOrgUnits() fiber.Handler {
             Name      string `bson:"name" json:"name"`
             ShortName string `bson:"shortname" json:"shortname"`
             Type      string `bson:"type" json:"type"`
+            Status    string `bson:"status" json:"status"`
         }
         out := make([]Row, 0, 50)
         for cur.Next(c.Context()) {
@@ -129,3 +151,446 @@ func ListOrgUnits() fiber.Handler {
         return c.JSON(out)
     }
 }
+
+// MoveOrgUnitHandler godoc
+// @Summary      Move or rename an organization unit
+// @Description  Re-parents and/or renames (slug, name) an org unit. Every document that stores the old path (org_units, memberships, policies, positions, posts, events) is rewritten in one transaction and a redirect from the old path is kept. Requires organize:create on both the old and the new location.
+// @Tags         Org Units
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.OrgUnitMoveDTO  true  "Move request"
+// @Success      200   {object}  dto.OrgUnitMoveReport
+// @Failure      400   {object}  dto.ErrorResponse "invalid request / cycle"
+// @Failure      403   {object}  dto.ErrorResponse "no permission"
+// @Failure      404   {object}  dto.ErrorResponse "org unit or parent not found"
+// @Failure      409   {object}  dto.ErrorResponse "destination path already exists"
+// @Failure      500   {object}  dto.ErrorResponse "internal server error"
+// @Router       /org/units/move [post]
+func MoveOrgUnitHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        var body dto.OrgUnitMoveDTO
+        if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+        }
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        actor, _ := bson.ObjectIDFromHex(uid)
+        report, err := services.MoveOrgUnit(ctx, userPolicy, actor, body)
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrOrgUnitNotFound), errors.Is(err, services.ErrOrgParentNotFound):
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+            case errors.Is(err, services.ErrOrgPathTaken):
+                return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+            case errors.Is(err, services.ErrOrgMoveCycle), errors.Is(err, services.ErrOrgMoveInvalid):
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+            case errors.Is(err, services.ErrOrgNoPermission):
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        }
+
+        return c.JSON(report)
+    }
+}
+
+// ResolveOrgPathHandler godoc
+// @Summary      Resolve an org path (follows redirects of moved units)
+// @Description  Returns the current org path for a path that may have been moved or renamed.
+// @Tags         Org Units
+// @Produce      json
+// @Param        path  query     string  true  "Org path (old or current)"
+// @Success      200   {object}  dto.OrgPathResolveResponse
+// @Failure      400   {object}  dto.ErrorResponse "path is required"
+// @Router       /org/units/resolve [get]
+func ResolveOrgPathHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        path := c.Query("path")
+        if path == "" {
+            return fiber.NewError(fiber.StatusBadRequest, "path is required")
+        }
+        current, redirected, err := services.ResolveOrgPath(c.Context(), path)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        return c.JSON(dto.OrgPathResolveResponse{Requested: path, OrgPath: current, Redirected: redirected})
+    }
+}
+
+func orgArchiveError(c *fiber.Ctx, err error) error {
+    switch {
+    case errors.Is(err, services.ErrOrgUnitNotFound), errors.Is(err, services.ErrOrgNotArchived):
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgArchived), errors.Is(err, services.ErrOrgParentArchived):
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgMoveInvalid):
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgNoPermission):
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+    }
+    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// ArchiveOrgUnitHandler godoc
+// @Summary      Archive an organization unit subtree
+// @Description  Archives the unit and all descendants: memberships and policies are deactivated, posts are hidden, events set inactive, and no new content can be posted as these units. Requires organize:create (subtree) on an ancestor.
+// @Tags         Org Units
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.OrgUnitArchiveDTO  true  "Unit to archive"
+// @Success      200   {object}  models.OrgArchive
+// @Failure      400   {object}  dto.ErrorResponse "invalid request body"
+// @Failure      403   {object}  dto.ErrorResponse "no permission"
+// @Failure      404   {object}  dto.ErrorResponse "org unit not found"
+// @Failure      409   {object}  dto.ErrorResponse "already archived"
+// @Router       /org/units/archive [post]
+func ArchiveOrgUnitHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        var body dto.OrgUnitArchiveDTO
+        if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+        }
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        actor, _ := bson.ObjectIDFromHex(uid)
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        archive, err := services.ArchiveOrgUnit(ctx, userPolicy, actor, body.OrgPath, body.Reason)
+        if err != nil {
+            return orgArchiveError(c, err)
+        }
+        return c.JSON(archive)
+    }
+}
+
+// RestoreOrgUnitHandler godoc
+// @Summary      Restore an archived organization unit subtree
+// @Description  Reverts the latest archive of this unit: re-activates exactly the memberships, policies, posts and events that archive closed. Requires organize:create (subtree) on an ancestor.
+// @Tags         Org Units
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.OrgUnitArchiveDTO  true  "Unit to restore"
+// @Success      200   {object}  models.OrgArchive
+// @Failure      403   {object}  dto.ErrorResponse "no permission"
+// @Failure      404   {object}  dto.ErrorResponse "org unit is not archived"
+// @Failure      409   {object}  dto.ErrorResponse "an ancestor is still archived"
+// @Router       /org/units/restore [post]
+func RestoreOrgUnitHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        var body dto.OrgUnitArchiveDTO
+        if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+        }
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        actor, _ := bson.ObjectIDFromHex(uid)
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+
+        archive, err := services.RestoreOrgUnit(ctx, userPolicy, actor, body.OrgPath)
+        if err != nil {
+            return orgArchiveError(c, err)
+        }
+        return c.JSON(archive)
+    }
+}
+
+// orgTreeFormat เลือก yaml/json จาก ?format= หรือ Content-Type
+func orgTreeFormat(c *fiber.Ctx) string {
+    if f := strings.ToLower(c.Query("format")); f == "yaml" || f == "yml" {
+        return "yaml"
+    } else if f == "json" {
+        return "json"
+    }
+    if strings.Contains(c.Get(fiber.HeaderContentType), "yaml") {
+        return "yaml"
+    }
+    return "json"
+}
+
+// ExportOrgTreeHandler godoc
+// @Summary      Export an org subtree
+// @Description  Exports units, positions, policies (and optionally active memberships) under start as a JSON or YAML document suitable for version control and re-import. Requires organize:create on start.
+// @Tags         Org Units
+// @Produce      json
+// @Produce      application/x-yaml
+// @Param        start        query     string  false  "Root org path (default /)"
+// @Param        memberships  query     bool    false  "Include memberships"
+// @Param        format       query     string  false  "json (default) or yaml"
+// @Success      200          {object}  dto.OrgTreeDocument
+// @Failure      403          {object}  dto.ErrorResponse "no permission"
+// @Failure      404          {object}  dto.ErrorResponse "org unit not found"
+// @Router       /org/units/export [get]
+func ExportOrgTreeHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        start := c.Query("start", "/")
+        if err := services.CanManageOrg(userPolicy, "organize:create", start); err != nil {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+        }
+
+        doc, err := services.ExportOrgTree(c.Context(), start, c.QueryBool("memberships"))
+        if err != nil {
+            if errors.Is(err, services.ErrOrgUnitNotFound) {
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        }
+
+        format := orgTreeFormat(c)
+        out, err := services.EncodeOrgTreeDocument(doc, format)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        }
+        if format == "yaml" {
+            c.Set(fiber.HeaderContentType, "application/x-yaml; charset=utf-8")
+        } else {
+            c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+        }
+        return c.Send(out)
+    }
+}
+
+// ImportOrgTreeHandler godoc
+// @Summary      Import an org subtree (plan / apply)
+// @Description  Diffs a JSON or YAML org tree document against the database and returns a plan of creates, updates, restores and archives. With apply=true the plan is executed in one transaction; re-applying the same document is a no-op. Units under root missing from the document are archived. Policy actions that are created or changed must exist in the permission catalog (GET /permissions). Memberships are only managed when the document has a memberships section; new or reactivated memberships are checked like a regular assignment (unit exists, position in scope and not deprecated, exclusive_per_org) and any violation rejects the whole plan. Requires organize:create on the document root.
+// @Tags         Org Units
+// @Accept       json
+// @Accept       application/x-yaml
+// @Produce      json
+// @Param        apply   query     bool    false  "Apply the plan (default false = dry run)"
+// @Param        format  query     string  false  "json (default) or yaml; Content-Type containing yaml also works"
+// @Param        body    body      dto.OrgTreeDocument  true  "Org tree document"
+// @Success      200     {object}  dto.OrgTreePlan
+// @Failure      400     {object}  dto.ErrorResponse "invalid document, action not in the catalog or membership violation"
+// @Failure      403     {object}  dto.ErrorResponse "no permission"
+// @Router       /org/units/import [post]
+func ImportOrgTreeHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        actor, _ := bson.ObjectIDFromHex(uid)
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        doc, err := services.DecodeOrgTreeDocument(c.Body(), orgTreeFormat(c))
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+        defer cancel()
+
+        plan, err := services.ImportOrgTree(ctx, userPolicy, actor, doc, c.QueryBool("apply"))
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrOrgTreeInvalid):
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+            case errors.Is(err, services.ErrOrgNoPermission):
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        }
+        return c.JSON(plan)
+    }
+}
+
+// GetOrgUnitProfileHandler godoc
+// @Summary      Get an organization unit profile
+// @Description  Returns the unit profile (localized names, description, logo, contact, social links), member counts by position, post/event counts and upcoming events visible to the viewer. Old paths of moved units answer 301 with the new location.
+// @Tags         Org Units
+// @Produce      json
+// @Param        path  path      string  true   "Org path without the leading slash, e.g. fac/eng/smo"
+// @Param        lang  query     string  false  "Preferred language for display_name (th|en)"
+// @Success      200   {object}  dto.OrgUnitProfile
+// @Success      301   "unit moved; see Location"
+// @Failure      404   {object}  dto.ErrorResponse "org unit not found"
+// @Router       /org/units/{path} [get]
+func GetOrgUnitProfileHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        t, err := middleware.OrgFromWildcard()(c)
+        if err != nil {
+            return err
+        }
+        orgPath := t.OrgPath
+        if orgPath == "/" {
+            return fiber.NewError(fiber.StatusBadRequest, "org path is required")
+        }
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        viewerID, _ := bson.ObjectIDFromHex(uid)
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        profile, err := services.GetOrgUnitProfile(c.Context(), orgPath, c.Query("lang"), viewerID, userPolicy)
+        if err != nil {
+            var moved *services.OrgRedirectError
+            switch {
+            case errors.As(err, &moved):
+                c.Set(fiber.HeaderLocation, "/org/units"+moved.To)
+                return c.Status(fiber.StatusMovedPermanently).JSON(fiber.Map{"error": err.Error(), "org_path": moved.To})
+            case errors.Is(err, services.ErrOrgUnitNotFound):
+                return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+            }
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        }
+        return c.JSON(profile)
+    }
+}
+
+// UpdateOrgUnitProfileHandler godoc
+// @Summary      Edit an organization unit profile
+// @Description  Managers (organize:create on the unit) can edit name, localized names, description, logo, contact and social links. Send JSON, or multipart/form-data with a "logo" file (jpg, png or webp) and names/contact/social as JSON strings. Omitted fields are unchanged. The request is validated before the logo is stored, and the stored logo is removed again if the update fails.
+// @Tags         Org Units
+// @Accept       json
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        path  path      string                        true   "Org path without the leading slash"
+// @Param        body  body      dto.OrgUnitProfileUpdateDTO   false  "Profile fields (JSON)"
+// @Param        logo  formData  file                          false  "Logo image (jpg, png or webp)"
+// @Success      200   {object}  models.OrgUnitNode
+// @Failure      400   {object}  dto.ErrorResponse "invalid body or logo is not a jpg/png/webp image"
+// @Failure      403   {object}  dto.ErrorResponse "no permission"
+// @Failure      404   {object}  dto.ErrorResponse "org unit not found"
+// @Failure      409   {object}  dto.ErrorResponse "org unit is archived"
+// @Router       /org/units/{path} [patch]
+func UpdateOrgUnitProfileHandler(uploadDir string) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        // path เดียวกับที่ RequireAction(OrgFromWildcard) ตรวจสิทธิ์ไปแล้ว
+        orgPath := middleware.AuthzDecision(c).OrgPath
+
+        uid, err := middleware.UIDFromLocals(c)
+        if err != nil {
+            return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+        }
+        userPolicy, err := services.MyUserPolicy(c.Context(), uid)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+
+        var body dto.OrgUnitProfileUpdateDTO
+        var logo *multipart.FileHeader
+        if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+            if v := c.FormValue("name"); v != "" {
+                body.Name = &v
+            }
+            if v := c.FormValue("description"); v != "" {
+                body.Description = &v
+            }
+            for field, dst := range map[string]any{"names": &body.Names, "contact": &body.Contact, "social": &body.Social} {
+                if v := c.FormValue(field); v != "" {
+                    if err := json.Unmarshal([]byte(v), dst); err != nil {
+                        return fiber.NewError(fiber.StatusBadRequest, "invalid "+field+" JSON")
+                    }
+                }
+            }
+            if file, err := c.FormFile("logo"); err == nil && file != nil {
+                logo = file
+            }
+        } else if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+        }
+
+        // ตรวจทั้งคำขอก่อนบันทึกไฟล์ (สิทธิ์, archived, ค่าใน body และชนิดไฟล์)
+        if err := services.ValidateOrgUnitProfile(c.Context(), userPolicy, orgPath, body); err != nil {
+            return orgProfileError(c, err)
+        }
+        savePath := ""
+        if logo != nil {
+            ext, err := logoExt(logo)
+            if err != nil {
+                return orgProfileError(c, err)
+            }
+            filename := fmt.Sprintf("org_%d%s", time.Now().UnixNano()/1e6, ext)
+            savePath = filepath.Join(uploadDir, filename)
+            if err := c.SaveFile(logo, savePath); err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save file"})
+            }
+            publicURL := fmt.Sprintf("http://%s/uploads/%s", serverIP, filename)
+            body.LogoURL = &publicURL
+        }
+
+        node, err := services.UpdateOrgUnitProfile(c.Context(), userPolicy, orgPath, body)
+        if err != nil {
+            if savePath != "" {
+                _ = os.Remove(savePath)
+            }
+            return orgProfileError(c, err)
+        }
+        return c.JSON(node)
+    }
+}
+
+// logoExt อ่านต้นไฟล์มาตรวจว่าเป็นรูป jpg/png/webp จริง
+func logoExt(file *multipart.FileHeader) (string, error) {
+    f, err := file.Open()
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+    head := make([]byte, 512)
+    n, err := io.ReadFull(f, head)
+    if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+        return "", err
+    }
+    return services.ImageExt(file.Filename, file.Header.Get(fiber.HeaderContentType), head[:n])
+}
+
+func orgProfileError(c *fiber.Ctx, err error) error {
+    switch {
+    case errors.Is(err, services.ErrOrgUnitNotFound):
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgArchived):
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgNoPermission):
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrOrgMoveInvalid), errors.Is(err, services.ErrUploadNotImage):
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    }
+    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
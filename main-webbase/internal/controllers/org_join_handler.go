@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/models"
+	"main-webbase/internal/services"
+)
+
+// callerWithPolicies คืน user id และ policy ปัจจุบันของผู้เรียก
+func callerWithPolicies(c *fiber.Ctx) (bson.ObjectID, []models.Policy, error) {
+	uid, err := middleware.UIDFromLocals(c)
+	if err != nil {
+		return bson.ObjectID{}, nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	oid, err := bson.ObjectIDFromHex(uid)
+	if err != nil {
+		return bson.ObjectID{}, nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	policies, err := services.MyUserPolicy(c.Context(), uid)
+	if err != nil {
+		return bson.ObjectID{}, nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return oid, policies, nil
+}
+
+// CreateJoinRequestHandler godoc
+// @Summary      Request to join an org unit
+// @Description  The caller asks for a position at an org path with an optional message. Holders of membership:assign on that org are notified.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.JoinRequestCreateDTO  true  "Join request"
+// @Success      201   {object}  models.JoinRequest
+// @Failure      400   {object}  dto.ErrorResponse "invalid body / position not usable here"
+// @Failure      404   {object}  dto.ErrorResponse "org unit not found"
+// @Failure      409   {object}  dto.ErrorResponse "already a member or request pending"
+// @Router       /memberships/requests [post]
+func CreateJoinRequestHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.JoinRequestCreateDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		uid, _, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		r, err := services.CreateJoinRequest(c.Context(), uid, body)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(r)
+	}
+}
+
+// ListMyJoinRequestsHandler godoc
+// @Summary      My join requests
+// @Tags         memberships
+// @Produce      json
+// @Success      200  {array}  models.JoinRequest
+// @Router       /memberships/requests/mine [get]
+func ListMyJoinRequestsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		out, err := services.ListMyJoinRequests(c.Context(), uid)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(out)
+	}
+}
+
+// ListJoinRequestQueueHandler godoc
+// @Summary      Join request queue
+// @Description  Requests in org units the caller can assign memberships in (membership:assign). Defaults to pending.
+// @Tags         memberships
+// @Produce      json
+// @Param        org_path query string false "limit to this org subtree"
+// @Param        status   query string false "pending|approved|rejected|cancelled"
+// @Success      200  {array}   models.JoinRequest
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /memberships/requests [get]
+func ListJoinRequestQueueHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		out, err := services.ListJoinRequestQueue(c.Context(), policies, c.Query("org_path"), c.Query("status"))
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(out)
+	}
+}
+
+// ApproveJoinRequestHandler godoc
+// @Summary      Approve a join request
+// @Description  Creates the membership through the same checks as POST /memberships (scope, exclusivity). For exclusive positions pass end_previous=true to end the current holder's term.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                      true   "Join request ID"
+// @Param        body  body      dto.JoinRequestDecisionDTO  false  "Decision"
+// @Success      200   {object}  models.JoinRequest
+// @Failure      403   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.MembershipConflictResponse
+// @Router       /memberships/requests/{id}/approve [post]
+func ApproveJoinRequestHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		var body dto.JoinRequestDecisionDTO
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&body); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+			}
+		}
+		uid, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		r, err := services.ApproveJoinRequest(c.Context(), policies, uid, id, body)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(r)
+	}
+}
+
+// RejectJoinRequestHandler godoc
+// @Summary      Reject a join request
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                      true   "Join request ID"
+// @Param        body  body      dto.JoinRequestDecisionDTO  false  "Decision (note)"
+// @Success      200   {object}  models.JoinRequest
+// @Failure      403   {object}  dto.ErrorResponse
+// @Failure      404   {object}  dto.ErrorResponse
+// @Failure      409   {object}  dto.ErrorResponse "no longer pending"
+// @Router       /memberships/requests/{id}/reject [post]
+func RejectJoinRequestHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		var body dto.JoinRequestDecisionDTO
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&body); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+			}
+		}
+		uid, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		r, err := services.RejectJoinRequest(c.Context(), policies, uid, id, body.Note)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(r)
+	}
+}
+
+// CancelJoinRequestHandler godoc
+// @Summary      Cancel my pending join request
+// @Tags         memberships
+// @Produce      json
+// @Param        id  path      string  true  "Join request ID"
+// @Success      200 {object}  models.JoinRequest
+// @Failure      404 {object}  dto.ErrorResponse
+// @Failure      409 {object}  dto.ErrorResponse "no longer pending"
+// @Router       /memberships/requests/{id} [delete]
+func CancelJoinRequestHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		uid, _, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		r, err := services.CancelJoinRequest(c.Context(), uid, id)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(r)
+	}
+}
+
+// CreateOrgInviteHandler godoc
+// @Summary      Create an invitation code
+// @Description  Generates a code for a position at an org path with expiry (default 7 days) and max uses (0 = unlimited). Requires membership:assign on org_path.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.OrgInviteCreateDTO  true  "Invitation"
+// @Success      201   {object}  models.OrgInvite
+// @Failure      400   {object}  dto.ErrorResponse
+// @Failure      403   {object}  dto.ErrorResponse
+// @Router       /memberships/invites [post]
+func CreateOrgInviteHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.OrgInviteCreateDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		uid, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		inv, err := services.CreateOrgInvite(c.Context(), policies, uid, body)
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(inv)
+	}
+}
+
+// ListOrgInvitesHandler godoc
+// @Summary      List invitation codes of an org unit
+// @Tags         memberships
+// @Produce      json
+// @Param        org_path query string true "Organization path"
+// @Success      200  {array}   models.OrgInvite
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /memberships/invites [get]
+func ListOrgInvitesHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		out, err := services.ListOrgInvites(c.Context(), policies, c.Query("org_path"))
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(out)
+	}
+}
+
+// RevokeOrgInviteHandler godoc
+// @Summary      Revoke an invitation code
+// @Tags         memberships
+// @Produce      json
+// @Param        id  path      string  true  "Invite ID"
+// @Success      200 {object}  map[string]interface{}
+// @Failure      403 {object}  dto.ErrorResponse
+// @Failure      404 {object}  dto.ErrorResponse
+// @Router       /memberships/invites/{id} [delete]
+func RevokeOrgInviteHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+		}
+		_, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		if err := services.RevokeOrgInvite(c.Context(), policies, id); err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(fiber.Map{"_id": id.Hex(), "revoked": true})
+	}
+}
+
+// RedeemOrgInviteHandler godoc
+// @Summary      Join an org unit with an invitation code
+// @Description  Creates the membership with the invite creator's authority; fails if the creator no longer holds membership:assign.
+// @Tags         memberships
+// @Produce      json
+// @Param        code  path      string  true  "Invitation code"
+// @Success      200   {object}  models.Membership
+// @Failure      404   {object}  dto.ErrorResponse "unknown code"
+// @Failure      409   {object}  dto.MembershipConflictResponse
+// @Failure      410   {object}  dto.ErrorResponse "expired, revoked or used up"
+// @Router       /memberships/invites/{code}/redeem [post]
+func RedeemOrgInviteHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		m, err := services.RedeemOrgInvite(c.Context(), uid, c.Params("code"))
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(fiber.Map{"message": "membership created", "data": m})
+	}
+}
+
+// HandoverMembershipsHandler godoc
+// @Summary      Bulk term handover (plan / apply)
+// @Description  Compares the current holders of the listed positions at org_path with the incoming assignments and returns a plan of end / continue / start items. With apply=true the old terms are ended and the new ones started in one transaction, an audit record is stored, and everyone affected is notified. If the current holders changed after the plan was computed (a concurrent assignment or import), nothing is written and 409 is returned; preview again. Requires membership:assign on org_path.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Param        apply  query     bool             false  "Apply the plan (default false = preview)"
+// @Param        body   body      dto.HandoverDTO  true   "Incoming board"
+// @Success      200    {object}  dto.HandoverPlan
+// @Failure      400    {object}  dto.ErrorResponse
+// @Failure      403    {object}  dto.ErrorResponse
+// @Failure      404    {object}  dto.ErrorResponse
+// @Failure      409    {object}  dto.ErrorResponse "holders changed since the plan was computed"
+// @Router       /memberships/handover [post]
+func HandoverMembershipsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.HandoverDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		uid, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		plan, err := services.HandoverMemberships(c.Context(), policies, uid, body, c.QueryBool("apply"))
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(plan)
+	}
+}
+
+// ListMembershipHandoversHandler godoc
+// @Summary      Handover audit trail of an org unit
+// @Tags         memberships
+// @Produce      json
+// @Param        org_path query string true "Organization path"
+// @Success      200  {array}   models.MembershipHandover
+// @Failure      403  {object}  dto.ErrorResponse
+// @Router       /memberships/handovers [get]
+func ListMembershipHandoversHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, policies, err := callerWithPolicies(c)
+		if err != nil {
+			return err
+		}
+		out, err := services.ListMembershipHandovers(c.Context(), policies, c.Query("org_path"))
+		if err != nil {
+			return membershipError(c, err)
+		}
+		return c.JSON(out)
+	}
+}
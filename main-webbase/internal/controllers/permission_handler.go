@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"main-webbase/dto"
+	"main-webbase/internal/services"
+)
+
+// ListPermissionsHandler godoc
+// @Summary      Permission catalog
+// @Description  Every action a policy can grant, grouped by category, with English and Thai labels.
+//               kind is org (granted by policies), self (any signed-in user) or system (root only).
+// @Tags         Permissions
+// @Produce      json
+// @Success      200  {array}   dto.PermissionCategory
+// @Failure      500  {object}  dto.ErrorResponse
+// @Router       /permissions [get]
+func ListPermissionsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		out, err := services.PermissionCatalog(c.Context())
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(out)
+	}
+}
+
+// ListRoleTemplatesHandler godoc
+// @Summary      Role templates
+// @Description  Named bundles of actions that can be applied to a new position (policy.role in POST /positions).
+// @Tags         Permissions
+// @Produce      json
+// @Success      200  {array}   models.Role
+// @Failure      500  {object}  dto.ErrorResponse
+// @Router       /permissions/roles [get]
+func ListRoleTemplatesHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		out, err := services.ListRoleTemplates(c.Context())
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(out)
+	}
+}
+
+func roleTemplateError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPolicyInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleTemplateExists), errors.Is(err, services.ErrRoleTemplateBuiltin):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+}
+
+// CreateRoleTemplateHandler godoc
+// @Summary      Create a role template (root only)
+// @Tags         Permissions
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.RoleTemplateDTO  true  "Template"
+// @Success      201   {object}  models.Role
+// @Failure      400   {object}  dto.ErrorResponse "invalid template or action not in the catalog"
+// @Failure      403   {object}  dto.ErrorResponse "forbidden"
+// @Failure      409   {object}  dto.ErrorResponse "name already used"
+// @Router       /permissions/roles [post]
+func CreateRoleTemplateHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.RoleTemplateDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		r, err := services.CreateRoleTemplate(c.Context(), body)
+		if err != nil {
+			return roleTemplateError(c, err)
+		}
+		return c.Status(fiber.StatusCreated).JSON(r)
+	}
+}
+
+// UpdateRoleTemplateHandler godoc
+// @Summary      Update a role template (root only)
+// @Description  Replaces the labels and permissions. Positions created from the template earlier are not changed.
+// @Tags         Permissions
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string               true  "Template name"
+// @Param        body  body      dto.RoleTemplateDTO  true  "Template"
+// @Success      200   {object}  models.Role
+// @Failure      400   {object}  dto.ErrorResponse "invalid template or action not in the catalog"
+// @Failure      403   {object}  dto.ErrorResponse "forbidden"
+// @Failure      404   {object}  dto.ErrorResponse "template not found"
+// @Router       /permissions/roles/{name} [put]
+func UpdateRoleTemplateHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body dto.RoleTemplateDTO
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+		}
+		r, err := services.UpdateRoleTemplate(c.Context(), c.Params("name"), body)
+		if err != nil {
+			return roleTemplateError(c, err)
+		}
+		return c.JSON(r)
+	}
+}
+
+// DeleteRoleTemplateHandler godoc
+// @Summary      Delete a role template (root only)
+// @Description  Built-in templates cannot be deleted.
+// @Tags         Permissions
+// @Param        name  path  string  true  "Template name"
+// @Success      204
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Failure      404  {object}  dto.ErrorResponse "template not found"
+// @Failure      409  {object}  dto.ErrorResponse "built-in template"
+// @Router       /permissions/roles/{name} [delete]
+func DeleteRoleTemplateHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := services.DeleteRoleTemplate(c.Context(), c.Params("name")); err != nil {
+			return roleTemplateError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+    "errors"
+
     "github.com/gofiber/fiber/v2"
     "main-webbase/internal/services"
     "main-webbase/internal/middleware"
     "main-webbase/dto"
+    "main-webbase/internal/models"
     repo "main-webbase/internal/repository"
     "go.mongodb.org/mongo-driver/v2/bson"
     "strings"
@@ -13,14 +16,16 @@ import (
 
 // UpdatePolicyHandler godoc
 // @Summary      Update Policy actions
-// @Description  Updates policy actions for a position. Only the actions sent will be kept. Supported actions:
-//               "membership:assign", "organize:create", "event:create". Sending fewer actions will remove the rest.
+// @Description  Updates policy actions for a position. Only the actions sent will be kept; sending fewer actions will
+//               remove the rest. Every action must exist in the permission catalog (GET /permissions).
+//               The position must rank strictly below the caller's own, and only actions the caller holds can be granted.
+//               Every change is recorded as a version (see GET /policies/{id}/history).
 // @Tags         Policies
 // @Accept       json
 // @Produce      json
 // @Param        body  body      dto.PolicyUpdateDTO  true  "Policy update data"
 // @Success      201   {object}  map[string]interface{} "policy updated successfully"
-// @Failure      400   {object}  dto.ErrorResponse "invalid body"
+// @Failure      400   {object}  dto.ErrorResponse "invalid body or action not in the catalog"
 // @Failure      401   {object}  dto.ErrorResponse "unauthorized"
 // @Failure      403   {object}  dto.ErrorResponse "no permission to manage this policy"
 // @Failure      404   {object}  dto.ErrorResponse "target policy not found"
@@ -54,10 +59,25 @@ func UpdatePolicyHandler() fiber.Handler {
         if err := services.CanManagePolicy(userPolicy, targetPolicy); err != nil {
 			return fiber.NewError(fiber.StatusForbidden, "no permission to manage this policy")
 		}
+        if err := services.ValidateCatalogActions(c.Context(), body.Actions); err != nil {
+            if errors.Is(err, services.ErrPolicyInvalid) {
+                return fiber.NewError(fiber.StatusBadRequest, err.Error())
+            }
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        // ตำแหน่งต้อง rank ต่ำกว่าผู้แก้ และให้ได้เฉพาะ action ที่ผู้แก้มีเอง
+        if err := services.CanEditPolicy(c.Context(), userPolicy, targetPolicy, body.Actions); err != nil {
+            if errors.Is(err, services.ErrRankTooHigh) || errors.Is(err, services.ErrActionNotHeld) {
+                return fiber.NewError(fiber.StatusForbidden, err.Error())
+            }
+            if errors.Is(err, services.ErrPositionNotFound) {
+                return fiber.NewError(fiber.StatusNotFound, err.Error())
+            }
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
 
-        targetPolicy.Actions = body.Actions
-
-        if err := services.UpdatedPolicy(c.Context(), targetPolicy); err != nil {
+        actor, _ := bson.ObjectIDFromHex(uid)
+        if err := services.UpdatePolicyActions(c.Context(), actor, targetPolicy, body.Actions); err != nil {
             return fiber.NewError(fiber.StatusInternalServerError, "failed to update policy")
         }
         return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -73,6 +93,50 @@ func UpdatePolicyHandler() fiber.Handler {
     }
 }
 
+// CreatePolicyRuleHandler godoc
+// @Summary      Create a deny or conditional policy rule
+// @Description  Adds a rule next to the position's main policy. effect=deny always wins over allow for the same action;
+//               conditions (e.g. {"status":"draft"}) must all match the target. Policies defined on a unit are inherited
+//               by holders of the position in every child unit. Actions must exist in the permission catalog.
+//               Requires policy:manage on org_prefix.
+// @Tags         Policies
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.PolicyRuleCreateDTO  true  "Policy rule"
+// @Success      201   {object}  models.Policy
+// @Failure      400   {object}  dto.ErrorResponse "invalid policy"
+// @Failure      403   {object}  dto.ErrorResponse "forbidden"
+// @Failure      404   {object}  dto.ErrorResponse "position not found"
+// @Failure      409   {object}  dto.ErrorResponse "rule already exists"
+// @Router       /policies [post]
+func CreatePolicyRuleHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        var body dto.PolicyRuleCreateDTO
+        if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+        }
+
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+        policy, err := services.CreatePolicyRule(c.Context(), subject.Policies, subject.UserID, body)
+        switch {
+        case errors.Is(err, services.ErrRankTooHigh), errors.Is(err, services.ErrActionNotHeld):
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPolicyInvalid):
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPositionNotFound):
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPolicyExists):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+        case err != nil:
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        return c.Status(fiber.StatusCreated).JSON(policy)
+    }
+}
+
 // ListPolicies godoc
 // @Summary      List policies
 // @Description  Returns policies, optionally filtered by org_prefix and/or position_key
@@ -104,6 +168,8 @@ func ListPolicies() fiber.Handler {
                 "org_prefix":   p.OrgPrefix,
                 "actions":      p.Actions,
                 "enabled":      p.Enabled,
+                "effect":       p.Effect,
+                "conditions":   p.Conditions,
                 "created_at":   p.CreatedAt,
                 "updatedAt":    p.UpdatedAt,
             })
@@ -114,7 +180,8 @@ func ListPolicies() fiber.Handler {
 
 // DeletePolicyHandler godoc
 // @Summary      Delete policies
-// @Description  Delete policies by org_prefix and optional position_key
+// @Description  Delete policies by org_prefix and optional position_key. Each deleted policy is recorded in its history
+//               and can be restored with POST /policies/{id}/rollback.
 // @Tags         Policies
 // @Accept       json
 // @Produce      json
@@ -122,6 +189,7 @@ func ListPolicies() fiber.Handler {
 // @Param        position_key query string false "Position key"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
+// @Failure      403 {object} map[string]string
 // @Failure      500 {object} map[string]string
 // @Router       /policies [delete]
 func DeletePolicyHandler() fiber.Handler {
@@ -134,10 +202,116 @@ func DeletePolicyHandler() fiber.Handler {
         col := database.DB.Collection("policies")
         filter := bson.M{"org_prefix": orgPrefix}
         if posKey != "" { filter["position_key"] = posKey }
-        res, err := col.DeleteMany(c.Context(), filter)
+
+        // ลบได้เฉพาะ policy ของตำแหน่งที่ rank ต่ำกว่าผู้ลบ
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+        var targets []models.Policy
+        cur, err := col.Find(c.Context(), filter)
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        if err := cur.All(c.Context(), &targets); err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        for i := range targets {
+            err := services.CanEditPolicy(c.Context(), subject.Policies, &targets[i], nil)
+            if errors.Is(err, services.ErrRankTooHigh) {
+                return fiber.NewError(fiber.StatusForbidden, err.Error())
+            }
+            if err != nil && !errors.Is(err, services.ErrPositionNotFound) {
+                return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+            }
+        }
+
+        deleted, err := services.DeletePoliciesVersioned(c.Context(), subject.UserID, targets)
         if err != nil {
             return fiber.NewError(fiber.StatusInternalServerError, err.Error())
         }
-        return c.JSON(fiber.Map{"deleted": res.DeletedCount})
+        return c.JSON(fiber.Map{"deleted": deleted})
+    }
+}
+
+// PolicyHistoryHandler godoc
+// @Summary      Policy change history
+// @Description  Versions of a policy, newest first: who changed it, when, before/after snapshots and a diff.
+//               op is baseline (state before history was recorded), create, update, delete or rollback.
+//               Deleted policies keep their history. Requires policy:manage on the policy's org_prefix.
+// @Tags         Policies
+// @Produce      json
+// @Param        id   path      string  true  "Policy ID"
+// @Success      200  {array}   models.PolicyVersion
+// @Failure      400  {object}  dto.ErrorResponse "invalid id"
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Failure      404  {object}  dto.ErrorResponse "policy not found"
+// @Router       /policies/{id}/history [get]
+func PolicyHistoryHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        id, err := bson.ObjectIDFromHex(c.Params("id"))
+        if err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+        }
+        list, err := services.PolicyHistory(c.Context(), id)
+        if errors.Is(err, services.ErrPolicyNotFound) {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+        }
+        if err != nil {
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        return c.JSON(list)
+    }
+}
+
+// RollbackPolicyHandler godoc
+// @Summary      Roll a policy back to a previous version
+// @Description  Restores the policy to its state right after the given version (recreating it if it was deleted).
+//               The caller must be able to manage both the current and the restored policy, with the usual rank and
+//               held-action checks. Every restored action must still exist in the permission catalog (GET /permissions).
+//               position_key and org_prefix always follow the current position and unit (renames and moves are not undone),
+//               and a policy of an archived unit cannot be rolled back until the unit is restored.
+//               The rollback itself is recorded as a new version.
+// @Tags         Policies
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                  true  "Policy ID"
+// @Param        body  body      dto.PolicyRollbackDTO   true  "Version to restore"
+// @Success      200   {object}  models.PolicyVersion
+// @Failure      400   {object}  dto.ErrorResponse "invalid version or action not in the catalog"
+// @Failure      403   {object}  dto.ErrorResponse "forbidden"
+// @Failure      404   {object}  dto.ErrorResponse "version not found"
+// @Failure      409   {object}  dto.ErrorResponse "an equivalent policy already exists or the unit is archived"
+// @Router       /policies/{id}/rollback [post]
+func RollbackPolicyHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        id, err := bson.ObjectIDFromHex(c.Params("id"))
+        if err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+        }
+        var body dto.PolicyRollbackDTO
+        if err := c.BodyParser(&body); err != nil || body.Version <= 0 {
+            return fiber.NewError(fiber.StatusBadRequest, "version is required")
+        }
+
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+        v, err := services.RollbackPolicy(c.Context(), subject.Policies, subject.UserID, id, body.Version)
+        switch {
+        case errors.Is(err, services.ErrPolicyNoPermission), errors.Is(err, services.ErrRankTooHigh), errors.Is(err, services.ErrActionNotHeld):
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPolicyInvalid):
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPolicyVersionNotFound), errors.Is(err, services.ErrPositionNotFound),
+            errors.Is(err, services.ErrOrgUnitNotFound):
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+        case errors.Is(err, services.ErrPolicyExists), errors.Is(err, services.ErrOrgArchived):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+        case err != nil:
+            return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+        }
+        return c.JSON(v)
     }
 }
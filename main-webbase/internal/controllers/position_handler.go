@@ -1,8 +1,12 @@
 package controllers
 
 import (
+    "errors"
+
     "github.com/gofiber/fiber/v2"
+    "go.mongodb.org/mongo-driver/v2/bson"
     // "main-webbase/internal/repository"
+    "main-webbase/internal/middleware"
     "main-webbase/internal/services"
     "main-webbase/dto"
     repo "main-webbase/internal/repository"
@@ -10,15 +14,18 @@ import (
 
 // CreatePosition godoc
 // @Summary      Create a new Position with Policy
-// @Description  Create a new position and attach a policy. Policy actions support only:
-//               "membership:assign", "organize:create", "event:create".
-//               Any missing action in update will be removed from the policy.
+// @Description  Create a new position and attach a policy. Policy actions must exist in the permission catalog
+//               (GET /permissions). policy.role applies a role template (GET /permissions/roles): its actions are
+//               added to policy.actions. Any missing action in update will be removed from the policy.
+//               The new rank must be lower than your own position:manage rank there, and policy actions must be ones you hold.
 // @Tags         Positions
 // @Accept       json
 // @Produce      json
 // @Param        body  body      dto.PositionCreateDTO  true  "Position & Policy data"
 // @Success      201   {object}  map[string]interface{} "position created successfully"
-// @Failure      400   {object}  dto.ErrorResponse "invalid body"
+// @Failure      400   {object}  dto.ErrorResponse "invalid body or action not in the catalog"
+// @Failure      403   {object}  dto.ErrorResponse "rank not lower than yours, or granting an action you do not hold"
+// @Failure      404   {object}  dto.ErrorResponse "role template not found"
 // @Failure      500   {object}  dto.ErrorResponse "internal server error"
 // @Router       /positions [post]
 func CreatePosition() fiber.Handler {
@@ -28,7 +35,21 @@ func CreatePosition() fiber.Handler {
             return fiber.NewError(fiber.StatusBadRequest, "invalid body")
         }
 
-        position, policy, err := services.CreatePositionWithPolicy(body, c.Context())
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+
+        position, policy, err := services.CreatePositionWithPolicy(subject.Policies, body, c.Context())
+        if errors.Is(err, services.ErrRankTooHigh) || errors.Is(err, services.ErrActionNotHeld) {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+        }
+        if errors.Is(err, services.ErrPolicyInvalid) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        }
+        if errors.Is(err, services.ErrRoleTemplateNotFound) {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+        }
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -75,3 +96,90 @@ func ListPositions() fiber.Handler {
         return c.JSON(fiber.Map{"data": positions})
     }
 }
+
+func positionError(c *fiber.Ctx, err error) error {
+    switch {
+    case errors.Is(err, services.ErrPositionInvalid), errors.Is(err, services.ErrPositionOutOfScope):
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrPositionNoPermission), errors.Is(err, services.ErrRankTooHigh):
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrPositionNotFound):
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+    case errors.Is(err, services.ErrPositionKeyTaken), errors.Is(err, services.ErrPositionInUse),
+        errors.Is(err, services.ErrPositionExclusive), errors.Is(err, services.ErrPositionDeprecated):
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+    }
+    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// UpdatePositionHandler godoc
+// @Summary      Update a position
+// @Description  Partially updates key, display, rank, constraints, scope or status (active|deprecated). Deprecated positions keep their holders but take no new memberships.
+// @Description  Renaming the key cascades to memberships, policies, posts (postAs), events (posted_as), join requests and invitations in one transaction.
+// @Description  Requires position:manage on the position's scope; the position and its new rank must be below the caller's own rank.
+// @Tags         Positions
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string                 true  "Position ID"
+// @Param        body  body      dto.PositionUpdateDTO  true  "Fields to change"
+// @Success      200   {object}  dto.PositionChangeReport
+// @Failure      400   {object}  dto.ErrorResponse "invalid body"
+// @Failure      403   {object}  dto.ErrorResponse "no permission / rank too high"
+// @Failure      404   {object}  dto.ErrorResponse "position not found"
+// @Failure      409   {object}  dto.ErrorResponse "key taken / holders outside new scope / exclusive conflict"
+// @Router       /positions/{id} [patch]
+func UpdatePositionHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        id, err := bson.ObjectIDFromHex(c.Params("id"))
+        if err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+        }
+        var body dto.PositionUpdateDTO
+        if err := c.BodyParser(&body); err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+        }
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+
+        report, err := services.UpdatePosition(c.Context(), subject.Policies, subject.UserID, id, body)
+        if err != nil {
+            return positionError(c, err)
+        }
+        return c.JSON(report)
+    }
+}
+
+// DeletePositionHandler godoc
+// @Summary      Delete a position
+// @Description  Deletes the position and its policies. Refused (409) while the position has active memberships unless replacement is given;
+// @Description  then holders, posts, events, join requests and invitations move to the replacement position (holders who already hold it just have the old membership ended).
+// @Tags         Positions
+// @Produce      json
+// @Param        id           path      string  true   "Position ID"
+// @Param        replacement  query     string  false  "Position key to migrate active holders to"
+// @Success      200   {object}  dto.PositionChangeReport
+// @Failure      400   {object}  dto.ErrorResponse "invalid replacement"
+// @Failure      403   {object}  dto.ErrorResponse "no permission / rank too high"
+// @Failure      404   {object}  dto.ErrorResponse "position not found"
+// @Failure      409   {object}  dto.ErrorResponse "active memberships exist"
+// @Router       /positions/{id} [delete]
+func DeletePositionHandler() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        id, err := bson.ObjectIDFromHex(c.Params("id"))
+        if err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+        }
+        subject, err := middleware.SubjectFromCtx(c)
+        if err != nil {
+            return err
+        }
+
+        report, err := services.DeletePosition(c.Context(), subject.Policies, subject.UserID, id, c.Query("replacement"))
+        if err != nil {
+            return positionError(c, err)
+        }
+        return c.JSON(report)
+    }
+}
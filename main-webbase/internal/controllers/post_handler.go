@@ -9,7 +9,6 @@ import (
 	"main-webbase/dto"
 	"main-webbase/internal/accessctx"
 	mid "main-webbase/internal/middleware"
-	repo "main-webbase/internal/repository"
 	"main-webbase/internal/services"
 	"strings"
 	"time"
@@ -52,8 +51,10 @@ const serverIP = "45.144.166.252:46602"
 // @Param org_of_content formData string false "Organization of content"
 // @Param postAs.org_path formData string true "Organization path"
 // @Param postAs.position_key formData string true "Position key"
-// @Param visibility.access formData string false "Visibility (default: public)"
+// @Param visibility.access formData string false "Visibility: public (default), private or org (send targets/positions/users as JSON body)"
 // @Param categoryIds formData string false "Category IDs (repeatable)"
+// @Param status formData string false "Empty/active = publish now, draft, or scheduled"
+// @Param publishAt formData string false "RFC3339 publish time (required when status=scheduled)"
 // @Success 201 {object} dto.PostResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 403 {object} dto.ErrorResponse
@@ -126,8 +127,12 @@ func CreatePostHandler(client *mongo.Client) fiber.Handler {
 				return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: "user not found"})
 			case errors.Is(err, services.ErrOrgNodeNotFound):
 				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "org_path not found"})
+			case errors.Is(err, services.ErrOrgArchived):
+				return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Error: err.Error()})
 			case errors.Is(err, services.ErrPositionNotFound):
 				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "position_key not found"})
+			case errors.Is(err, services.ErrPostInvalidSchedule), errors.Is(err, services.ErrPostInvalidAudience):
+				return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 			default:
 				return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
 			}
@@ -157,7 +162,10 @@ func GetIndividualPostHandler(client *mongo.Client) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		postIDHex := c.Params("post_id")
-		userID, _ := mid.UIDObjectID(c)
+		viewer, err := mid.SubjectFromCtx(c) // ร่าง/โพสต์ตั้งเวลา ต้องมีสิทธิ์ post:update
+		if err != nil {
+			return err
+		}
 
 		if postIDHex == "" {
 			return fiber.NewError(fiber.StatusBadRequest, "missing post_id in route")
@@ -173,7 +181,7 @@ func GetIndividualPostHandler(client *mongo.Client) fiber.Handler {
 
 		db := client.Database(dbName)
 
-		resp, err := services.GetPostDetail(ctx, db, userID, postID)
+		resp, err := services.GetPostDetail(ctx, db, viewer, viewerRoles(c), postID)
 		if err != nil {
 			// ถ้าถูก wrap ด้วย %w จาก service จะเช็ค ErrNoDocuments ได้
 			if errors.Is(err, mongo.ErrNoDocuments) {
@@ -206,14 +214,15 @@ func DeletePostHandler(client *mongo.Client) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "invalid post id")
 		}
 
-		isRoot := isRootByPath(viewerFrom(c))
+		// owner ผ่าน RequireAction ได้เสมอ; root/post:moderate ลบของคนอื่นได้
+		isRoot := mid.AuthzDecision(c).Elevated()
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		db := client.Database("unicom")
 
-		ok, err := repo.DeletePost(db, postID, ctx, uid, isRoot)
+		ok, err := services.DeletePost(client, db, postID, uid, isRoot, ctx)
 		if err != nil {
 			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -230,6 +239,8 @@ func DeletePostHandler(client *mongo.Client) fiber.Handler {
 // UpdatePostHandler godoc
 // @Summary      Update a post (full replace of editable fields)
 // @Description  Update post text, medias, categories, visibility, and posted-as. Owner can edit content; admin/root can also change status.
+//               Every edit is kept as a revision (GET /posts/{post_id}/revisions) and sets editedAt. When POST_EDIT_WINDOW_MINUTES
+//               is set, the author can only edit within that many minutes after posting; moderators can always edit.
 // @Tags         posts
 // @Accept       json
 // @Produce      json
@@ -243,7 +254,7 @@ func DeletePostHandler(client *mongo.Client) fiber.Handler {
 // @Failure      403            {object}  dto.ErrorResponse
 // @Failure      500            {object}  dto.ErrorResponse
 // @Router       /posts/{post_id} [put]
-func UpdatePostHandler(client *mongo.Client) fiber.Handler {
+func UpdatePostHandler(client *mongo.Client, editWindow time.Duration) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := mid.UIDObjectID(c)
 		fmt.Printf("[Handler] uid=%s\n", uid.Hex())
@@ -262,7 +273,7 @@ func UpdatePostHandler(client *mongo.Client) fiber.Handler {
 			return fiber.NewError(fiber.StatusBadRequest, "postText required")
 		}
 
-		isRoot := isRootByPath(viewerFrom(c)) // root/admin = OrgPath == "/"
+		isRoot := mid.AuthzDecision(c).Elevated() // root หรือ post:moderate ของ org ที่โพสต์
 
 		// ✅ เช็คสิทธิ์ postAs ถ้าส่งมาแก้ (เรา require postAs ใน DTO อยู่แล้ว)
 		// ถ้าอยากให้ "ไม่บังคับส่ง postAs ทุกครั้ง" ให้เช็คเฉพาะกรณีที่มีค่าใหม่
@@ -275,9 +286,13 @@ func UpdatePostHandler(client *mongo.Client) fiber.Handler {
 		defer cancel()
 
 		db := client.Database("unicom")
-		if _, err := services.UpdatePostFull(client, db, postID, uid, isRoot, body, ctx); err != nil {
+		if _, err := services.UpdatePostFull(client, db, postID, uid, isRoot, body, editWindow, ctx); err != nil {
 			msg := err.Error()
 			switch {
+			case errors.Is(err, services.ErrPostEditWindowClosed):
+				return c.Status(403).JSON(dto.ErrorResponse{Error: services.ErrPostEditWindowClosed.Error()})
+			case errors.Is(err, services.ErrPostInvalidAudience):
+				return c.Status(400).JSON(dto.ErrorResponse{Error: msg})
 			case strings.Contains(msg, "forbidden"):
 				// fmt.Println("[FORBIDDEN-3] handler caught forbidden:", err)
 				return c.Status(403).JSON(dto.ErrorResponse{Error: "forbidden"})
@@ -290,10 +305,43 @@ func UpdatePostHandler(client *mongo.Client) fiber.Handler {
 			}
 		}
 
-		resp, err := services.GetPostDetail(ctx, client.Database("unicom"), uid, postID)
+		viewer, err := mid.SubjectFromCtx(c)
+		if err != nil {
+			return err
+		}
+		resp, err := services.GetPostDetail(ctx, client.Database("unicom"), viewer, viewerRoles(c), postID)
 		if err != nil {
 			return c.Status(500).JSON(dto.ErrorResponse{Error: err.Error()})
 		}
 		return c.Status(200).JSON(resp)
 	}
 }
+
+// PostRevisionsHandler godoc
+// @Summary      Post edit history
+// @Description  Revisions of a post, newest first. Revision 1 is the original; each later one is the content after an edit
+//               with the editor and time. Empty when the post was never edited. For the author and moderators (post:update).
+// @Tags         posts
+// @Produce      json
+// @Param        post_id  path  string  true  "Post ID (hex)"
+// @Success      200  {array}   models.PostRevision
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /posts/{post_id}/revisions [get]
+func PostRevisionsHandler(client *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		postID, err := bson.ObjectIDFromHex(c.Params("post_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid post id")
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		revs, err := services.PostRevisions(ctx, client.Database("unicom"), postID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(revs)
+	}
+}
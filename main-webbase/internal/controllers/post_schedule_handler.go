@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"main-webbase/dto"
+	mid "main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func postScheduleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPostInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrPostNotUnpublished), errors.Is(err, services.ErrOrgArchived):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Error: err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+}
+
+// ListDraftPostsHandler godoc
+// @Summary      My drafts and scheduled posts
+// @Description  Unpublished posts (status draft or scheduled) the caller can edit: their own,
+//               and those posted as an org where the caller has post:update. Latest update first.
+// @Tags         posts
+// @Produce      json
+// @Success      200  {array}   dto.PostResponse
+// @Failure      401  {object}  dto.ErrorResponse
+// @Router       /posts/drafts [get]
+func ListDraftPostsHandler(client *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		s, err := mid.SubjectFromCtx(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		posts, err := services.ListUnpublishedPosts(ctx, client.Database("unicom"), s)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(posts)
+	}
+}
+
+// PublishPostHandler godoc
+// @Summary      Publish a draft or scheduled post now
+// @Description  Sets status to active with createdAt = now, and indexes hashtags (trending counts the publish day).
+//               409 when the post is not a draft/scheduled post or its org is archived.
+// @Tags         posts
+// @Produce      json
+// @Param        post_id  path  string  true  "Post ID (hex)"
+// @Success      200  {object}  dto.PostResponse
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      409  {object}  dto.ErrorResponse
+// @Router       /posts/{post_id}/publish [post]
+func PublishPostHandler(client *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		postID, err := bson.ObjectIDFromHex(c.Params("post_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid post id")
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+		defer cancel()
+
+		db := client.Database("unicom")
+		if _, err := services.PublishPost(ctx, client, db, postID); err != nil {
+			return postScheduleError(c, err)
+		}
+		s, err := mid.SubjectFromCtx(c)
+		if err != nil {
+			return err
+		}
+		resp, err := services.GetPostDetail(ctx, db, s, viewerRoles(c), postID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(resp)
+	}
+}
+
+// SchedulePostHandler godoc
+// @Summary      Schedule or reschedule a post
+// @Description  Draft or scheduled post → scheduled at publishAt (must be in the future)
+// @Tags         posts
+// @Accept       json
+// @Produce      json
+// @Param        post_id  path  string               true  "Post ID (hex)"
+// @Param        body     body  dto.PostScheduleDTO  true  "Publish time"
+// @Success      204
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      409  {object}  dto.ErrorResponse
+// @Router       /posts/{post_id}/schedule [put]
+func SchedulePostHandler(client *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		postID, err := bson.ObjectIDFromHex(c.Params("post_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid post id")
+		}
+		var body dto.PostScheduleDTO
+		if err := c.BodyParser(&body); err != nil || body.PublishAt.IsZero() {
+			return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "publishAt is required"})
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := services.SchedulePost(ctx, client.Database("unicom"), postID, body.PublishAt); err != nil {
+			return postScheduleError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// CancelScheduledPostHandler godoc
+// @Summary      Cancel a scheduled post
+// @Description  Scheduled post → draft (publishAt cleared). Delete it with DELETE /posts/{post_id}
+// @Tags         posts
+// @Param        post_id  path  string  true  "Post ID (hex)"
+// @Success      204
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      403  {object}  dto.ErrorResponse
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      409  {object}  dto.ErrorResponse
+// @Router       /posts/{post_id}/schedule [delete]
+func CancelScheduledPostHandler(client *mongo.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		postID, err := bson.ObjectIDFromHex(c.Params("post_id"))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid post id")
+		}
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := services.CancelScheduledPost(ctx, client.Database("unicom"), postID); err != nil {
+			return postScheduleError(c, err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/config"
+	"main-webbase/dto"
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/models"
+	"main-webbase/internal/services"
+)
+
+const tusVersion = "1.0.0"
+
+type UploadHandler struct {
+	Store *services.UploadStore
+}
+
+func NewUploadHandler(store *services.UploadStore) *UploadHandler {
+	return &UploadHandler{Store: store}
+}
+
+// parseUploadMetadata แปลง header Upload-Metadata ("key base64,key2 base64") เป็น map
+func parseUploadMetadata(raw string) map[string]string {
+	out := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(parts) == 0 || parts[0] == "" {
+			continue
+		}
+		val := ""
+		if len(parts) == 2 {
+			if b, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				val = string(b)
+			}
+		}
+		out[parts[0]] = val
+	}
+	return out
+}
+
+func uploadError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadOffsetMismatch):
+		return c.Status(fiber.StatusConflict).JSON(dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadTooLarge), errors.Is(err, services.ErrUploadQuotaExceeded):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadNotVideo):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(dto.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrUploadNotActive):
+		return c.Status(fiber.StatusGone).JSON(dto.ErrorResponse{Error: err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+}
+
+func uploadReport(up *models.Upload) dto.UploadReport {
+	r := dto.UploadReport{
+		ID:       up.ID.Hex(),
+		FileName: up.Original,
+		MimeType: up.MimeType,
+		Length:   up.Length,
+		Offset:   up.Offset,
+		Status:   up.Status,
+	}
+	if up.Status == models.UploadStatusCompleted {
+		r.StreamURL = "/media/videos/" + up.ID.Hex()
+		r.PublicURL = fmt.Sprintf("http://%s/uploads/%s", serverIP, up.FileName)
+	}
+	return r
+}
+
+// Create godoc
+// @Summary      Start a resumable video upload
+// @Description  tus-style creation. Send Upload-Length (bytes) and optional Upload-Metadata ("filename <b64>,filetype <b64>"). Per-user quota is enforced.
+// @Tags         uploads
+// @Produce      json
+// @Security     BearerAuth
+// @Param        Upload-Length    header  int     true   "Total file size in bytes"
+// @Param        Upload-Metadata  header  string  false  "tus metadata (filename, filetype)"
+// @Success      201  {object}  dto.UploadReport
+// @Failure      400  {object}  dto.ErrorResponse
+// @Failure      413  {object}  dto.ErrorResponse "file too large or quota exceeded"
+// @Failure      415  {object}  dto.ErrorResponse "not a video"
+// @Router       /uploads [post]
+func (h *UploadHandler) Create(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: "unauthorized"})
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "Upload-Length header is required"})
+	}
+	meta := parseUploadMetadata(c.Get("Upload-Metadata"))
+
+	up, err := h.Store.Create(c.Context(), uid, length, meta["filename"], meta["filetype"])
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Location", "/uploads/"+up.ID.Hex())
+	c.Set("Upload-Offset", "0")
+	return c.Status(fiber.StatusCreated).JSON(uploadReport(up))
+}
+
+// Head godoc
+// @Summary      Get resumable upload offset
+// @Description  tus-style HEAD. Returns Upload-Offset / Upload-Length headers so the client can resume.
+// @Tags         uploads
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Upload ID"
+// @Success      200
+// @Failure      404
+// @Router       /uploads/{id} [head]
+func (h *UploadHandler) Head(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	up, err := h.Store.Get(c.Context(), id, uid)
+	if err != nil {
+		if errors.Is(err, services.ErrUploadNotFound) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Cache-Control", "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(up.Length, 10))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// Get godoc
+// @Summary      Get resumable upload status
+// @Tags         uploads
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Upload ID"
+// @Success      200  {object}  dto.UploadReport
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /uploads/{id} [get]
+func (h *UploadHandler) Get(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: "unauthorized"})
+	}
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "invalid upload id"})
+	}
+	up, err := h.Store.Get(c.Context(), id, uid)
+	if err != nil {
+		return uploadError(c, err)
+	}
+	return c.JSON(uploadReport(up))
+}
+
+// Patch godoc
+// @Summary      Append a chunk to a resumable upload
+// @Description  tus-style PATCH. Body is raw bytes (Content-Type: application/offset+octet-stream), Upload-Offset must match the server offset. Max chunk 4 MiB.
+// @Tags         uploads
+// @Accept       application/offset+octet-stream
+// @Security     BearerAuth
+// @Param        id             path    string  true  "Upload ID"
+// @Param        Upload-Offset  header  int     true  "Offset this chunk starts at"
+// @Success      204
+// @Failure      404  {object}  dto.ErrorResponse
+// @Failure      409  {object}  dto.ErrorResponse "offset mismatch"
+// @Failure      415  {object}  dto.ErrorResponse
+// @Router       /uploads/{id} [patch]
+func (h *UploadHandler) Patch(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: "unauthorized"})
+	}
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: "upload not found"})
+	}
+	if ct := c.Get(fiber.HeaderContentType); ct != "application/offset+octet-stream" {
+		return c.Status(fiber.StatusUnsupportedMediaType).
+			JSON(dto.ErrorResponse{Error: "Content-Type must be application/offset+octet-stream"})
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: "Upload-Offset header is required"})
+	}
+	chunk := c.Body()
+	if len(chunk) > config.MaxUploadChunkBytes {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(dto.ErrorResponse{Error: "chunk too large"})
+	}
+
+	up, err := h.Store.Append(c.Context(), id, uid, offset, chunk)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Upload-Offset", strconv.FormatInt(up.Offset, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Delete godoc
+// @Summary      Abort a resumable upload
+// @Tags         uploads
+// @Security     BearerAuth
+// @Param        id   path  string  true  "Upload ID"
+// @Success      204
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /uploads/{id} [delete]
+func (h *UploadHandler) Delete(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: "unauthorized"})
+	}
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: "upload not found"})
+	}
+	if err := h.Store.Abort(c.Context(), id, uid); err != nil {
+		return uploadError(c, err)
+	}
+	c.Set("Tus-Resumable", tusVersion)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Quota godoc
+// @Summary      Get my upload quota usage
+// @Tags         uploads
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  dto.UploadQuotaResponse
+// @Router       /uploads/quota [get]
+func (h *UploadHandler) Quota(c *fiber.Ctx) error {
+	uid, err := middleware.UIDObjectID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: "unauthorized"})
+	}
+	used, quota, err := h.Store.Usage(c.Context(), uid)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	return c.JSON(dto.UploadQuotaResponse{UsedBytes: used, QuotaBytes: quota})
+}
+
+// StreamVideo godoc
+// @Summary      Stream a completed video
+// @Description  Serves the uploaded video with HTTP Range support (206 Partial Content) so players can seek.
+// @Tags         uploads
+// @Produce      octet-stream
+// @Security     BearerAuth
+// @Param        id     path    string  true   "Upload ID"
+// @Param        Range  header  string  false  "bytes=start-end"
+// @Success      200
+// @Success      206
+// @Failure      404  {object}  dto.ErrorResponse
+// @Router       /media/videos/{id} [get]
+func (h *UploadHandler) StreamVideo(c *fiber.Ctx) error {
+	id, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(dto.ErrorResponse{Error: "video not found"})
+	}
+	up, err := h.Store.Completed(c.Context(), id)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	c.Type(strings.TrimPrefix(filepathExt(up.FileName), "."))
+	// SendFile ของ fiber ใช้ fasthttp.FS (AcceptByteRange) จึงตอบ 206 ตาม Range header ให้เอง
+	return c.SendFile(h.Store.FilePath(up), false)
+}
+
+func filepathExt(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
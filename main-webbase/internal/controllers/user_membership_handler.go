@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+)
+
+// GetMyMembershipsHandler godoc
+// @Summary      My memberships
+// @Description  Positions of the current user with localized position labels, org names and terms, plus the org subtree the user can see and the "post as" options (current terms in active org units).
+// @Tags         Users
+// @Produce      json
+// @Param        lang           query  string  false  "th|en (position/org labels)"
+// @Param        include_ended  query  bool    false  "include ended terms"
+// @Success      200  {object}  dto.UserMembershipsResponse
+// @Failure      401  {object}  dto.ErrorResponse "unauthorized"
+// @Failure      500  {object}  dto.ErrorResponse "internal server error"
+// @Router       /users/me/memberships [get]
+func GetMyMembershipsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, err := middleware.UIDFromLocals(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		oid, err := bson.ObjectIDFromHex(uid)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		resp, err := services.ListUserMemberships(c.Context(), services.UserMembershipsQuery{
+			UserID:       oid,
+			Lang:         c.Query("lang"),
+			IncludeEnded: c.QueryBool("include_ended"),
+			Full:         true,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(resp)
+	}
+}
+
+// GetUserMembershipsHandler godoc
+// @Summary      Memberships of a user
+// @Description  Root admins (and the user themself) get the full view including visible orgs and "post as" options. Holders of membership:assign only see the user's memberships inside org units they manage.
+// @Tags         Users
+// @Produce      json
+// @Param        id             path   string  true   "User ID"
+// @Param        lang           query  string  false  "th|en (position/org labels)"
+// @Param        include_ended  query  bool    false  "include ended terms"
+// @Success      200  {object}  dto.UserMembershipsResponse
+// @Failure      400  {object}  dto.ErrorResponse "invalid id"
+// @Failure      403  {object}  dto.ErrorResponse "forbidden"
+// @Failure      500  {object}  dto.ErrorResponse "internal server error"
+// @Router       /users/{id}/memberships [get]
+func GetUserMembershipsHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, err := middleware.UIDFromLocals(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		target, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid id"})
+		}
+
+		q := services.UserMembershipsQuery{
+			UserID:       target,
+			Lang:         c.Query("lang"),
+			IncludeEnded: c.QueryBool("include_ended"),
+			Full:         target.Hex() == uid || isRootByPath(viewerFrom(c)),
+		}
+		if !q.Full {
+			policies, err := services.MyUserPolicy(c.Context(), uid)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+			canAssign := false
+			for _, p := range policies {
+				if p.Enabled && !p.IsDeny() && slices.Contains(p.Actions, "membership:assign") {
+					canAssign = true
+					break
+				}
+			}
+			if !canAssign {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+			}
+			q.ManagedBy = policies
+		}
+
+		resp, err := services.ListUserMemberships(c.Context(), q)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(resp)
+	}
+}
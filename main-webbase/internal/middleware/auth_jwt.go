@@ -9,6 +9,7 @@ import (
 
 type MyClaims struct {
 	UID string `json:"uid,omitempty"`
+	Imp string `json:"imp,omitempty"` // root ที่ออก impersonation token (ID = session id)
 	jwt.RegisteredClaims
 }
 
@@ -46,6 +47,10 @@ func JWTUidOnly(secret string) fiber.Handler {
 		}
 
 		c.Locals("user_id", uid)
+		if claims.Imp != "" {
+			c.Locals("impersonator", claims.Imp)
+			c.Locals("impersonation_id", claims.ID)
+		}
 		return c.Next()
 	}
 }
\ No newline at end of file
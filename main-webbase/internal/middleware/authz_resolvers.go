@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/database"
+	"main-webbase/internal/authz"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+func objectIDParam(c *fiber.Ctx, name string) (bson.ObjectID, error) {
+	id, err := bson.ObjectIDFromHex(c.Params(name))
+	if err != nil {
+		return bson.ObjectID{}, fiber.NewError(fiber.StatusBadRequest, "invalid "+name)
+	}
+	return id, nil
+}
+
+func notFound(what string, err error) error {
+	if err == nil || err == mongo.ErrNoDocuments {
+		return fiber.NewError(fiber.StatusNotFound, what+" not found")
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+}
+
+// bodyField อ่านค่า string จาก JSON body (รองรับ "a.b") หรือ form field ชื่อเดียวกัน
+func bodyField(c *fiber.Ctx, field string) string {
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		var m map[string]any
+		if err := json.Unmarshal(c.Body(), &m); err != nil {
+			return ""
+		}
+		var cur any = m
+		for _, k := range strings.Split(field, ".") {
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return ""
+			}
+			cur = obj[k]
+		}
+		s, _ := cur.(string)
+		return strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(c.FormValue(field))
+}
+
+func eventTarget(c *fiber.Ctx, eventID bson.ObjectID) (authz.Target, error) {
+	event, err := repo.GetEventByID(c.Context(), eventID)
+	if err != nil {
+		return authz.Target{}, notFound("event", err)
+	}
+	node, err := repo.GetOrgByID(c.Context(), event.NodeID)
+	if err != nil {
+		return authz.Target{}, notFound("event org unit", err)
+	}
+	organizers, err := repo.FindOrganizer(c.Context(), eventID)
+	if err != nil {
+		return authz.Target{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return authz.Target{OrgPath: node.OrgPath, Owners: organizers, Attrs: map[string]string{"status": event.Status}}, nil
+}
+
+// OrgFromEvent event (route param) → org ของ event, owners = organizer, attrs = status
+func OrgFromEvent(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		return eventTarget(c, id)
+	}
+}
+
+// OrgFromEventField event id จาก body field
+func OrgFromEventField(field string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := bson.ObjectIDFromHex(bodyField(c, field))
+		if err != nil {
+			return authz.Target{}, fiber.NewError(fiber.StatusBadRequest, "invalid "+field)
+		}
+		return eventTarget(c, id)
+	}
+}
+
+// OrgFromQA คำถาม Q&A → event ของคำถาม
+func OrgFromQA(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		var qa models.EventQA
+		if err := database.DB.Collection("event_qa").FindOne(c.Context(), bson.M{"_id": id}).Decode(&qa); err != nil {
+			return authz.Target{}, notFound("question", err)
+		}
+		return eventTarget(c, qa.EventID)
+	}
+}
+
+// OrgFromPost โพสต์ → postAs.org_path, owner = ผู้โพสต์
+func OrgFromPost(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		post, err := repo.FindPostByID(database.DB.Collection("posts"), id, c.Context())
+		if err != nil {
+			return authz.Target{}, notFound("post", err)
+		}
+		return authz.Target{OrgPath: post.PostAs.OrgPath, Owners: []bson.ObjectID{post.UserID}, Attrs: map[string]string{"status": post.Status}}, nil
+	}
+}
+
+// OrgFromComment คอมเมนต์ → org ของโพสต์, owner = ผู้คอมเมนต์
+func OrgFromComment(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		var cm models.Comment
+		if err := database.DB.Collection("comments").FindOne(c.Context(), bson.M{"_id": id}).Decode(&cm); err != nil {
+			return authz.Target{}, notFound("comment", err)
+		}
+		post, err := repo.FindPostByID(database.DB.Collection("posts"), cm.PostID, c.Context())
+		if err != nil {
+			return authz.Target{}, notFound("post", err)
+		}
+		return authz.Target{OrgPath: post.PostAs.OrgPath, Owners: []bson.ObjectID{cm.UserID}}, nil
+	}
+}
+
+// OrgFromBody org path จาก body field (JSON "a.b" หรือ form)
+func OrgFromBody(field string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		p := bodyField(c, field)
+		if p == "" {
+			return authz.Target{}, fiber.NewError(fiber.StatusBadRequest, field+" is required")
+		}
+		return authz.Target{OrgPath: p}, nil
+	}
+}
+
+// OrgFromQuery org path จาก query string
+func OrgFromQuery(key string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		p := strings.TrimSpace(c.Query(key))
+		if p == "" {
+			return authz.Target{}, fiber.NewError(fiber.StatusBadRequest, key+" is required")
+		}
+		return authz.Target{OrgPath: p}, nil
+	}
+}
+
+// OrgFromNodeIDField org node id (hex) จาก body/form → org path
+func OrgFromNodeIDField(field string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := bson.ObjectIDFromHex(bodyField(c, field))
+		if err != nil {
+			return authz.Target{}, fiber.NewError(fiber.StatusBadRequest, field+" is required")
+		}
+		node, err := repo.GetOrgByID(c.Context(), id)
+		if err != nil {
+			return authz.Target{}, notFound("org unit", err)
+		}
+		return authz.Target{OrgPath: node.OrgPath}, nil
+	}
+}
+
+// OrgFromWildcard /org/units/<org_path>
+func OrgFromWildcard() TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		raw := c.Params("*")
+		if p, err := url.PathUnescape(raw); err == nil {
+			raw = p
+		}
+		return authz.Target{OrgPath: "/" + strings.Trim(raw, "/")}, nil
+	}
+}
+
+// OrgFromMembership membership (route param) → org ของ membership
+func OrgFromMembership(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		m, err := repo.FindMembershipByID(c.Context(), id)
+		if err != nil || m == nil {
+			return authz.Target{}, notFound("membership", err)
+		}
+		return authz.Target{OrgPath: m.OrgPath}, nil
+	}
+}
+
+// OrgFromJoinRequest คำขอเข้าร่วม → org ที่ขอ
+func OrgFromJoinRequest(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		r, err := repo.FindJoinRequestByID(c.Context(), id)
+		if err != nil || r == nil {
+			return authz.Target{}, notFound("join request", err)
+		}
+		return authz.Target{OrgPath: r.OrgPath}, nil
+	}
+}
+
+// OrgFromInvite โค้ดเชิญ (id) → org ของโค้ด
+func OrgFromInvite(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		inv, err := repo.FindOrgInviteByID(c.Context(), id)
+		if err != nil || inv == nil {
+			return authz.Target{}, notFound("invitation", err)
+		}
+		return authz.Target{OrgPath: inv.OrgPath}, nil
+	}
+}
+
+// OrgFromPosition ตำแหน่ง (route param) → scope.org_path ของตำแหน่ง
+func OrgFromPosition(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		p, err := repo.FindPositionByID(c.Context(), id)
+		if err != nil || p == nil {
+			return authz.Target{}, notFound("position", err)
+		}
+		return authz.Target{OrgPath: p.Scope.OrgPath}, nil
+	}
+}
+
+// OrgFromPolicy org_prefix ของ policy — ถ้าถูกลบไปแล้วใช้ snapshot จากประวัติล่าสุด
+func OrgFromPolicy(param string) TargetResolver {
+	return func(c *fiber.Ctx) (authz.Target, error) {
+		id, err := objectIDParam(c, param)
+		if err != nil {
+			return authz.Target{}, err
+		}
+		p, err := repo.FindPolicyByID(c.Context(), id)
+		if err != nil {
+			return authz.Target{}, notFound("policy", err)
+		}
+		if p == nil {
+			v, err := repo.LatestPolicyVersion(c.Context(), id)
+			if err != nil || v == nil || v.Before == nil {
+				return authz.Target{}, notFound("policy", err)
+			}
+			p = v.Before
+		}
+		return authz.Target{OrgPath: p.OrgPrefix}, nil
+	}
+}
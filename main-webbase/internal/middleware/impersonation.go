@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/models"
+	"main-webbase/internal/services"
+)
+
+// Impersonator root ที่กำลังดูในมุมของผู้ใช้ (ok = false ถ้าไม่ใช่ impersonation token)
+func Impersonator(c *fiber.Ctx) (bson.ObjectID, bool) {
+	s, _ := c.Locals("impersonator").(string)
+	id, err := bson.ObjectIDFromHex(s)
+	return id, err == nil
+}
+
+var errImpersonationReadOnly = fiber.NewError(fiber.StatusForbidden, "impersonation tokens are read-only")
+
+// ImpersonationGuard ใช้ต่อจาก JWTUidOnly: request ที่ใช้ impersonation token
+// ทำได้เฉพาะ GET/HEAD/OPTIONS และทุก request ถูกบันทึกลง impersonation_audit พร้อม status
+func ImpersonationGuard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		admin, ok := Impersonator(c)
+		if !ok {
+			return c.Next()
+		}
+
+		var err error
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			err = c.Next()
+		default:
+			err = errImpersonationReadOnly
+		}
+		blocked := errors.Is(err, errImpersonationReadOnly)
+
+		status := c.Response().StatusCode()
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+
+		target, _ := UIDObjectID(c)
+		sid, _ := c.Locals("impersonation_id").(string)
+		session, _ := bson.ObjectIDFromHex(sid)
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if aerr := services.RecordImpersonatedRequest(ctx, &models.ImpersonationAudit{
+			SessionID: session,
+			AdminID:   admin,
+			TargetID:  target,
+			Method:    c.Method(),
+			Path:      c.OriginalURL(),
+			Status:    status,
+			Blocked:   blocked,
+		}); aerr != nil {
+			log.Printf("[impersonation] audit failed session=%s: %v", session.Hex(), aerr)
+		}
+		return err
+	}
+}
+
+// DenyImpersonation ใช้กับ route แบบ GET ที่แก้ข้อมูล (เช่น อ่านแจ้งเตือนแล้ว mark read)
+func DenyImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := Impersonator(c); ok {
+			return errImpersonationReadOnly
+		}
+		return c.Next()
+	}
+}
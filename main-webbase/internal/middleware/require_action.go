@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"main-webbase/internal/accessctx"
+	"main-webbase/internal/authz"
+	"main-webbase/internal/services"
+)
+
+// TargetResolver แปลง route param/body เป็นเป้าหมาย (org path + เจ้าของ) ของ action
+type TargetResolver func(c *fiber.Ctx) (authz.Target, error)
+
+// SubjectFromCtx โหลด policy ของผู้เรียก (cache ไว้ใน Locals ต่อ request)
+func SubjectFromCtx(c *fiber.Ctx) (authz.Subject, error) {
+	if s, ok := c.Locals("authz_subject").(authz.Subject); ok {
+		return s, nil
+	}
+	uid, err := UIDObjectID(c)
+	if err != nil {
+		return authz.Subject{}, err
+	}
+	policies, err := services.MyUserPolicy(c.Context(), uid.Hex())
+	if err != nil {
+		return authz.Subject{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	s := authz.Subject{UserID: uid, Policies: policies}
+	if v, ok := c.Locals("viewer").(*accessctx.ViewerAccess); ok && v != nil {
+		for _, m := range v.Memberships {
+			if m.OrgPath == "/" {
+				s.IsRoot = true
+				break
+			}
+		}
+	}
+	c.Locals("authz_subject", s)
+	return s, nil
+}
+
+// RequireAction ตรวจสิทธิ์ action กับเป้าหมายที่ resolve ได้ก่อนเข้า handler
+// resolve = nil → ไม่ผูก org (self/system action หรือ "มีสิทธิ์ที่ไหนก็ได้")
+// ผลการตัดสินเก็บไว้ใน Locals ให้ handler ใช้ผ่าน AuthzDecision
+func RequireAction(action string, resolve TargetResolver) fiber.Handler {
+	if !authz.IsKnown(action) {
+		panic(fmt.Sprintf("authz: RequireAction with unregistered action %q", action))
+	}
+	return func(c *fiber.Ctx) error {
+		s, err := SubjectFromCtx(c)
+		if err != nil {
+			return err
+		}
+
+		var t authz.Target
+		if resolve != nil {
+			if t, err = resolve(c); err != nil {
+				return err
+			}
+		}
+
+		d := authz.Authorize(s, action, t)
+		c.Locals("authz_decision", d)
+		if !d.Allowed {
+			authz.LogDenied(s, d, c.Method()+" "+c.Route().Path)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden: " + action + " (" + d.Reason + ")"})
+		}
+		return c.Next()
+	}
+}
+
+// AuthzDecision ผลของ RequireAction ใน request นี้
+func AuthzDecision(c *fiber.Ctx) authz.Decision {
+	d, _ := c.Locals("authz_decision").(authz.Decision)
+	return d
+}
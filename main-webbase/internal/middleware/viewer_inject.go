@@ -26,7 +26,7 @@ func InjectViewer(db *mongo.Database) fiber.Handler {
 		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
 		defer cancel()
 
-		v, err := accessctx.BuildViewerAccess(ctx, db, uid)
+		v, err := accessctx.CachedViewerAccess(ctx, db, uid)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
 				return fiber.ErrUnauthorized
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ImpersonationSession token "ดูในมุมของผู้ใช้" ที่ root ออกให้ตัวเอง (collection: impersonation_sessions)
+// token อ่านได้อย่างเดียวและหมดอายุตาม ExpiresAt
+type ImpersonationSession struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	AdminID   bson.ObjectID `bson:"admin_id" json:"admin_id"`
+	TargetID  bson.ObjectID `bson:"target_id" json:"target_id"`
+	Reason    string        `bson:"reason" json:"reason"`
+	IssuedAt  time.Time     `bson:"issued_at" json:"issued_at"`
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`
+}
+
+// ImpersonationAudit หนึ่ง request ที่ใช้ impersonation token (collection: impersonation_audit)
+// Blocked = request ที่ถูกปฏิเสธเพราะเป็น route ที่แก้ข้อมูล
+type ImpersonationAudit struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	SessionID bson.ObjectID `bson:"session_id" json:"session_id"`
+	AdminID   bson.ObjectID `bson:"admin_id" json:"admin_id"`
+	TargetID  bson.ObjectID `bson:"target_id" json:"target_id"`
+	Method    string        `bson:"method" json:"method"`
+	Path      string        `bson:"path" json:"path"`
+	Status    int           `bson:"status" json:"status"`
+	Blocked   bool          `bson:"blocked,omitempty" json:"blocked,omitempty"`
+	At        time.Time     `bson:"at" json:"at"`
+}
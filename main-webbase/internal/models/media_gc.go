@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MediaGCCandidate ไฟล์ใน upload dir ที่ไม่มีใครอ้างถึง นับ grace period จาก FirstSeenAt
+type MediaGCCandidate struct {
+	FileName    string    `bson:"_id" json:"file_name"`
+	Size        int64     `bson:"size" json:"size"`
+	FirstSeenAt time.Time `bson:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt  time.Time `bson:"last_seen_at" json:"last_seen_at"`
+}
+
+type MediaGCFile struct {
+	FileName    string    `bson:"file_name" json:"file_name"`
+	Size        int64     `bson:"size" json:"size"`
+	FirstSeenAt time.Time `bson:"first_seen_at" json:"first_seen_at"`
+}
+
+// MediaGCReport ผลของการรัน GC หนึ่งรอบ (เก็บไว้ใน media_gc_reports)
+type MediaGCReport struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	StartedAt    time.Time     `bson:"started_at" json:"started_at"`
+	FinishedAt   time.Time     `bson:"finished_at" json:"finished_at"`
+	DryRun       bool          `bson:"dry_run" json:"dry_run"`
+	Trigger      string        `bson:"trigger" json:"trigger"` // "schedule" | "manual"
+	GraceSeconds int64         `bson:"grace_seconds" json:"grace_seconds"`
+
+	Scanned    int `bson:"scanned" json:"scanned"`
+	Referenced int `bson:"referenced" json:"referenced"`
+	InProgress int `bson:"in_progress" json:"in_progress"`
+
+	Pending    []MediaGCFile `bson:"pending" json:"pending"`
+	Deleted    []MediaGCFile `bson:"deleted" json:"deleted"`
+	FreedBytes int64         `bson:"freed_bytes" json:"freed_bytes"`
+	Errors     []string      `bson:"errors,omitempty" json:"errors,omitempty"`
+}
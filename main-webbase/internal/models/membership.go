@@ -5,6 +5,14 @@ import (
 	"time"
 )
 
+// EndReason ของ membership ที่จบวาระ
+const (
+	MembershipEndExpired     = "expired"
+	MembershipEndDeactivated = "deactivated"
+	MembershipEndReplaced    = "replaced"
+	MembershipEndHandover    = "handover"
+)
+
 type Membership struct {
 	ID           bson.ObjectID   `bson:"_id,omitempty" json:"id"`
 	UserID       bson.ObjectID   `bson:"user_id" json:"user_id"`
@@ -12,6 +20,11 @@ type Membership struct {
 	PositionKey  string               `bson:"position_key" json:"position_key"`
 	Active       bool                 `bson:"active" json:"active"`
 	OrgAncestors []string             `bson:"org_ancestors" json:"org_ancestors"`
+	ArchiveRef   *bson.ObjectID       `bson:"archive_ref,omitempty" json:"archive_ref,omitempty"` // ถูกปิดเพราะ archive หน่วยงาน
+	JoinedAt     *time.Time           `bson:"joined_at,omitempty" json:"joined_at,omitempty"` // เริ่มวาระ
+	TermEnd      *time.Time           `bson:"term_end,omitempty" json:"term_end,omitempty"`   // วันหมดวาระตามกำหนด (เช่น สิ้นปีการศึกษา)
+	EndedAt      *time.Time           `bson:"ended_at,omitempty" json:"ended_at,omitempty"`   // วันที่จบวาระจริง
+	EndReason    string               `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
 	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time            `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 }
@@ -21,4 +34,7 @@ type MembershipRequestDTO struct {
 	OrgPath      string               `bson:"org_path" json:"org_path"`
 	PositionKey  string               `bson:"position_key" json:"position_key"`
 	Active       bool                 `bson:"active" json:"active"`
+	JoinedAt     *time.Time           `bson:"joined_at,omitempty" json:"joined_at,omitempty"`
+	TermEnd      *time.Time           `bson:"term_end,omitempty" json:"term_end,omitempty"`
+	EndPrevious  bool                 `bson:"-" json:"end_previous,omitempty"` // ปิดวาระผู้ดำรงตำแหน่งเดิม (ตำแหน่ง exclusive_per_org)
 }
\ No newline at end of file
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// HandoverItem หนึ่งรายการในการส่งมอบตำแหน่ง: start | end | continue
+type HandoverItem struct {
+	Action       string         `bson:"action" json:"action"`
+	UserID       bson.ObjectID  `bson:"user_id" json:"user_id"`
+	PositionKey  string         `bson:"position_key" json:"position_key"`
+	MembershipID *bson.ObjectID `bson:"membership_id,omitempty" json:"membership_id,omitempty"`
+}
+
+// MembershipHandover audit trail ของการส่งมอบตำแหน่งทั้งชุด (collection: membership_handovers)
+type MembershipHandover struct {
+	ID          bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	OrgPath     string         `bson:"org_path" json:"org_path"`
+	PerformedBy bson.ObjectID  `bson:"performed_by" json:"performed_by"`
+	PerformedAt time.Time      `bson:"performed_at" json:"performed_at"`
+	JoinedAt    time.Time      `bson:"joined_at" json:"joined_at"`
+	TermEnd     *time.Time     `bson:"term_end,omitempty" json:"term_end,omitempty"`
+	Note        string         `bson:"note,omitempty" json:"note,omitempty"`
+	Items       []HandoverItem `bson:"items" json:"items"`
+}
@@ -11,6 +11,14 @@ type PostAs struct {
 	PositionKey string `bson:"position_key" json:"position_key"`
 }
 
+// Post status
+const (
+	PostStatusActive    = "active"
+	PostStatusDraft     = "draft"     // เห็นเฉพาะผู้เขียนและผู้ที่แก้โพสต์ของ org ได้ (post:update)
+	PostStatusScheduled = "scheduled" // publisher เปลี่ยนเป็น active เมื่อถึง publish_at
+	PostStatusInactive  = "inactive"  // ลบแล้ว (soft delete)
+)
+
 type Post struct {
 	ID     bson.ObjectID `json:"id" bson:"_id,omitempty"`
 	UserID bson.ObjectID `json:"userId" bson:"user_id"`
@@ -29,8 +37,12 @@ type Post struct {
 	CommentCount int           `json:"CommentCount" bson:"comment_count"`
 	CreatedAt    time.Time     `json:"createdAt" bson:"created_at"`
 	UpdatedAt    time.Time     `json:"updatedAt" bson:"updated_at"`
-	Status       string        `json:"status" bson:"status"` // active, deleted
+	EditedAt     *time.Time    `json:"editedAt,omitempty" bson:"edited_at,omitempty"` // แก้ไขล่าสุด (ดูประวัติที่ post_revisions)
+	Status       string        `json:"status" bson:"status"` // active, draft, scheduled, inactive
+	PublishAt    *time.Time    `json:"publishAt,omitempty" bson:"publish_at,omitempty"` // เวลาที่ publisher จะเผยแพร่ (status = scheduled)
 	Visibility   string        `json:"visibility" bson:"visibility"`
+	// Targeting กลุ่มผู้เห็นแบบเดียวกับ event (access = org) — nil = public หรือ private แบบเดิม (post_role_visibility)
+	Targeting    *Visibility   `json:"targeting,omitempty" bson:"targeting,omitempty"`
 	Isliked      bool          `json:"is_liked" bson:"is_liked"`
 }
 
@@ -47,4 +59,5 @@ type QueryOptions struct {
 
 	ViewerID       bson.ObjectID
 	AllowedNodeIDs []bson.ObjectID
+	ViewerRoles    []ViewerRole // ใช้กับ Post.Targeting
 }
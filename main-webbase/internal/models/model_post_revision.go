@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PostRevision เนื้อหาของโพสต์ ณ revision หนึ่ง (collection: post_revisions)
+// revision 1 = ต้นฉบับ (บันทึกตอนแก้ครั้งแรก, EditedBy = ผู้เขียน, EditedAt = created_at)
+// revision ถัดไป = ผลหลังการแก้แต่ละครั้ง พร้อมผู้แก้และเวลา
+type PostRevision struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostID      bson.ObjectID `bson:"post_id" json:"postId"`
+	Revision    int           `bson:"revision" json:"revision"`
+	PostText    string        `bson:"post_text" json:"postText"`
+	Media       []string      `bson:"media,omitempty" json:"media,omitempty"`
+	CategoryIDs []string      `bson:"category_ids" json:"categoryIds"`
+	Access      string        `bson:"access" json:"access"` // public | private | org
+	Audience    []string      `bson:"audience,omitempty" json:"audience,omitempty"`
+	Targeting   *Visibility   `bson:"targeting,omitempty" json:"targeting,omitempty"` // access = org
+	PostAs      PostAs        `bson:"post_as" json:"postAs"`
+	Status      string        `bson:"status" json:"status"`
+	EditedBy    bson.ObjectID `bson:"edited_by" json:"editedBy"`
+	EditedAt    time.Time     `bson:"edited_at" json:"editedAt"`
+}
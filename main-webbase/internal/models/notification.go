@@ -28,5 +28,7 @@ type NotiParams struct {
 	EventTitle string // ใช้กับหลายเคส
 	EventID	bson.ObjectID
 	StartTime *time.Time // ใช้กับ event reminder
+	OrgName     string // ใช้กับ join request / invite
+	PositionKey string
 	// เติม field อื่นได้ถ้าต้องใช้ในอนาคต
 }
\ No newline at end of file
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	JoinRequestPending   = "pending"
+	JoinRequestApproved  = "approved"
+	JoinRequestRejected  = "rejected"
+	JoinRequestCancelled = "cancelled"
+)
+
+// JoinRequest คำขอเข้าร่วมหน่วยงาน (collection: org_join_requests)
+type JoinRequest struct {
+	ID           bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID       bson.ObjectID  `bson:"user_id" json:"user_id"`
+	OrgPath      string         `bson:"org_path" json:"org_path"`
+	PositionKey  string         `bson:"position_key" json:"position_key"`
+	Message      string         `bson:"message,omitempty" json:"message,omitempty"`
+	Status       string         `bson:"status" json:"status"`
+	DecidedBy    *bson.ObjectID `bson:"decided_by,omitempty" json:"decided_by,omitempty"`
+	DecidedAt    *time.Time     `bson:"decided_at,omitempty" json:"decided_at,omitempty"`
+	DecisionNote string         `bson:"decision_note,omitempty" json:"decision_note,omitempty"`
+	MembershipID *bson.ObjectID `bson:"membership_id,omitempty" json:"membership_id,omitempty"`
+	CreatedAt    time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// OrgInvite โค้ดเชิญเข้าตำแหน่ง (collection: org_invites)
+// MaxUses = 0 คือไม่จำกัด
+type OrgInvite struct {
+	ID          bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Code        string          `bson:"code" json:"code"`
+	OrgPath     string          `bson:"org_path" json:"org_path"`
+	PositionKey string          `bson:"position_key" json:"position_key"`
+	CreatedBy   bson.ObjectID   `bson:"created_by" json:"created_by"`
+	ExpiresAt   time.Time       `bson:"expires_at" json:"expires_at"`
+	MaxUses     int             `bson:"max_uses" json:"max_uses"`
+	Uses        int             `bson:"uses" json:"uses"`
+	RedeemedBy  []bson.ObjectID `bson:"redeemed_by" json:"redeemed_by"`
+	TermEnd     *time.Time      `bson:"term_end,omitempty" json:"term_end,omitempty"`
+	Revoked     bool            `bson:"revoked" json:"revoked"`
+	CreatedAt   time.Time       `bson:"created_at" json:"created_at"`
+}
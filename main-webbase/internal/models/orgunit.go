@@ -16,6 +16,49 @@ type OrgUnitNode struct {
 	Slug      	 string             `bson:"slug,omitempty" json:"slug,omitempty"`
 	Type       	 string            	`bson:"type"        json:"type"`
 	Status     	 string            	`bson:"status"      json:"status"`
+
+	// Profile (แก้ได้โดยผู้ดูแลหน่วยงาน)
+	Names        map[string]string  `bson:"names,omitempty"       json:"names,omitempty"` // {"th": "...", "en": "..."} แบบเดียวกับ Position.Display
+	Description  string             `bson:"description,omitempty" json:"description,omitempty"`
+	LogoURL      string             `bson:"logo_url,omitempty"    json:"logo_url,omitempty"`
+	Contact      *OrgContact        `bson:"contact,omitempty"     json:"contact,omitempty"`
+	Social       map[string]string  `bson:"social,omitempty"      json:"social,omitempty"` // {"facebook": "https://...", "instagram": "..."}
+
 	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
-}
\ No newline at end of file
+}
+
+type OrgContact struct {
+	Email   string `bson:"email,omitempty"   json:"email,omitempty"`
+	Phone   string `bson:"phone,omitempty"   json:"phone,omitempty"`
+	Website string `bson:"website,omitempty" json:"website,omitempty"`
+	Address string `bson:"address,omitempty" json:"address,omitempty"`
+}
+
+// OrgPathRedirect จำ path เดิมของหน่วยงานที่ถูกย้าย/เปลี่ยนชื่อ (ครอบคลุมทั้ง subtree)
+type OrgPathRedirect struct {
+	From      string        `bson:"_id" json:"from"`
+	To        string        `bson:"to" json:"to"`
+	NodeID    bson.ObjectID `bson:"node_id" json:"node_id"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+const (
+	OrgStatusActive   = "active"
+	OrgStatusArchived = "archived"
+)
+
+// OrgArchive บันทึกการ archive subtree หนึ่งครั้ง เอกสารที่ถูกปิดจะมี archive_ref = ID นี้
+// เพื่อให้ restore คืนเฉพาะสิ่งที่ archive นี้ปิดไป (ไม่ไปเปิดของที่ถูกปิดด้วยเหตุผลอื่น)
+type OrgArchive struct {
+	ID         bson.ObjectID    `bson:"_id,omitempty" json:"id"`
+	OrgPath    string           `bson:"org_path" json:"org_path"`
+	NodeID     bson.ObjectID    `bson:"node_id" json:"node_id"`
+	ArchivedBy bson.ObjectID    `bson:"archived_by" json:"archived_by"`
+	ArchivedAt time.Time        `bson:"archived_at" json:"archived_at"`
+	Reason     string           `bson:"reason,omitempty" json:"reason,omitempty"`
+	Counts     map[string]int64 `bson:"counts" json:"counts"`
+	RestoredBy *bson.ObjectID   `bson:"restored_by,omitempty" json:"restored_by,omitempty"`
+	RestoredAt *time.Time       `bson:"restored_at,omitempty" json:"restored_at,omitempty"`
+}
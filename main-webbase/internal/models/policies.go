@@ -12,8 +12,25 @@ type Policy struct {
 	OrgPrefix 	string 				`bson:"org_prefix" json:"org_prefix"`
 	Actions     []string           	`bson:"actions" json:"actions"`
 	Enabled     bool               	`bson:"enabled" json:"enabled"`
+	Effect      string              `bson:"effect,omitempty" json:"effect,omitempty"`         // "allow" (ค่าว่าง) หรือ "deny" — deny ชนะ allow เสมอ
+	Conditions  map[string]string   `bson:"conditions,omitempty" json:"conditions,omitempty"` // เช่น {"status": "draft"} ต้องตรงกับ attribute ของเป้าหมายทุกข้อ
+	ArchiveRef  *bson.ObjectID      `bson:"archive_ref,omitempty" json:"archive_ref,omitempty"` // ถูกปิดเพราะ archive หน่วยงาน
 	CreatedAt   time.Time          	`bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+
+	// InheritedFrom org_prefix เดิมของ policy ที่ถูกสืบทอดลงมาจาก unit แม่
+	// (MyUserPolicy ย้าย OrgPrefix มาไว้ที่ org ของ membership ผู้ถือตำแหน่ง)
+	InheritedFrom string `bson:"-" json:"inherited_from,omitempty"`
+}
+
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// IsDeny policy นี้เป็นกฎห้าม
+func (p *Policy) IsDeny() bool {
+	return p.Effect == PolicyEffectDeny
 }
 
 // Actions list
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PolicyVersion op
+const (
+	PolicyOpCreate   = "create"
+	PolicyOpUpdate   = "update"
+	PolicyOpDelete   = "delete"
+	PolicyOpRollback = "rollback"
+	// เขียนโดยการจัดการหน่วยงาน (org_prefix เปลี่ยน / ถูกปิด-เปิดตาม archive)
+	PolicyOpMove    = "move"
+	PolicyOpArchive = "archive"
+	PolicyOpRestore = "restore"
+)
+
+// PolicyVersion หนึ่งการเปลี่ยนแปลงของ policy (collection: policy_versions)
+// Before = nil สำหรับ create, After = nil สำหรับ delete
+type PolicyVersion struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	PolicyID     bson.ObjectID `bson:"policy_id" json:"policy_id"`
+	Version      int           `bson:"version" json:"version"`
+	Op           string        `bson:"op" json:"op"`
+	Actor        bson.ObjectID `bson:"actor" json:"actor"`
+	At           time.Time     `bson:"at" json:"at"`
+	Before       *Policy       `bson:"before,omitempty" json:"before,omitempty"`
+	After        *Policy       `bson:"after,omitempty" json:"after,omitempty"`
+	Diff         []string      `bson:"diff" json:"diff"`
+	RolledBackTo int           `bson:"rolled_back_to,omitempty" json:"rolled_back_to,omitempty"`
+}
@@ -5,6 +5,11 @@ import (
 	"time"
 )
 
+const (
+    PositionStatusActive     = "active"
+    PositionStatusDeprecated = "deprecated" // คนเดิมยังถืออยู่ได้ แต่รับสมาชิกใหม่ไม่ได้
+)
+
 type Constraints struct {
     ExclusivePerOrg bool `bson:"exclusive_per_org" json:"exclusive_per_org"`
 }
@@ -1,19 +1,33 @@
 package models
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"time"
 
-// Permission defines a single action key
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Permission defines a single action key (collection: permissions)
+// seeded from the authz registry on start; labels are shown in English and Thai
 type Permission struct {
-	ID       bson.ObjectID `bson:"_id,omitempty" json:"id"`
-	Key      string        `bson:"key" json:"key"`
-	Label    string        `bson:"label,omitempty" json:"label,omitempty"`
-	Category string        `bson:"category,omitempty" json:"category,omitempty"`
+	ID              bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key             string        `bson:"key" json:"key"`
+	Label           string        `bson:"label,omitempty" json:"label,omitempty"`
+	LabelTH         string        `bson:"label_th,omitempty" json:"label_th,omitempty"`
+	Category        string        `bson:"category,omitempty" json:"category,omitempty"`
+	CategoryLabel   string        `bson:"category_label,omitempty" json:"-"`
+	CategoryLabelTH string        `bson:"category_label_th,omitempty" json:"-"`
+	Kind            string        `bson:"kind,omitempty" json:"kind,omitempty"` // org | self | system
 }
 
-// Role is a collection of permissions
+// Role is a collection of permissions — used as a named template (collection: role_templates)
+// that can be applied to a new position's policy
 type Role struct {
 	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name        string        `bson:"name" json:"name"`
 	Label       string        `bson:"label" json:"label"`
+	LabelTH     string        `bson:"label_th,omitempty" json:"label_th,omitempty"`
 	Permissions []string      `bson:"permissions" json:"permissions"`
+	Builtin     bool          `bson:"builtin,omitempty" json:"builtin,omitempty"` // seeded on start, cannot be deleted
+	CreatedAt   time.Time     `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	UpdatedAt   time.Time     `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Upload เก็บสถานะของการอัปโหลดแบบ resumable (tus-style)
+// ไฟล์ระหว่างอัปโหลดอยู่ที่ <upload_dir>/<id>.part แล้วถูก rename เป็น FileName เมื่อครบ
+type Upload struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
+	FileName    string        `bson:"file_name" json:"file_name"`         // ชื่อไฟล์ปลายทางใน upload dir
+	Original    string        `bson:"original_name" json:"original_name"` // ชื่อไฟล์จาก client (Upload-Metadata)
+	MimeType    string        `bson:"mime_type" json:"mime_type"`
+	Length      int64         `bson:"length" json:"length"` // Upload-Length
+	Offset      int64         `bson:"offset" json:"offset"` // Upload-Offset ที่ commit แล้ว
+	Status      string        `bson:"status" json:"status"` // uploading | completed | aborted
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+	ExpiresAt   *time.Time    `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // เฉพาะตอนยังไม่เสร็จ
+	CompletedAt *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+	UploadStatusAborted   = "aborted"
+)
@@ -19,6 +19,12 @@ type Visibility struct {
 	DenyUserIDs      []string       `bson:"deny_user_ids,omitempty"     json:"deny_user_ids,omitempty"`
 }
 
+// ViewerRole ตำแหน่งหนึ่งของผู้ดู (membership ปัจจุบัน) ใช้จับคู่กับ Visibility ของโพสต์ใน feed
+type ViewerRole struct {
+	OrgPath     string
+	PositionKey string
+}
+
 type PostedAs struct {
 	OrgPath     string `bson:"org_path,omitempty"     json:"org_path,omitempty"`
 	PositionKey string `bson:"position_key,omitempty" json:"position_key,omitempty"`
@@ -32,7 +38,9 @@ type MembershipDoc struct {
 	PositionKey string        `bson:"position_key"     json:"position_key"`
 	Active      bool          `bson:"active"           json:"active"` // boolean flag
 	JoinedAt    *time.Time    `bson:"joined_at,omitempty" json:"joined_at,omitempty"`
+	TermEnd     *time.Time    `bson:"term_end,omitempty"  json:"term_end,omitempty"`
 	EndedAt     *time.Time    `bson:"ended_at,omitempty"  json:"ended_at,omitempty"`
+	EndReason   string        `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
 	CreatedAt   time.Time     `bson:"created_at"       json:"created_at"`
 	UpdatedAt   time.Time     `bson:"updated_at"       json:"updated_at"`
 }
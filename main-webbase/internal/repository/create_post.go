@@ -28,7 +28,8 @@ func ResolvePositionIDByKey(db *mongo.Database, positionKey string, ctx context.
 	var doc struct {
 		ID bson.ObjectID `bson:"_id"`
 	}
-	err := db.Collection("positions").FindOne(ctx, bson.M{"key": positionKey, "status": "active"}).Decode(&doc)
+	// deprecated ยังใช้ได้ (คนที่ถืออยู่เดิมยังโพสต์ในนามตำแหน่งได้)
+	err := db.Collection("positions").FindOne(ctx, bson.M{"key": positionKey, "status": bson.M{"$in": bson.A{models.PositionStatusActive, models.PositionStatusDeprecated}}}).Decode(&doc)
 	return doc.ID, err
 }
 
@@ -172,7 +173,7 @@ func FindOrgNode(col *mongo.Collection, id bson.ObjectID, ctx context.Context) (
 	return doc.OrgPath, err
 }
 
-// ดึง visibility ของโพสต์จาก post_role_visibility -> แปลง role_id เป็น org_units.path
+// ดึง visibility ของโพสต์จาก post_role_visibility -> แปลง node_id เป็น org_units.path
 func FindVisibilityPaths(
 	colPRV *mongo.Collection, // post_role_visibility
 	colOrg *mongo.Collection, // org_units
@@ -180,10 +181,10 @@ func FindVisibilityPaths(
 	ctx context.Context,
 ) (dto.Visibility, error) {
 
-	// 1) หา role_id ทั้งหมดที่ผูกกับ post_id
+	// 1) หา node_id ทั้งหมดที่ผูกกับ post_id (ReplaceRoleVisibility เขียนไว้ใน node_id; แถว node_id=null = public)
 	cur, err := colPRV.Find(ctx,
 		bson.M{"post_id": postID},
-		options.Find().SetProjection(bson.M{"role_id": 1}))
+		options.Find().SetProjection(bson.M{"node_id": 1}))
 	if err != nil {
 		return dto.Visibility{}, err
 	}
@@ -192,12 +193,14 @@ func FindVisibilityPaths(
 	roleIDs := make([]bson.ObjectID, 0, 8)
 	for cur.Next(ctx) {
 		var row struct {
-			RoleID bson.ObjectID `bson:"role_id"`
+			NodeID *bson.ObjectID `bson:"node_id"`
 		}
 		if err := cur.Decode(&row); err != nil {
 			return dto.Visibility{}, err
 		}
-		roleIDs = append(roleIDs, row.RoleID)
+		if row.NodeID != nil {
+			roleIDs = append(roleIDs, *row.NodeID)
+		}
 	}
 	if err := cur.Err(); err != nil {
 		return dto.Visibility{}, err
@@ -209,7 +212,6 @@ func FindVisibilityPaths(
 	}
 
 	// 2) มี record → access=private แล้ว resolve path จาก org_units
-	//    (สมมติ role_id == _id ของ org_units; ถ้า schema จริงต่างออกไป ให้ปรับ join ตรงนี้)
 	cur2, err := colOrg.Find(ctx,
 		bson.M{"_id": bson.M{"$in": roleIDs}, "status": "active"},
 		options.Find().SetProjection(bson.M{"org_path": 1}))
@@ -269,7 +271,8 @@ func DeletePost(db *mongo.Database, postID bson.ObjectID, ctx context.Context, u
 	col := db.Collection("posts")
 
 	// filter หาจาก _id
-	filter := bson.M{"_id": postID, "status": "active"}
+	// ร่าง/โพสต์ตั้งเวลา ยกเลิก (ลบ) ได้เหมือนโพสต์ปกติ
+	filter := bson.M{"_id": postID, "status": bson.M{"$in": bson.A{models.PostStatusActive, models.PostStatusDraft, models.PostStatusScheduled}}}
 
 	if !isRoot {
 		filter["user_id"] = userID
@@ -306,12 +309,14 @@ func UpdatePostCore(
 
 	col := db.Collection("posts")
 
-	filter := bson.M{"_id": postID, "status": "active"}
+	// ร่าง/โพสต์ตั้งเวลา แก้ได้จนกว่าจะเผยแพร่
+	filter := bson.M{"_id": postID, "status": bson.M{"$in": bson.A{models.PostStatusActive, models.PostStatusDraft, models.PostStatusScheduled}}}
 	if !isRoot {
 		// เจ้าของเท่านั้นถ้าไม่ใช่แอดมิน
 		filter["user_id"] = userID
 	}
 	newHashtags := utils.ExtractHashtags(in.PostText)
+	now := time.Now().UTC()
 	// fmt.Printf("[UpdatePost] post=%s user=%s admin=%v\n", postID.Hex(), userID.Hex(), isRoot)
 	// fmt.Printf("[UpdatePost] filter=%v\n", filter)
 	set := bson.M{
@@ -323,7 +328,8 @@ func UpdatePostCore(
 		"position_id":   positionID, // map จาก position_key
 		"tags":          in.PostAs.Tag,
 		"hashtag":       newHashtags,
-		"updated_at":    time.Now().UTC(),
+		"updated_at":    now,
+		"edited_at":     now,
 	}
 
 	// อนุญาตให้ admin เปลี่ยน status ได้เท่านั้น
@@ -46,6 +46,8 @@ func buildCommonPipeline(baseMatch bson.D, lim int64, opts models.QueryOptions,
 		bson.D{{Key: "$match", Value: bson.D{
 			{Key: "status", Value: "active"},
 		}}},
+		// ===== Targeting (access = org) =====
+		bson.D{{Key: "$match", Value: PostAudienceMatch(opts.ViewerID, opts.ViewerRoles)}},
 		// ===== Users =====
 		{{Key: "$lookup", Value: bson.M{
 			"from":         "users",
@@ -195,9 +197,7 @@ func buildCommonPipeline(baseMatch bson.D, lim int64, opts models.QueryOptions,
 			}},
 		}}},
 		bson.D{{Key: "$addFields", Value: bson.M{
-			"visibilityAccess": bson.M{
-				"$cond": bson.A{"$hasVisibility", "private", "public"},
-			},
+			"visibilityAccess": postVisibilityLabel("$hasVisibility"),
 		}}},
 		bson.D{{Key: "$addFields", Value: bson.M{
 			"isOwner": bson.M{"$cond": bson.A{
@@ -273,14 +273,9 @@ func buildCommonPipeline(baseMatch bson.D, lim int64, opts models.QueryOptions,
 			"comment_count": 1,
 			"created_at": 1,
 			"updated_at": 1,
+			"edited_at": 1,
 			"status": bson.M{"$ifNull": bson.A{"$status", "active"}},
-			"visibility": bson.M{
-				"$cond": bson.A{
-					"$hasVisibility",  // ถ้ามีบันทึก visibility แปลว่า "private"
-					"private",
-					"public",
-				},
-			},
+			"visibility": postVisibilityLabel("$hasVisibility"), // targeting → org, มีบันทึก visibility → private
 			"is_liked": bson.M{"$ifNull": bson.A{"$is_liked", false}},
 		}}},
 	)
@@ -10,6 +10,8 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 	mongo "go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/internal/models"
 )
 
 // ------- cursor helpers (no primitive) -------
@@ -53,6 +55,8 @@ func ListAllPostsVisibleToViewer(
 	cursorStr string,
 	limit int64,
 	allowedRoleIDs []bson.ObjectID, // สิทธิ์ของผู้ดู (node/role IDs ที่เข้าถึงได้)
+	viewerID bson.ObjectID,
+	viewerRoles []models.ViewerRole, // membership ของผู้ดู ใช้กับ targeting
 ) (items []bson.M, next *string, err error) {
 
 	db := client.Database("unicom")
@@ -79,6 +83,8 @@ func ListAllPostsVisibleToViewer(
     bson.D{{Key: "$match", Value: bson.D{
         {Key: "status", Value: "active"},
     }}},
+		// targeting (access = org) ตัดสินจาก field ในโพสต์เองได้เลย ไม่ต้อง lookup
+		bson.D{{Key: "$match", Value: PostAudienceMatch(viewerID, viewerRoles)}},
 	}
 	if len(cursorMatch) > 0 {
 		pipe = append(pipe, bson.D{{Key: "$match", Value: cursorMatch}})
@@ -117,12 +123,9 @@ func ListAllPostsVisibleToViewer(
 	// คำนวณ field เสริม
 	pipe = append(pipe,
 		bson.D{{Key: "$addFields", Value: bson.D{
-			{Key: "visibility", Value: bson.D{
-				{Key: "$cond", Value: bson.A{
-					bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: "$prv"}}, 0}}},
-					"private", "public",
-				}},
-			}},
+			{Key: "visibility", Value: postVisibilityLabel(
+				bson.D{{Key: "$gt", Value: bson.A{bson.D{{Key: "$size", Value: "$prv"}}, 0}}},
+			)},
 			{Key: "matched_node_ids", Value: bson.D{
 				{Key: "$setIntersection", Value: bson.A{"$prv.node_id", allowedRoleIDs}},
 			}},
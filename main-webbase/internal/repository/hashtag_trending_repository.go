@@ -327,8 +327,8 @@ func (r *mongoHashtagTrendingRepo) ListPublicPostsByHashtag(
 	itemsPipe = append(itemsPipe,
 		// ใช้เอกสาร p (post) เป็นราก
 		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$p"}}},
-		// สถานะ
-		bson.D{{Key: "$match", Value: bson.M{"status": "active"}}},
+		// สถานะ (โพสต์ที่กำหนดกลุ่มผู้เห็น (targeting) ไม่ใช่โพสต์สาธารณะ)
+		bson.D{{Key: "$match", Value: bson.M{"status": "active", "targeting": nil}}},
 		// ===== Users =====
 		bson.D{{Key: "$lookup", Value: bson.M{
 			"from":         "users",
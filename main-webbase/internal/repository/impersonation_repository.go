@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+func InsertImpersonationSession(ctx context.Context, s *models.ImpersonationSession) error {
+	_, err := database.DB.Collection("impersonation_sessions").InsertOne(ctx, s)
+	return err
+}
+
+func InsertImpersonationAudit(ctx context.Context, a *models.ImpersonationAudit) error {
+	_, err := database.DB.Collection("impersonation_audit").InsertOne(ctx, a)
+	return err
+}
+
+// ListImpersonationSessions ใหม่สุดก่อน — filter ตาม target_id/admin_id (ว่าง = ทั้งหมด)
+func ListImpersonationSessions(ctx context.Context, filter bson.M, limit int64) ([]models.ImpersonationSession, error) {
+	cur, err := database.DB.Collection("impersonation_sessions").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "issued_at", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.ImpersonationSession{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListImpersonationAudit request ของ session ที่ระบุ เรียงตามเวลา
+func ListImpersonationAudit(ctx context.Context, sessionIDs []bson.ObjectID) ([]models.ImpersonationAudit, error) {
+	if len(sessionIDs) == 0 {
+		return []models.ImpersonationAudit{}, nil
+	}
+	cur, err := database.DB.Collection("impersonation_audit").Find(ctx,
+		bson.M{"session_id": bson.M{"$in": sessionIDs}},
+		options.Find().SetSort(bson.D{{Key: "at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.ImpersonationAudit{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+// mediaRefFields ที่เก็บ URL ของไฟล์ใน /uploads หรือ stream URL /media/videos/<upload id> (collection -> field)
+// Filter = เฉพาะเอกสารที่ยังนับเป็นการอ้างถึง: โพสต์ที่ถูกลบ (soft delete, status inactive) ยังเก็บ media ไว้
+// แต่ไม่กันไฟล์จาก GC
+//
+// post_revisions.media ตั้งใจไม่นับ: ไฟล์ที่ถูกแก้ออกจากโพสต์ (หรือของโพสต์ที่ถูกลบ) ถูก GC ได้
+// ประวัติการแก้จะยังเก็บ URL เดิมไว้แต่เปิดไฟล์ไม่ได้แล้ว
+var mediaRefFields = []struct {
+	Collection string
+	Field      string
+	Filter     bson.M
+}{
+	{"posts", "media", bson.M{"status": bson.M{"$ne": models.PostStatusInactive}}},
+	{"events", "picture_url", nil},
+	{"users", "profile_pic", nil},
+	{"org_units", "logo_url", nil},
+	{"comments", "text", nil}, // comment ไม่มี field media แต่อาจแปะลิงก์ไฟล์ไว้ในข้อความ
+}
+
+var (
+	uploadsURLRe = regexp.MustCompile(`/uploads/([^/\s"'?#]+)`)
+	streamURLRe  = regexp.MustCompile(`/media/videos/([0-9a-fA-F]{24})`)
+)
+
+// mediaRefPattern regex ที่ตรงกับ URL ทั้งสองแบบ (ใช้กรองเอกสารก่อน decode)
+const mediaRefPattern = `/uploads/|/media/videos/`
+
+func collectRefs(v any, files map[string]struct{}, uploadIDs map[bson.ObjectID]struct{}) {
+	switch x := v.(type) {
+	case string:
+		for _, m := range uploadsURLRe.FindAllStringSubmatch(x, -1) {
+			files[path.Base(m[1])] = struct{}{}
+		}
+		for _, m := range streamURLRe.FindAllStringSubmatch(x, -1) {
+			if id, err := bson.ObjectIDFromHex(m[1]); err == nil {
+				uploadIDs[id] = struct{}{}
+			}
+		}
+	case bson.A:
+		for _, e := range x {
+			collectRefs(e, files, uploadIDs)
+		}
+	case []any:
+		for _, e := range x {
+			collectRefs(e, files, uploadIDs)
+		}
+	}
+}
+
+func mediaRefFilter(field string, filter bson.M, pattern string) bson.M {
+	f := bson.M{field: bson.M{"$regex": pattern}}
+	for k, v := range filter {
+		f[k] = v
+	}
+	return f
+}
+
+// ListReferencedMediaFiles รวมชื่อไฟล์ทุกไฟล์ที่ post/event/comment/profile ยังอ้างถึงอยู่
+// stream URL ของวิดีโอ (/media/videos/<id>) ถูก resolve เป็น file_name ของ upload นั้น
+func ListReferencedMediaFiles(ctx context.Context) (map[string]struct{}, error) {
+	refs := map[string]struct{}{}
+	uploadIDs := map[bson.ObjectID]struct{}{}
+	for _, rf := range mediaRefFields {
+		cur, err := database.DB.Collection(rf.Collection).Find(ctx,
+			mediaRefFilter(rf.Field, rf.Filter, mediaRefPattern),
+			options.Find().SetProjection(bson.M{rf.Field: 1}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for cur.Next(ctx) {
+			var doc bson.M
+			if err := cur.Decode(&doc); err != nil {
+				cur.Close(ctx)
+				return nil, err
+			}
+			collectRefs(doc[rf.Field], refs, uploadIDs)
+		}
+		err = cur.Err()
+		cur.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(uploadIDs) > 0 {
+		ids := make([]bson.ObjectID, 0, len(uploadIDs))
+		for id := range uploadIDs {
+			ids = append(ids, id)
+		}
+		names, err := database.DB.Collection("uploads").Distinct(ctx, "file_name", bson.M{"_id": bson.M{"$in": ids}}).Raw()
+		if err != nil {
+			return nil, err
+		}
+		vals, err := names.Values()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			if name, ok := v.StringValueOK(); ok && name != "" {
+				refs[name] = struct{}{}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// IsMediaReferenced เช็คซ้ำทีละไฟล์ก่อนลบจริง (กันเคสที่เพิ่งถูกแนบหลังจาก scan)
+// นับทั้ง /uploads/<ชื่อไฟล์> และ stream URL ของ upload ที่เขียนไฟล์นี้
+func IsMediaReferenced(ctx context.Context, fileName string) (bool, error) {
+	alts := []string{"/uploads/" + regexp.QuoteMeta(fileName)}
+
+	cur, err := database.DB.Collection("uploads").Find(ctx,
+		bson.M{"file_name": fileName},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return false, err
+	}
+	var ups []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &ups); err != nil {
+		return false, err
+	}
+	for _, u := range ups {
+		alts = append(alts, "/media/videos/"+u.ID.Hex())
+	}
+	pattern := strings.Join(alts, "|")
+
+	for _, rf := range mediaRefFields {
+		n, err := database.DB.Collection(rf.Collection).CountDocuments(ctx,
+			mediaRefFilter(rf.Field, rf.Filter, pattern),
+			options.Count().SetLimit(1),
+		)
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListActiveUploadParts คืนชื่อไฟล์ .part ของ upload ที่ยังไม่หมดอายุ (ห้ามลบ)
+func ListActiveUploadParts(ctx context.Context, now time.Time) (map[string]struct{}, error) {
+	cur, err := database.DB.Collection("uploads").Find(ctx,
+		bson.M{"status": models.UploadStatusUploading, "expires_at": bson.M{"$gt": now}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	parts := map[string]struct{}{}
+	for cur.Next(ctx) {
+		var row struct {
+			ID bson.ObjectID `bson:"_id"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		parts[row.ID.Hex()+".part"] = struct{}{}
+	}
+	return parts, cur.Err()
+}
+
+// AbortExpiredUploads ปิด upload ที่ค้างเกินเวลา ไฟล์ .part จะกลายเป็น orphan ให้ GC เก็บ
+func AbortExpiredUploads(ctx context.Context, now time.Time) (int64, error) {
+	res, err := database.DB.Collection("uploads").UpdateMany(ctx,
+		bson.M{"status": models.UploadStatusUploading, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": models.UploadStatusAborted, "updated_at": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// MarkUploadFileDeleted ปล่อย quota ของ upload ที่ไฟล์ถูก GC ลบไปแล้ว
+func MarkUploadFileDeleted(ctx context.Context, fileName string, now time.Time) error {
+	_, err := database.DB.Collection("uploads").UpdateMany(ctx,
+		bson.M{"file_name": fileName, "status": models.UploadStatusCompleted},
+		bson.M{"$set": bson.M{"status": models.UploadStatusAborted, "updated_at": now}},
+	)
+	return err
+}
+
+func ListMediaGCCandidates(ctx context.Context) (map[string]models.MediaGCCandidate, error) {
+	cur, err := database.DB.Collection("media_gc_candidates").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var list []models.MediaGCCandidate
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	out := make(map[string]models.MediaGCCandidate, len(list))
+	for _, c := range list {
+		out[c.FileName] = c
+	}
+	return out, nil
+}
+
+func UpsertMediaGCCandidate(ctx context.Context, fileName string, size int64, now time.Time) error {
+	_, err := database.DB.Collection("media_gc_candidates").UpdateOne(ctx,
+		bson.M{"_id": fileName},
+		bson.M{
+			"$set":         bson.M{"size": size, "last_seen_at": now},
+			"$setOnInsert": bson.M{"first_seen_at": now},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+func DeleteMediaGCCandidates(ctx context.Context, fileNames []string) error {
+	if len(fileNames) == 0 {
+		return nil
+	}
+	_, err := database.DB.Collection("media_gc_candidates").DeleteMany(ctx,
+		bson.M{"_id": bson.M{"$in": fileNames}})
+	return err
+}
+
+func InsertMediaGCReport(ctx context.Context, r *models.MediaGCReport) error {
+	res, err := database.DB.Collection("media_gc_reports").InsertOne(ctx, r)
+	if err != nil {
+		return err
+	}
+	if oid, ok := res.InsertedID.(bson.ObjectID); ok {
+		r.ID = oid
+	}
+	return nil
+}
+
+func ListMediaGCReports(ctx context.Context, limit int64) ([]models.MediaGCReport, error) {
+	cur, err := database.DB.Collection("media_gc_reports").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	reports := []models.MediaGCReport{}
+	if err := cur.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func FindMediaGCReport(ctx context.Context, id bson.ObjectID) (*models.MediaGCReport, error) {
+	var r models.MediaGCReport
+	err := database.DB.Collection("media_gc_reports").FindOne(ctx, bson.M{"_id": id}).Decode(&r)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// FindPositionInScope หา position ที่ใช้ได้ที่ orgPath:
+// position ที่ scope.org_path ตรงกับ orgPath ก่อน ไม่งั้นเอาตัวที่ใกล้ที่สุดจาก ancestor ที่ scope.inherit = true
+// คืน nil ถ้าไม่มี position ที่ใช้ได้
+func FindPositionInScope(ctx context.Context, key string, orgPath string) (*models.Position, error) {
+	paths := append(utils.OrgAncestors(orgPath), orgPath)
+	cur, err := database.DB.Collection("positions").Find(ctx, bson.M{
+		"key":            key,
+		"scope.org_path": bson.M{"$in": paths},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var positions []models.Position
+	if err := cur.All(ctx, &positions); err != nil {
+		return nil, err
+	}
+
+	var best *models.Position
+	for i := range positions {
+		p := &positions[i]
+		if p.Scope.OrgPath == orgPath {
+			return p, nil
+		}
+		if !p.Scope.Inherit {
+			continue
+		}
+		if best == nil || len(p.Scope.OrgPath) > len(best.Scope.OrgPath) {
+			best = p
+		}
+	}
+	return best, nil
+}
+
+func PositionKeyExists(ctx context.Context, key string) (bool, error) {
+	n, err := database.DB.Collection("positions").CountDocuments(ctx, bson.M{"key": key})
+	return n > 0, err
+}
+
+func FindMembershipByID(ctx context.Context, id bson.ObjectID) (*models.Membership, error) {
+	var m models.Membership
+	err := database.DB.Collection("memberships").FindOne(ctx, bson.M{"_id": id}).Decode(&m)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// FindActiveHolders คืน membership ที่ active อยู่ของตำแหน่งนี้ที่ org นี้
+func FindActiveHolders(ctx context.Context, orgPath string, positionKey string) ([]models.Membership, error) {
+	cur, err := database.DB.Collection("memberships").Find(ctx, bson.M{
+		"org_path":     orgPath,
+		"position_key": positionKey,
+		"active":       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	holders := []models.Membership{}
+	if err := cur.All(ctx, &holders); err != nil {
+		return nil, err
+	}
+	return holders, nil
+}
+
+// TouchPositionGuard เขียนเอกสาร guard ของ (org_path, position_key) ต้องเรียกใน transaction ก่อนอ่าน holders
+// transaction สองตัวที่แต่งตั้งตำแหน่งเดียวกันพร้อมกันจะชนกันที่เอกสารนี้ (WriteConflict) ตัวหลังจะถูก retry
+// แล้วเห็น membership ของตัวแรก จึงตรวจ exclusive_per_org ได้ถูกต้อง
+func TouchPositionGuard(ctx context.Context, orgPath, positionKey string) error {
+	_, err := database.DB.Collection("position_guards").UpdateOne(ctx,
+		bson.M{"org_path": orgPath, "position_key": positionKey},
+		bson.M{"$inc": bson.M{"seq": 1}, "$set": bson.M{"updated_at": time.Now()}},
+		options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+// DeactivateMembershipsByID ปิดวาระ membership ที่ active อยู่ พร้อมบันทึก ended_at/end_reason
+func DeactivateMembershipsByID(ctx context.Context, ids []bson.ObjectID, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	now := time.Now()
+	_, err := database.DB.Collection("memberships").UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "active": true},
+		bson.M{"$set": bson.M{"active": false, "ended_at": now, "end_reason": reason, "updated_at": now}},
+	)
+	return err
+}
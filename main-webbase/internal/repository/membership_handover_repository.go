@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+func CountUsersByIDs(ctx context.Context, ids []bson.ObjectID) (int64, error) {
+	return database.DB.Collection("users").CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// SetMembershipTermEnd ต่อ/ปรับวาระของ membership ที่ยังดำรงตำแหน่งต่อ
+func SetMembershipTermEnd(ctx context.Context, id bson.ObjectID, termEnd *time.Time) error {
+	_, err := database.DB.Collection("memberships").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"term_end": termEnd, "updated_at": time.Now()}})
+	return err
+}
+
+func InsertMembershipHandover(ctx context.Context, h *models.MembershipHandover) error {
+	_, err := database.DB.Collection("membership_handovers").InsertOne(ctx, h)
+	return err
+}
+
+func ListMembershipHandovers(ctx context.Context, orgPath string) ([]models.MembershipHandover, error) {
+	cur, err := database.DB.Collection("membership_handovers").Find(ctx,
+		bson.M{"org_path": orgPath},
+		options.Find().SetSort(bson.D{{Key: "performed_at", Value: -1}}).SetLimit(100))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.MembershipHandover{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"main-webbase/database"
 	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
 )
 
 type MembershipRepository struct {
@@ -42,9 +43,12 @@ func InsertMembership(ctx context.Context, m models.MembershipRequestDTO) error
 	if orgNode == nil {
 		return fmt.Errorf("org path not found: %s", m.OrgPath)
 	}
+	if orgNode.Status == models.OrgStatusArchived {
+		return fmt.Errorf("org unit is archived: %s", m.OrgPath)
+	}
 
-	// 2. Check if Position exists
-	position, err := FindPositionByKeyandPath(ctx, m.PositionKey, m.OrgPath)
+	// 2. Check if Position exists (scope.org_path ตรง หรือ inherit มาจาก ancestor)
+	position, err := FindPositionInScope(ctx, m.PositionKey, m.OrgPath)
 	if err != nil {
 		return fmt.Errorf("error finding position: %w", err)
 	}
@@ -58,6 +62,11 @@ func InsertMembership(ctx context.Context, m models.MembershipRequestDTO) error
 		return fmt.Errorf("invalid user_id: %w", err)
 	}
 	m.Active = true
+	now := time.Now()
+	joinedAt := now
+	if m.JoinedAt != nil {
+		joinedAt = *m.JoinedAt
+	}
 
 	// 4. Check if membership already exists
 	col := database.DB.Collection("memberships")
@@ -65,6 +74,7 @@ func InsertMembership(ctx context.Context, m models.MembershipRequestDTO) error
 		"user_id":      userID,
 		"org_path":     m.OrgPath,
 		"position_key": m.PositionKey,
+		"active":       true,
 	})
 	if existing.Err() == nil {
 		return fmt.Errorf("membership already exists for user_id=%s, org_path=%s, position_key=%s",
@@ -80,8 +90,10 @@ func InsertMembership(ctx context.Context, m models.MembershipRequestDTO) error
 		PositionKey:  m.PositionKey,
 		Active:       m.Active,
 		OrgAncestors: orgNode.Ancestors,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		JoinedAt:     &joinedAt,
+		TermEnd:      m.TermEnd,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 
 	_, err = database.DB.Collection("memberships").InsertOne(ctx, membership)
@@ -100,10 +112,9 @@ func GetUserMemberships(ctx context.Context, uid string) ([]models.Membership, e
         return nil, fmt.Errorf("invalid user ID: %w", err)
     }
 
-	filter := bson.M{
-		"user_id" : userID,
-		"active"  : true,
-	}
+	// เฉพาะที่อยู่ในวาระตอนนี้ (ไม่นับที่ยังไม่เริ่มหรือเลย term_end แล้ว)
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["user_id"] = userID
 
 	cursor, err := col.Find(ctx, filter)
 	if err != nil {
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+// ExpireMembershipTerms ปิด membership ที่เลย term_end แล้ว (ended_at = term_end)
+// คืน user_id ที่ได้รับผลกระทบ
+func ExpireMembershipTerms(ctx context.Context, now time.Time) ([]bson.ObjectID, error) {
+	col := database.DB.Collection("memberships")
+	filter := bson.M{"active": true, "term_end": bson.M{"$lte": now}}
+
+	cur, err := col.Find(ctx, filter, options.Find().SetProjection(bson.M{"user_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var rows []models.Membership
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]bson.ObjectID, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	_, err = col.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "active": true}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"active":     false,
+			"ended_at":   "$term_end",
+			"end_reason": models.MembershipEndExpired,
+			"updated_at": now,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[bson.ObjectID]struct{}{}
+	users := make([]bson.ObjectID, 0, len(rows))
+	for _, r := range rows {
+		if _, ok := seen[r.UserID]; ok {
+			continue
+		}
+		seen[r.UserID] = struct{}{}
+		users = append(users, r.UserID)
+	}
+	return users, nil
+}
+
+// ListMembershipHistory คืนทุกวาระ (รวมที่จบแล้ว) เรียงจากล่าสุด
+func ListMembershipHistory(ctx context.Context, filter bson.M) ([]models.Membership, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "joined_at", Value: -1}, {Key: "created_at", Value: -1}})
+	cur, err := database.DB.Collection("memberships").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Membership{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// orgArchiveTarget คือสิ่งที่ถูกปิดเมื่อ archive subtree
+//   - PathField: field ที่ใช้หาเอกสารใน subtree
+//   - Match: เงื่อนไขว่าเอกสารยัง "เปิด" อยู่ (ของที่ปิดไปแล้วไม่แตะ)
+//   - Off / On: ค่าที่ $set ตอน archive / restore
+type orgArchiveTarget struct {
+	Collection string
+	PathField  string
+	Match      bson.M
+	Off        bson.M
+	On         bson.M
+	// SavePrev: เก็บค่าเดิมของ field นี้ไว้ใน archive_prev แล้วคืนตอน restore
+	SavePrev string
+}
+
+var orgArchiveTargets = []orgArchiveTarget{
+	{
+		Collection: "org_units", PathField: "org_path",
+		Match: bson.M{"status": models.OrgStatusActive},
+		Off:   bson.M{"status": models.OrgStatusArchived},
+		On:    bson.M{"status": models.OrgStatusActive},
+	},
+	{
+		Collection: "memberships", PathField: "org_path",
+		Match: bson.M{"active": true},
+		Off:   bson.M{"active": false},
+		On:    bson.M{"active": true},
+	},
+	{
+		Collection: "policies", PathField: "org_prefix",
+		Match: bson.M{"enabled": true},
+		Off:   bson.M{"enabled": false},
+		On:    bson.M{"enabled": true},
+	},
+	{
+		// รวมร่าง/โพสต์ตั้งเวลา (ไม่ให้ publisher เผยแพร่เข้าฟีดระหว่าง archive) และคืนสถานะเดิมตอน restore
+		Collection: "posts", PathField: "postAs.org_path",
+		Match:    bson.M{"status": bson.M{"$in": bson.A{models.PostStatusActive, models.PostStatusDraft, models.PostStatusScheduled}}},
+		Off:      bson.M{"status": models.OrgStatusArchived},
+		On:       bson.M{"status": models.PostStatusActive}, // archive เก่าที่ยังไม่มี archive_prev
+		SavePrev: "status",
+	},
+	{
+		// event ไม่มีสถานะ archived ใช้ inactive (ทุก query ซ่อน inactive อยู่แล้ว) แล้วคืนสถานะเดิมตอน restore
+		Collection: "events", PathField: "org_of_content",
+		Match:    bson.M{"status": bson.M{"$ne": "inactive"}},
+		Off:      bson.M{"status": "inactive"},
+		SavePrev: "status",
+	},
+}
+
+// ArchiveOrgSubtree ปิดทุกอย่างใน subtree แล้วติด archive_ref ไว้ คืนจำนวนที่ถูกปิดต่อ collection
+func ArchiveOrgSubtree(ctx context.Context, root string, ref bson.ObjectID, now time.Time) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, t := range orgArchiveTargets {
+		filter := bson.M{t.PathField: bson.M{"$regex": utils.OrgSubtreePattern(root)}}
+		for k, v := range t.Match {
+			filter[k] = v
+		}
+
+		set := bson.M{"archive_ref": ref, "updated_at": now}
+		for k, v := range t.Off {
+			set[k] = v
+		}
+
+		var update any = bson.M{"$set": set}
+		if t.SavePrev != "" {
+			// เก็บค่าเดิมก่อนเขียนทับ (pipeline update อ่านค่าเดิมได้)
+			set["archive_prev"] = "$" + t.SavePrev
+			update = mongo.Pipeline{{{Key: "$set", Value: set}}}
+		}
+
+		res, err := database.DB.Collection(t.Collection).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		counts[t.Collection] = res.ModifiedCount
+	}
+	return counts, nil
+}
+
+// prevValue ค่าเดิมที่เก็บไว้ตอน archive — ถ้าไม่มี (archive ก่อนที่ target นี้จะใช้ SavePrev) ใช้ค่าใน On
+func prevValue(t orgArchiveTarget) any {
+	if on, ok := t.On[t.SavePrev]; ok {
+		return bson.M{"$ifNull": bson.A{"$archive_prev", on}}
+	}
+	return "$archive_prev"
+}
+
+// RestoreOrgSubtree เปิดคืนเฉพาะเอกสารที่มี archive_ref ตรงกับ archive นี้
+func RestoreOrgSubtree(ctx context.Context, ref bson.ObjectID, now time.Time) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, t := range orgArchiveTargets {
+		filter := bson.M{"archive_ref": ref}
+
+		var update any
+		if t.SavePrev != "" {
+			update = mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{t.SavePrev: prevValue(t), "updated_at": now}}},
+				{{Key: "$unset", Value: bson.A{"archive_ref", "archive_prev"}}},
+			}
+		} else {
+			set := bson.M{"updated_at": now}
+			for k, v := range t.On {
+				set[k] = v
+			}
+			update = bson.M{"$set": set, "$unset": bson.M{"archive_ref": ""}}
+		}
+
+		res, err := database.DB.Collection(t.Collection).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		counts[t.Collection] = res.ModifiedCount
+	}
+	return counts, nil
+}
+
+func InsertOrgArchive(ctx context.Context, a models.OrgArchive) error {
+	_, err := database.DB.Collection("org_archives").InsertOne(ctx, a)
+	return err
+}
+
+// FindOpenOrgArchive หา archive ล่าสุดของ path ที่ยังไม่ถูก restore
+func FindOpenOrgArchive(ctx context.Context, orgPath string) (*models.OrgArchive, error) {
+	var a models.OrgArchive
+	err := database.DB.Collection("org_archives").FindOne(ctx,
+		bson.M{"org_path": orgPath, "restored_at": bson.M{"$exists": false}},
+		options.FindOne().SetSort(bson.D{{Key: "archived_at", Value: -1}}),
+	).Decode(&a)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func MarkOrgArchiveRestored(ctx context.Context, id, by bson.ObjectID, now time.Time) error {
+	_, err := database.DB.Collection("org_archives").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"restored_by": by, "restored_at": now}},
+	)
+	return err
+}
+
+// FindArchivedAncestor คืน ancestor ที่ถูก archive อยู่ (ถ้ามี) ใช้กันการ restore ลูกใต้ parent ที่ยังปิด
+func FindArchivedAncestor(ctx context.Context, orgPath string) (*models.OrgUnitNode, error) {
+	var node models.OrgUnitNode
+	err := database.DB.Collection("org_units").FindOne(ctx, bson.M{
+		"org_path": bson.M{"$in": utils.OrgAncestors(orgPath)},
+		"status":   models.OrgStatusArchived,
+	}).Decode(&node)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &node, nil
+}
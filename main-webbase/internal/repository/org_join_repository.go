@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+func InsertJoinRequest(ctx context.Context, r *models.JoinRequest) error {
+	_, err := database.DB.Collection("org_join_requests").InsertOne(ctx, r)
+	return err
+}
+
+func FindJoinRequestByID(ctx context.Context, id bson.ObjectID) (*models.JoinRequest, error) {
+	var r models.JoinRequest
+	err := database.DB.Collection("org_join_requests").FindOne(ctx, bson.M{"_id": id}).Decode(&r)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func HasPendingJoinRequest(ctx context.Context, userID bson.ObjectID, orgPath, positionKey string) (bool, error) {
+	n, err := database.DB.Collection("org_join_requests").CountDocuments(ctx, bson.M{
+		"user_id":      userID,
+		"org_path":     orgPath,
+		"position_key": positionKey,
+		"status":       models.JoinRequestPending,
+	})
+	return n > 0, err
+}
+
+func ListJoinRequests(ctx context.Context, filter bson.M) ([]models.JoinRequest, error) {
+	cur, err := database.DB.Collection("org_join_requests").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(500))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.JoinRequest{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DecideJoinRequest เปลี่ยนสถานะจาก pending เท่านั้น (กันการอนุมัติซ้ำพร้อมกัน)
+func DecideJoinRequest(ctx context.Context, id bson.ObjectID, from string, set bson.M) (*models.JoinRequest, error) {
+	set["updated_at"] = time.Now()
+	var r models.JoinRequest
+	err := database.DB.Collection("org_join_requests").FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&r)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// FindAssignerUserIDs ผู้ที่มี membership:assign ครอบคลุม orgPath (ใช้แจ้งเตือนคำขอใหม่)
+func FindAssignerUserIDs(ctx context.Context, orgPath string) ([]bson.ObjectID, error) {
+	paths := append(utils.OrgAncestors(orgPath), orgPath)
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["org_path"] = bson.M{"$in": paths}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "policies",
+			"let":  bson.M{"pk": "$position_key", "op": "$org_path"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{
+					"$expr": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$position_key", "$$pk"}},
+						bson.M{"$eq": bson.A{"$org_prefix", "$$op"}},
+					}},
+					"enabled": true,
+					"actions": "membership:assign",
+					"$or": bson.A{
+						bson.M{"scope": "subtree"},
+						bson.M{"scope": "exact", "org_prefix": orgPath},
+					},
+				}},
+			},
+			"as": "policy_docs",
+		}}},
+		{{Key: "$match", Value: bson.M{"policy_docs.0": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id"}}},
+	}
+	cur, err := database.DB.Collection("memberships").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]bson.ObjectID, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+func InsertOrgInvite(ctx context.Context, inv *models.OrgInvite) error {
+	_, err := database.DB.Collection("org_invites").InsertOne(ctx, inv)
+	return err
+}
+
+func FindOrgInviteByID(ctx context.Context, id bson.ObjectID) (*models.OrgInvite, error) {
+	var inv models.OrgInvite
+	err := database.DB.Collection("org_invites").FindOne(ctx, bson.M{"_id": id}).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func FindOrgInviteByCode(ctx context.Context, code string) (*models.OrgInvite, error) {
+	var inv models.OrgInvite
+	err := database.DB.Collection("org_invites").FindOne(ctx, bson.M{"code": code}).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func ListOrgInvites(ctx context.Context, orgPath string) ([]models.OrgInvite, error) {
+	cur, err := database.DB.Collection("org_invites").Find(ctx, bson.M{"org_path": orgPath},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.OrgInvite{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func RevokeOrgInvite(ctx context.Context, id bson.ObjectID) error {
+	_, err := database.DB.Collection("org_invites").UpdateOne(ctx,
+		bson.M{"_id": id}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+// ClaimOrgInvite จองการใช้โค้ด 1 ครั้งแบบ atomic (ยังไม่หมดอายุ ไม่ถูก revoke ยังไม่เต็ม และ user ยังไม่เคยใช้)
+// คืน nil ถ้าใช้ไม่ได้
+func ClaimOrgInvite(ctx context.Context, code string, userID bson.ObjectID, now time.Time) (*models.OrgInvite, error) {
+	filter := bson.M{
+		"code":        code,
+		"revoked":     false,
+		"expires_at":  bson.M{"$gt": now},
+		"redeemed_by": bson.M{"$ne": userID},
+		"$or": bson.A{
+			bson.M{"max_uses": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+		},
+	}
+	var inv models.OrgInvite
+	err := database.DB.Collection("org_invites").FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"uses": 1}, "$push": bson.M{"redeemed_by": userID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&inv)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ReleaseOrgInvite คืนสิทธิ์ที่จองไว้เมื่อสร้าง membership ไม่สำเร็จ
+func ReleaseOrgInvite(ctx context.Context, id bson.ObjectID, userID bson.ObjectID) error {
+	_, err := database.DB.Collection("org_invites").UpdateOne(ctx,
+		bson.M{"_id": id, "redeemed_by": userID},
+		bson.M{"$inc": bson.M{"uses": -1}, "$pull": bson.M{"redeemed_by": userID}})
+	return err
+}
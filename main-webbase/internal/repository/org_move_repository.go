@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// OrgPathRef คือ field ที่เก็บ org_path เป็น string (ต้องถูกเขียนใหม่เมื่อย้าย/เปลี่ยนชื่อหน่วยงาน)
+// ArrayField != "" แปลว่า path อยู่ใน sub-document ของ array เช่น visibility.audience[].org_path
+type OrgPathRef struct {
+	Collection string
+	Field      string
+	ArrayField string
+}
+
+// OrgPathRefs รายการ field ทั้งหมดที่อ้างถึง org_path แบบ string
+// (org_units กับ ancestors ของ memberships คำนวณใหม่แยกต่างหาก)
+var OrgPathRefs = []OrgPathRef{
+	{Collection: "memberships", Field: "org_path"},
+	{Collection: "policies", Field: "org_prefix"},
+	{Collection: "positions", Field: "scope.org_path"},
+	{Collection: "posts", Field: "postAs.org_path"},
+	{Collection: "posts", ArrayField: "targeting.audience", Field: "org_path"},
+	{Collection: "events", Field: "org_of_content"},
+	{Collection: "events", Field: "postedas.org_path"},  // ตอน insert (models.Event ไม่มี bson tag)
+	{Collection: "events", Field: "posted_as.org_path"}, // ตอน update
+	{Collection: "events", ArrayField: "visibility.audience", Field: "org_path"},
+	{Collection: "org_path_redirects", Field: "to"},
+}
+
+// RebaseOrgPathRef เขียน prefix oldRoot -> newRoot ของ field หนึ่งด้วย pipeline update
+func RebaseOrgPathRef(ctx context.Context, ref OrgPathRef, oldRoot, newRoot string) (int64, error) {
+	col := database.DB.Collection(ref.Collection)
+	cut := len(oldRoot)
+
+	if ref.ArrayField == "" {
+		filter := bson.M{ref.Field: bson.M{"$regex": utils.OrgSubtreePattern(oldRoot)}}
+		update := mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				ref.Field: bson.M{"$concat": bson.A{newRoot, bson.M{"$substrBytes": bson.A{"$" + ref.Field, cut, -1}}}},
+			}}},
+		}
+		res, err := col.UpdateMany(ctx, filter, update)
+		if err != nil {
+			return 0, err
+		}
+		return res.ModifiedCount, nil
+	}
+
+	item := "$$it." + ref.Field
+	filter := bson.M{ref.ArrayField + "." + ref.Field: bson.M{"$regex": utils.OrgSubtreePattern(oldRoot)}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			ref.ArrayField: bson.M{"$map": bson.M{
+				"input": "$" + ref.ArrayField,
+				"as":    "it",
+				"in": bson.M{"$cond": bson.A{
+					bson.M{"$regexMatch": bson.M{"input": item, "regex": utils.OrgSubtreePattern(oldRoot)}},
+					bson.M{"$mergeObjects": bson.A{"$$it", bson.M{
+						ref.Field: bson.M{"$concat": bson.A{newRoot, bson.M{"$substrBytes": bson.A{item, cut, -1}}}},
+					}}},
+					"$$it",
+				}},
+			}},
+		}}},
+	}
+	res, err := col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// UpdateOrgUnitPlacement บันทึกตำแหน่งใหม่ของ node (path, parent, ancestors, depth, ชื่อ)
+func UpdateOrgUnitPlacement(ctx context.Context, node models.OrgUnitNode) error {
+	_, err := database.DB.Collection("org_units").UpdateOne(ctx,
+		bson.M{"_id": node.ID},
+		bson.M{"$set": bson.M{
+			"org_path":    node.OrgPath,
+			"parent_path": node.ParentPath,
+			"ancestors":   node.Ancestors,
+			"depth":       node.Depth,
+			"name":        node.Name,
+			"shortname":   node.ShortName,
+			"slug":        node.Slug,
+			"updated_at":  node.UpdatedAt,
+		}},
+	)
+	return err
+}
+
+// RecomputeMembershipAncestors คำนวณ org_ancestors ใหม่ให้ membership ใน subtree ที่เพิ่งย้าย
+func RecomputeMembershipAncestors(ctx context.Context, root string) (int64, error) {
+	col := database.DB.Collection("memberships")
+	cur, err := col.Find(ctx,
+		bson.M{"org_path": bson.M{"$regex": utils.OrgSubtreePattern(root)}},
+		options.Find().SetProjection(bson.M{"org_path": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cur.Next(ctx) {
+		var row struct {
+			ID      bson.ObjectID `bson:"_id"`
+			OrgPath string        `bson:"org_path"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return 0, err
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": row.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"org_ancestors": utils.OrgAncestors(row.OrgPath)}}))
+	}
+	if err := cur.Err(); err != nil {
+		return 0, err
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	res, err := col.BulkWrite(ctx, writes)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// ===== org path redirects =====
+
+func UpsertOrgPathRedirect(ctx context.Context, from, to string, nodeID bson.ObjectID) error {
+	now := time.Now().UTC()
+	_, err := database.DB.Collection("org_path_redirects").UpdateOne(ctx,
+		bson.M{"_id": from},
+		bson.M{
+			"$set":         bson.M{"to": to, "node_id": nodeID, "updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+// DeleteOrgPathRedirectsUnder ลบ redirect ที่ชี้ทับ path ที่กลับมามีหน่วยงานจริงแล้ว
+func DeleteOrgPathRedirectsUnder(ctx context.Context, root string) error {
+	_, err := database.DB.Collection("org_path_redirects").DeleteMany(ctx,
+		bson.M{"_id": bson.M{"$regex": utils.OrgSubtreePattern(root)}})
+	return err
+}
+
+// FindOrgPathRedirect หา redirect ของ path หรือของ ancestor ที่ใกล้ที่สุด
+func FindOrgPathRedirect(ctx context.Context, path string) (*models.OrgPathRedirect, error) {
+	candidates := append(utils.OrgAncestors(path), path)
+	cur, err := database.DB.Collection("org_path_redirects").Find(ctx,
+		bson.M{"_id": bson.M{"$in": candidates}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var best *models.OrgPathRedirect
+	for cur.Next(ctx) {
+		var r models.OrgPathRedirect
+		if err := cur.Decode(&r); err != nil {
+			return nil, err
+		}
+		if best == nil || len(r.From) > len(best.From) {
+			rr := r
+			best = &rr
+		}
+	}
+	if err := cur.Err(); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return best, nil
+}
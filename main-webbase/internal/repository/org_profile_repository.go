@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+type PositionMemberCount struct {
+	PositionKey string `bson:"_id"`
+	Count       int64  `bson:"count"`
+}
+
+// CountActiveMembersByPosition นับสมาชิก active ของหน่วยงาน (เฉพาะ path นี้) แยกตาม position
+func CountActiveMembersByPosition(ctx context.Context, orgPath string) ([]PositionMemberCount, error) {
+	cur, err := database.DB.Collection("memberships").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"org_path": orgPath, "active": true}}},
+		{{Key: "$group", Value: bson.M{"_id": "$position_key", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	rows := []PositionMemberCount{}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func CountActivePostsAs(ctx context.Context, orgPath string) (int64, error) {
+	return database.DB.Collection("posts").CountDocuments(ctx,
+		bson.M{"postAs.org_path": orgPath, "status": "active"})
+}
+
+func CountEventsOfOrg(ctx context.Context, orgPath string) (int64, error) {
+	return database.DB.Collection("events").CountDocuments(ctx,
+		bson.M{"org_of_content": orgPath, "status": bson.M{"$ne": "inactive"}})
+}
+
+func CountOrgChildren(ctx context.Context, orgPath string) (int64, error) {
+	return database.DB.Collection("org_units").CountDocuments(ctx,
+		bson.M{"parent_path": orgPath, "status": models.OrgStatusActive})
+}
+
+// EventNextSchedule คือ event พร้อมรอบถัดไปที่ยังไม่เริ่ม
+type EventNextSchedule struct {
+	Event    models.Event
+	Schedule models.EventSchedule
+}
+
+// FindUpcomingEventsOfOrg คืน event ของหน่วยงานที่มีรอบเริ่มหลัง now เรียงตามเวลาเริ่ม
+func FindUpcomingEventsOfOrg(ctx context.Context, orgPath string, now time.Time, limit int64) ([]EventNextSchedule, error) {
+	cur, err := database.DB.Collection("events").Find(ctx,
+		bson.M{"org_of_content": orgPath, "status": bson.M{"$ne": "inactive"}})
+	if err != nil {
+		return nil, err
+	}
+	var events []models.Event
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return []EventNextSchedule{}, nil
+	}
+
+	byID := make(map[bson.ObjectID]models.Event, len(events))
+	ids := make([]bson.ObjectID, 0, len(events))
+	for _, ev := range events {
+		byID[ev.ID] = ev
+		ids = append(ids, ev.ID)
+	}
+
+	scur, err := database.DB.Collection("event_schedules").Find(ctx,
+		bson.M{"event_id": bson.M{"$in": ids}, "time_start": bson.M{"$gte": now}},
+		options.Find().SetSort(bson.D{{Key: "time_start", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var schedules []models.EventSchedule
+	if err := scur.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+
+	out := []EventNextSchedule{}
+	seen := map[bson.ObjectID]bool{}
+	for _, s := range schedules {
+		if seen[s.EventID] {
+			continue
+		}
+		seen[s.EventID] = true
+		out = append(out, EventNextSchedule{Event: byID[s.EventID], Schedule: s})
+		if limit > 0 && int64(len(out)) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func UpdateOrgUnitProfile(ctx context.Context, id bson.ObjectID, set bson.M) error {
+	_, err := database.DB.Collection("org_units").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
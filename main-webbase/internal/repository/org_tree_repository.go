@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+func ListPositionsUnder(ctx context.Context, root string) ([]models.Position, error) {
+	cur, err := database.DB.Collection("positions").Find(ctx,
+		bson.M{"scope.org_path": bson.M{"$regex": utils.OrgSubtreePattern(root)}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	positions := []models.Position{}
+	if err := cur.All(ctx, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+func ListPoliciesUnder(ctx context.Context, root string) ([]models.Policy, error) {
+	cur, err := database.DB.Collection("policies").Find(ctx,
+		bson.M{"org_prefix": bson.M{"$regex": utils.OrgSubtreePattern(root)}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	policies := []models.Policy{}
+	if err := cur.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ListPoliciesByArchiveRef policy ที่ถูกปิดโดย archive นี้
+func ListPoliciesByArchiveRef(ctx context.Context, ref bson.ObjectID) ([]models.Policy, error) {
+	return findPolicies(ctx, bson.M{"archive_ref": ref})
+}
+
+// FindPoliciesByIDs ใช้อ่านสถานะหลังเขียนแบบ bulk
+func FindPoliciesByIDs(ctx context.Context, ids []bson.ObjectID) ([]models.Policy, error) {
+	return findPolicies(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func findPolicies(ctx context.Context, filter bson.M) ([]models.Policy, error) {
+	cur, err := database.DB.Collection("policies").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	policies := []models.Policy{}
+	if err := cur.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func ListMembershipsUnder(ctx context.Context, root string) ([]models.Membership, error) {
+	cur, err := database.DB.Collection("memberships").Find(ctx,
+		bson.M{"org_path": bson.M{"$regex": utils.OrgSubtreePattern(root)}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	memberships := []models.Membership{}
+	if err := cur.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// SetDocFields $set แบบทั่วไปตาม _id (ใช้ตอน apply แผน import)
+func SetDocFields(ctx context.Context, collection string, id bson.ObjectID, set bson.M) error {
+	_, err := database.DB.Collection(collection).UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func InsertPosition(ctx context.Context, p models.Position) error {
+	_, err := database.DB.Collection("positions").InsertOne(ctx, p)
+	return err
+}
+
+func InsertPolicy(ctx context.Context, p models.Policy) error {
+	_, err := database.DB.Collection("policies").InsertOne(ctx, p)
+	return err
+}
+
+func InsertMembershipDoc(ctx context.Context, m models.Membership) error {
+	_, err := database.DB.Collection("memberships").InsertOne(ctx, m)
+	return err
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+func ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	cur, err := database.DB.Collection("permissions").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "key", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Permission{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnknownPermissionKeys คืน key ที่ไม่มีใน catalog
+func UnknownPermissionKeys(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	found, err := database.DB.Collection("permissions").Distinct(ctx, "key", bson.M{"key": bson.M{"$in": keys}}).Raw()
+	if err != nil {
+		return nil, err
+	}
+	vals, err := found.Values()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(vals))
+	for _, v := range vals {
+		if s, ok := v.StringValueOK(); ok {
+			known[s] = true
+		}
+	}
+	var unknown []string
+	for _, k := range keys {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	return unknown, nil
+}
+
+func ListRoleTemplates(ctx context.Context) ([]models.Role, error) {
+	cur, err := database.DB.Collection("role_templates").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Role{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FindRoleTemplate คืน nil, nil ถ้าไม่พบ
+func FindRoleTemplate(ctx context.Context, name string) (*models.Role, error) {
+	var r models.Role
+	err := database.DB.Collection("role_templates").FindOne(ctx, bson.M{"name": name}).Decode(&r)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func InsertRoleTemplate(ctx context.Context, r *models.Role) error {
+	_, err := database.DB.Collection("role_templates").InsertOne(ctx, r)
+	return err
+}
+
+func UpdateRoleTemplate(ctx context.Context, name string, set bson.M) error {
+	_, err := database.DB.Collection("role_templates").UpdateOne(ctx, bson.M{"name": name}, bson.M{"$set": set})
+	return err
+}
+
+func DeleteRoleTemplate(ctx context.Context, name string) error {
+	_, err := database.DB.Collection("role_templates").DeleteOne(ctx, bson.M{"name": name})
+	return err
+}
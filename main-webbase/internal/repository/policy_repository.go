@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"maps"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -60,9 +61,12 @@ func (r *PolicyRepository) FindByPositionsAndAction(ctx context.Context, posKeys
 
 func FindPolicyByKeyandPath(ctx context.Context, key string, path string) (*models.Policy, error) {
 	col := database.DB.Collection("policies")
+	// policy หลักของตำแหน่ง: allow ที่ไม่มีเงื่อนไข (deny/conditional เป็นกฎแยกต่างหาก)
 	filter := bson.M{
 		"position_key": key,
 		"org_prefix":   path,
+		"effect":       bson.M{"$ne": models.PolicyEffectDeny},
+		"conditions":   bson.M{"$exists": false},
 	}
 	var policy models.Policy
 	err := col.FindOne(ctx, filter).Decode(&policy)
@@ -74,4 +78,34 @@ func FindPolicyByKeyandPath(ctx context.Context, key string, path string) (*mode
 	}
 
 	return &policy, nil
-}
\ No newline at end of file
+}
+// FindPolicyRule หา policy ที่ตรงทั้ง position, org_prefix, effect และ conditions
+// (เทียบ conditions ฝั่ง Go เพราะลำดับ key ของ embedded document ไม่แน่นอน)
+func FindPolicyRule(ctx context.Context, p models.Policy) (*models.Policy, error) {
+	col := database.DB.Collection("policies")
+	filter := bson.M{
+		"position_key": p.PositionKey,
+		"org_prefix":   p.OrgPrefix,
+	}
+	if p.IsDeny() {
+		filter["effect"] = models.PolicyEffectDeny
+	} else {
+		filter["effect"] = bson.M{"$ne": models.PolicyEffectDeny}
+	}
+	cur, err := col.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var candidates []models.Policy
+	if err := cur.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		if maps.Equal(candidates[i].Conditions, p.Conditions) {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
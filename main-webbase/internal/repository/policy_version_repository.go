@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+func FindPolicyByID(ctx context.Context, id bson.ObjectID) (*models.Policy, error) {
+	var p models.Policy
+	err := database.DB.Collection("policies").FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ReplacePolicy เขียน policy ทั้งเอกสาร (upsert — ใช้ตอน rollback policy ที่ถูกลบไปแล้ว)
+func ReplacePolicy(ctx context.Context, p models.Policy) error {
+	_, err := database.DB.Collection("policies").ReplaceOne(ctx, bson.M{"_id": p.ID}, p, options.Replace().SetUpsert(true))
+	return err
+}
+
+func DeletePolicyByID(ctx context.Context, id bson.ObjectID) error {
+	_, err := database.DB.Collection("policies").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// LatestPolicyVersion คืน nil, nil ถ้ายังไม่มีประวัติ
+func LatestPolicyVersion(ctx context.Context, policyID bson.ObjectID) (*models.PolicyVersion, error) {
+	var v models.PolicyVersion
+	err := database.DB.Collection("policy_versions").FindOne(ctx,
+		bson.M{"policy_id": policyID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}),
+	).Decode(&v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func FindPolicyVersion(ctx context.Context, policyID bson.ObjectID, version int) (*models.PolicyVersion, error) {
+	var v models.PolicyVersion
+	err := database.DB.Collection("policy_versions").FindOne(ctx, bson.M{"policy_id": policyID, "version": version}).Decode(&v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func InsertPolicyVersion(ctx context.Context, v *models.PolicyVersion) error {
+	_, err := database.DB.Collection("policy_versions").InsertOne(ctx, v)
+	return err
+}
+
+// ListPolicyVersions ใหม่สุดก่อน
+func ListPolicyVersions(ctx context.Context, policyID bson.ObjectID) ([]models.PolicyVersion, error) {
+	cur, err := database.DB.Collection("policy_versions").Find(ctx,
+		bson.M{"policy_id": policyID},
+		options.Find().SetSort(bson.D{{Key: "version", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.PolicyVersion{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
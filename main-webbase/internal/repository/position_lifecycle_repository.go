@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/database"
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// FindPositionByID คืน nil, nil ถ้าไม่พบ
+func FindPositionByID(ctx context.Context, id bson.ObjectID) (*models.Position, error) {
+	var p models.Position
+	err := database.DB.Collection("positions").FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// PositionKeyTakenAt มีตำแหน่งอื่นใช้ key นี้ที่ scope.org_path เดียวกันแล้วหรือไม่
+func PositionKeyTakenAt(ctx context.Context, key, orgPath string, except bson.ObjectID) (bool, error) {
+	n, err := database.DB.Collection("positions").CountDocuments(ctx, bson.M{
+		"key":            key,
+		"scope.org_path": orgPath,
+		"_id":            bson.M{"$ne": except},
+	})
+	return n > 0, err
+}
+
+// DistinctPositionPaths org path ทั้งหมดใน collection ที่อ้าง position key นี้ภายใต้ scope
+// (exact = org เดียว, inherit = ทั้ง subtree) — ผู้เรียกต้องกรองต่อว่า path นั้น resolve มาที่ตำแหน่งนี้จริง
+func DistinctPositionPaths(ctx context.Context, collection, keyField, pathField, key string, scope models.Scope) ([]string, error) {
+	filter := bson.M{keyField: key}
+	if scope.Inherit {
+		filter[pathField] = bson.M{"$regex": utils.OrgSubtreePattern(scope.OrgPath)}
+	} else {
+		filter[pathField] = scope.OrgPath
+	}
+	var paths []string
+	if err := database.DB.Collection(collection).Distinct(ctx, pathField, filter).Decode(&paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// RenamePositionKeyAt เปลี่ยน position key ของเอกสารที่อยู่ใน paths
+func RenamePositionKeyAt(ctx context.Context, collection, keyField, pathField, oldKey, newKey string, paths []string) (int64, error) {
+	if len(paths) == 0 || oldKey == newKey {
+		return 0, nil
+	}
+	res, err := database.DB.Collection(collection).UpdateMany(ctx,
+		bson.M{keyField: oldKey, pathField: bson.M{"$in": paths}},
+		bson.M{"$set": bson.M{keyField: newKey}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// ListCurrentHoldersAt membership ที่อยู่ในวาระของ key นี้ใน paths
+func ListCurrentHoldersAt(ctx context.Context, key string, paths []string) ([]models.Membership, error) {
+	if len(paths) == 0 {
+		return []models.Membership{}, nil
+	}
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["position_key"] = key
+	filter["org_path"] = bson.M{"$in": paths}
+	cur, err := database.DB.Collection("memberships").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Membership{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetMembershipPosition ย้าย membership ไปตำแหน่งใหม่ (ใช้ตอนลบตำแหน่งแบบมีตัวแทน)
+func SetMembershipPosition(ctx context.Context, ids []bson.ObjectID, key string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := database.DB.Collection("memberships").UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"position_key": key, "updated_at": time.Now()}})
+	return err
+}
+
+// RepointPosts ย้าย postAs ของโพสต์จากตำแหน่งเดิมไปตำแหน่งใหม่ (key + position_id)
+func RepointPosts(ctx context.Context, from *models.Position, toKey string, toID bson.ObjectID, paths []string) (int64, error) {
+	res, err := database.DB.Collection("posts").UpdateMany(ctx,
+		bson.M{
+			"postAs.position_key": from.Key,
+			"$or": []bson.M{
+				{"position_id": from.ID},
+				{"postAs.org_path": bson.M{"$in": paths}},
+			},
+		},
+		bson.M{"$set": bson.M{"postAs.position_key": toKey, "position_id": toID}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// ListPoliciesForKeyAt policy ของตำแหน่งที่ org_prefix อยู่ใน paths
+// (ผู้เรียกเขียนทีละตัวเพื่อบันทึก version ของ policy)
+func ListPoliciesForKeyAt(ctx context.Context, key string, paths []string) ([]models.Policy, error) {
+	if len(paths) == 0 {
+		return []models.Policy{}, nil
+	}
+	cur, err := database.DB.Collection("policies").Find(ctx,
+		bson.M{"position_key": key, "org_prefix": bson.M{"$in": paths}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Policy{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func DeletePositionByID(ctx context.Context, id bson.ObjectID) error {
+	_, err := database.DB.Collection("positions").DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/internal/models"
+	"main-webbase/internal/utils"
+)
+
+// PostAudienceMatch เงื่อนไข $match บน posts สำหรับ Post.Targeting (access = org)
+// ใช้ร่วมกันทั้ง ListAllPostsVisibleToViewer และ buildCommonPipeline
+//
+//   - ไม่มี targeting → ผ่าน (public / private แบบเดิมไปตัดสินด้วย post_role_visibility ต่อ)
+//   - ผู้เขียนเห็นเสมอ, root ("/") เห็นทุกโพสต์
+//   - deny_user_ids ชนะทุกอย่าง, allow_user_ids เห็นได้แม้ไม่อยู่ในกลุ่ม
+//   - นอกนั้นต้องมี membership "ตัวเดียวกัน" ที่ตรงทั้ง audience (exact = org นั้น, subtree = org นั้นหรือลูก),
+//     include_positions (ว่าง = ทุกตำแหน่ง) และไม่อยู่ใน exclude_positions
+func PostAudienceMatch(viewerID bson.ObjectID, roles []models.ViewerRole) bson.M {
+	reach := []bson.M{}
+	if !viewerID.IsZero() {
+		reach = append(reach, bson.M{"targeting.allow_user_ids": viewerID.Hex()})
+	}
+	for _, r := range roles {
+		if r.OrgPath == "/" {
+			return bson.M{}
+		}
+		subtree := append(utils.OrgAncestors(r.OrgPath), r.OrgPath)
+		reach = append(reach, bson.M{
+			"targeting.exclude_positions": bson.M{"$ne": r.PositionKey},
+			"$and": []bson.M{
+				{"$or": []bson.M{
+					{"targeting.include_positions.0": bson.M{"$exists": false}},
+					{"targeting.include_positions": r.PositionKey},
+				}},
+				{"$or": []bson.M{
+					{"targeting.audience.0": bson.M{"$exists": false}},
+					{"targeting.audience": bson.M{"$elemMatch": bson.M{"$or": []bson.M{
+						{"scope": "exact", "org_path": r.OrgPath},
+						{"scope": bson.M{"$ne": "exact"}, "org_path": bson.M{"$in": subtree}},
+					}}}},
+				}},
+			},
+		})
+	}
+
+	or := []bson.M{{"targeting": nil}}
+	if !viewerID.IsZero() {
+		or = append(or, bson.M{"user_id": viewerID})
+	}
+	if len(reach) > 0 {
+		targeted := bson.M{"$or": reach}
+		if !viewerID.IsZero() {
+			targeted = bson.M{"$and": []bson.M{
+				{"targeting.deny_user_ids": bson.M{"$ne": viewerID.Hex()}},
+				{"$or": reach},
+			}}
+		}
+		or = append(or, targeted)
+	}
+	return bson.M{"$or": or}
+}
+
+// postVisibilityLabel ค่า visibility ที่ส่งให้ FE: org (targeting) > private (post_role_visibility) > public
+func postVisibilityLabel(hasRoleVisibility any) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$targeting", nil}}, nil}},
+		"org",
+		bson.M{"$cond": bson.A{hasRoleVisibility, "private", "public"}},
+	}}
+}
+
+// SetPostTargeting เขียน/ลบ targeting ของโพสต์ (nil = ไม่กำหนดกลุ่ม)
+func SetPostTargeting(ctx context.Context, db *mongo.Database, postID bson.ObjectID, t *models.Visibility) error {
+	update := bson.M{"$unset": bson.M{"targeting": ""}}
+	if t != nil {
+		update = bson.M{"$set": bson.M{"targeting": t}}
+	}
+	_, err := db.Collection("posts").UpdateOne(ctx, bson.M{"_id": postID}, update)
+	return err
+}
+
+// FindPostVisibleTo เหมือน FindPostByID แต่โพสต์ที่มี targeting ต้องผ่าน PostAudienceMatch (ไม่ผ่าน = ErrNoDocuments)
+func FindPostVisibleTo(ctx context.Context, col *mongo.Collection, id, viewerID bson.ObjectID, roles []models.ViewerRole) (models.Post, error) {
+	var p models.Post
+	filter := bson.M{"_id": id, "$and": []bson.M{PostAudienceMatch(viewerID, roles)}}
+	err := col.FindOne(ctx, filter).Decode(&p)
+	return p, err
+}
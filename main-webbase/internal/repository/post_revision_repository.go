@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/internal/models"
+)
+
+// LatestPostRevision เลข revision ล่าสุดของโพสต์ (0 = ยังไม่เคยแก้)
+func LatestPostRevision(ctx context.Context, db *mongo.Database, postID bson.ObjectID) (int, error) {
+	var doc struct {
+		Revision int `bson:"revision"`
+	}
+	err := db.Collection("post_revisions").FindOne(ctx,
+		bson.M{"post_id": postID},
+		options.FindOne().SetSort(bson.D{{Key: "revision", Value: -1}}).SetProjection(bson.M{"revision": 1}),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return doc.Revision, err
+}
+
+func InsertPostRevision(ctx context.Context, db *mongo.Database, r *models.PostRevision) error {
+	_, err := db.Collection("post_revisions").InsertOne(ctx, r)
+	return err
+}
+
+// ListPostRevisions ใหม่สุดก่อน
+func ListPostRevisions(ctx context.Context, db *mongo.Database, postID bson.ObjectID) ([]models.PostRevision, error) {
+	cur, err := db.Collection("post_revisions").Find(ctx,
+		bson.M{"post_id": postID},
+		options.Find().SetSort(bson.D{{Key: "revision", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.PostRevision{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"main-webbase/internal/models"
+)
+
+// UnpublishedPostStatuses โพสต์ที่ยังไม่เผยแพร่ (แก้/ยกเลิก/เผยแพร่ได้)
+var UnpublishedPostStatuses = bson.A{models.PostStatusDraft, models.PostStatusScheduled}
+
+// PublishPostDoc เปลี่ยนโพสต์ที่ตรง filter เป็น active: created_at = เวลาเผยแพร่ (ขึ้นฟีด/นับ trending ตามวันนั้น)
+// การแก้ระหว่างเป็นร่างไม่นับว่า "แก้ไขแล้ว" จึงลบ edited_at ทิ้ง (revision ยังเก็บไว้)
+// คืน nil, nil ถ้าไม่มีโพสต์ตรง (ถูกเผยแพร่/ยกเลิก/เลื่อนไปแล้ว)
+func PublishPostDoc(ctx context.Context, db *mongo.Database, filter bson.M, at time.Time) (*models.Post, error) {
+	var out models.Post
+	err := db.Collection("posts").FindOneAndUpdate(ctx, filter,
+		bson.M{
+			"$set":   bson.M{"status": models.PostStatusActive, "created_at": at, "updated_at": at},
+			"$unset": bson.M{"publish_at": "", "edited_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&out)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetPostSchedule เปลี่ยน status/publish_at ของโพสต์ที่ status อยู่ใน from (publishAt = nil → ลบ publish_at)
+func SetPostSchedule(ctx context.Context, db *mongo.Database, postID bson.ObjectID, from bson.A, status string, publishAt *time.Time) (bool, error) {
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now().UTC()}}
+	if publishAt != nil {
+		update["$set"].(bson.M)["publish_at"] = *publishAt
+	} else {
+		update["$unset"] = bson.M{"publish_at": ""}
+	}
+	res, err := db.Collection("posts").UpdateOne(ctx, bson.M{"_id": postID, "status": bson.M{"$in": from}}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// ListDueScheduledPostIDs โพสต์ที่ถึงเวลาเผยแพร่แล้ว (เก่าสุดก่อน)
+func ListDueScheduledPostIDs(ctx context.Context, db *mongo.Database, now time.Time, limit int64) ([]bson.ObjectID, error) {
+	cur, err := db.Collection("posts").Find(ctx,
+		bson.M{"status": models.PostStatusScheduled, "publish_at": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.D{{Key: "publish_at", Value: 1}}).SetLimit(limit).SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]bson.ObjectID, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+// FindUnpublishedPosts ร่าง/โพสต์ตั้งเวลา ของผู้เขียน หรือที่โพสต์ในนาม org ที่ตรง orgFilters
+func FindUnpublishedPosts(ctx context.Context, db *mongo.Database, authorID bson.ObjectID, orgFilters []bson.M) ([]models.Post, error) {
+	or := append([]bson.M{{"user_id": authorID}}, orgFilters...)
+	cur, err := db.Collection("posts").Find(ctx,
+		bson.M{"status": bson.M{"$in": UnpublishedPostStatuses}, "$or": or},
+		options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}).SetLimit(200),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.Post{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
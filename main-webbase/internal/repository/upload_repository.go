@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"main-webbase/database"
+	"main-webbase/internal/models"
+)
+
+func InsertUpload(ctx context.Context, up models.Upload) error {
+	_, err := database.DB.Collection("uploads").InsertOne(ctx, up)
+	return err
+}
+
+func FindUploadByID(ctx context.Context, id bson.ObjectID) (*models.Upload, error) {
+	var up models.Upload
+	err := database.DB.Collection("uploads").FindOne(ctx, bson.M{"_id": id}).Decode(&up)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &up, nil
+}
+
+// SumUserUploadBytes รวม Upload-Length ของไฟล์ที่ยังนับ quota (กำลังอัปโหลด + เสร็จแล้ว)
+func SumUserUploadBytes(ctx context.Context, userID bson.ObjectID) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id": userID,
+			"status":  bson.M{"$in": bson.A{models.UploadStatusUploading, models.UploadStatusCompleted}},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$length"}}}},
+	}
+	cur, err := database.DB.Collection("uploads").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var row struct {
+		Total int64 `bson:"total"`
+	}
+	if cur.Next(ctx) {
+		if err := cur.Decode(&row); err != nil {
+			return 0, err
+		}
+	}
+	return row.Total, cur.Err()
+}
+
+// AdvanceUploadOffset ขยับ offset แบบ compare-and-set (กัน PATCH ซ้อนกัน)
+// คืน false ถ้า offset ใน DB ไม่ใช่ from แล้ว
+func AdvanceUploadOffset(ctx context.Context, id bson.ObjectID, from, to int64) (bool, error) {
+	res, err := database.DB.Collection("uploads").UpdateOne(ctx,
+		bson.M{"_id": id, "offset": from, "status": models.UploadStatusUploading},
+		bson.M{"$set": bson.M{"offset": to, "updated_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+func MarkUploadCompleted(ctx context.Context, id bson.ObjectID) error {
+	now := time.Now().UTC()
+	_, err := database.DB.Collection("uploads").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$set":   bson.M{"status": models.UploadStatusCompleted, "completed_at": now, "updated_at": now},
+			"$unset": bson.M{"expires_at": ""},
+		},
+	)
+	return err
+}
+
+func MarkUploadAborted(ctx context.Context, id bson.ObjectID) error {
+	_, err := database.DB.Collection("uploads").UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": models.UploadStatusAborted, "updated_at": time.Now().UTC()}},
+	)
+	return err
+}
+
+func ListUserUploads(ctx context.Context, userID bson.ObjectID) ([]models.Upload, error) {
+	cur, err := database.DB.Collection("uploads").Find(ctx,
+		bson.M{"user_id": userID, "status": bson.M{"$ne": models.UploadStatusAborted}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var uploads []models.Upload
+	if err := cur.All(ctx, &uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
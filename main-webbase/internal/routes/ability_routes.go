@@ -1,19 +1,12 @@
 package routes
 
-// import (
-// 	"github.com/gofiber/fiber/v2"
-// 	"main-webbase/internal/controllers"
-// 	"main-webbase/internal/repository"
-// 	"main-webbase/internal/services"
-// )
-
-// func SetupRoutesAbility(app *fiber.App) {
-// 	mRepo := repository.NewMembershipRepository()
-// 	pRepo := repository.NewPolicyRepository()
-
-// 	authzService := services.NewAuthzService(mRepo, pRepo)
-// 	h := controllers.NewAbilitiesHandler(authzService)
-
-// 	abilities := app.Group("/abilities")
-// 	abilities.Get("/:userId", h.GetAbilities)
-// }
+import (
+	"github.com/gofiber/fiber/v2"
+	"main-webbase/internal/controllers"
+)
+
+func SetupRoutesAbility(app *fiber.App) {
+	abilities := app.Group("/abilities")
+	abilities.Get("/me", controllers.GetMyAbilitiesHandler())
+	abilities.Get("/:userId", controllers.GetUserAbilitiesHandler())
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
+)
+
+func SetupRoutesAuthz(app *fiber.App) {
+	az := app.Group("/authz")
+	az.Get("/explain", middleware.RequireAction("system:admin", nil), controllers.ExplainAuthzHandler())
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"main-webbase/internal/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GET /admin/cache/metrics (root only)
+//
+//	curl -X GET "http://localhost:8000/admin/cache/metrics" -H "Authorization: Bearer <JWT>"
+func SetupRoutesCache(app *fiber.App) {
+	app.Get("/admin/cache/metrics", controllers.GetCacheMetricsHandler())
+}
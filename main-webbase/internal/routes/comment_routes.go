@@ -2,6 +2,7 @@ package routes
 
 import (
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 	"main-webbase/internal/repository"
 
 	"github.com/gofiber/fiber/v2"
@@ -34,7 +35,7 @@ func CommentRoutes(app *fiber.App, client *mongo.Client) {
 	// สร้างคอมเมนต์ใหม่ในโพสต์ ระบุด้วย postId
 	// Example Request:
 	//   POST http://localhost:8000/posts/66c62.../comments
-	posts.Post("/:postId/comments", h.Create)
+	posts.Post("/:postId/comments", middleware.RequireAction("comment:create", nil), h.Create)
 
 	// PUT /comments/:commentId
 	// อัปเดตคอมเมนต์ที่มี id ตรงกับ commentId
@@ -42,12 +43,12 @@ func CommentRoutes(app *fiber.App, client *mongo.Client) {
 	// Example Request:
 	//   PUT http://localhost:8000/comments/66e4d7b17a12f9dbf8123abc
 
-	app.Put("/comments/:commentId", h.Update)
+	app.Put("/comments/:commentId", middleware.RequireAction("comment:update", middleware.OrgFromComment("commentId")), h.Update)
 
 	// DELETE /comments/:commentId
 	// ลบคอมเมนต์ตาม id
-	// เฉพาะเจ้าของคอมเมนต์หรือ root เท่านั้นที่สามารถลบได้
+	// เจ้าของคอมเมนต์, root หรือผู้มี comment:moderate ของ org ที่โพสต์
 	// Example Request:
 	//   DELETE http://localhost:8000/comments/66e4d7b17a12f9dbf8123abc
-	app.Delete("/comments/:commentId", h.Delete)
+	app.Delete("/comments/:commentId", middleware.RequireAction("comment:delete", middleware.OrgFromComment("commentId")), h.Delete)
 }
@@ -4,13 +4,14 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 )
 
 func SetupRoutesEvent(app *fiber.App, client *mongo.Client) {
 	event := app.Group("/event")
 
 	// Event CRUD
-	event.Post("/", controllers.CreateEventHandler())
+	event.Post("/", middleware.RequireAction("event:create", middleware.OrgFromNodeIDField("NodeID")), controllers.CreateEventHandler())
 	event.Get("/", controllers.GetAllVisibleEventHandler())
 
 	// Event management (static path)
@@ -19,31 +20,31 @@ func SetupRoutesEvent(app *fiber.App, client *mongo.Client) {
 
 	// Dynamic event routes
 	event.Get("/:event_id", controllers.GetEventDetailHandler())
-	event.Patch("/:event_id", controllers.UpdateEventHandler())
-	event.Delete("/:event_id", controllers.DeleteEventHandler())
-	event.Post("/participate/:event_id", controllers.ParticipateEventWithNoFormHandler())
-	event.Patch("/activate/:event_id", controllers.ActivateEventHandler())
+	event.Patch("/:event_id", middleware.RequireAction("event:update", middleware.OrgFromEvent("event_id")), controllers.UpdateEventHandler())
+	event.Delete("/:event_id", middleware.RequireAction("event:delete", middleware.OrgFromEvent("event_id")), controllers.DeleteEventHandler())
+	event.Post("/participate/:event_id", middleware.RequireAction("event:participate", nil), controllers.ParticipateEventWithNoFormHandler())
+	event.Patch("/activate/:event_id", middleware.RequireAction("event:update", middleware.OrgFromEvent("event_id")), controllers.ActivateEventHandler())
 
 	// Event Q&A
-	event.Post("/:eventId/qa", controllers.CreateEventQAHandler(client))
+	event.Post("/:eventId/qa", middleware.RequireAction("event:participate", nil), controllers.CreateEventQAHandler(client))
 	event.Get("/:eventId/qa", controllers.ListEventQAHandler(client))
 
 	event.Get("/:eventId/participants", controllers.ListEventParticipantsHandler())
 
 	// Form Section
 	form := app.Group("/event/:eventId/form")
-	form.Post("/initialize", controllers.InitializeFormHandler())
-	form.Post("/disable", controllers.DisableFormHandler())
-	form.Post("/questions", controllers.CreateFormQuestionHandler())
+	form.Post("/initialize", middleware.RequireAction("event:manage", middleware.OrgFromEvent("eventId")), controllers.InitializeFormHandler())
+	form.Post("/disable", middleware.RequireAction("event:manage", middleware.OrgFromEvent("eventId")), controllers.DisableFormHandler())
+	form.Post("/questions", middleware.RequireAction("event:manage", middleware.OrgFromEvent("eventId")), controllers.CreateFormQuestionHandler())
 	form.Get("/questions", controllers.GetFormQuestionHandler())
-	form.Post("/answers", controllers.CreateUserAnswerHandler())
+	form.Post("/answers", middleware.RequireAction("event:participate", nil), controllers.CreateUserAnswerHandler())
 	form.Get("/matrix", controllers.GetAllUserAnswerandQuestionHandler())
 
 	// Participant Status
 	participant := event.Group("/participant")
-	participant.Put("/status", controllers.UpdateParticipantStatusHandler())
+	participant.Put("/status", middleware.RequireAction("event:manage", middleware.OrgFromEventField("event_id")), controllers.UpdateParticipantStatusHandler())
 	participant.Get("/mystatus/:eventId", controllers.GetMyParticipantStatusHandler())
 
 	// Q&A answer (global path to match mobile client)
-	event.Patch("/qa/:qaId/answer", controllers.AnswerEventQAHandler(client))
+	event.Patch("/qa/:qaId/answer", middleware.RequireAction("event:manage", middleware.OrgFromQA("qaId")), controllers.AnswerEventQAHandler(client))
 }
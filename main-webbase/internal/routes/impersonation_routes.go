@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
+)
+
+func SetupRoutesImpersonation(app *fiber.App) {
+	imp := app.Group("/impersonations")
+	imp.Post("/", middleware.RequireAction("system:admin", nil), controllers.CreateImpersonationHandler())
+	imp.Get("/", middleware.RequireAction("system:admin", nil), controllers.ListImpersonationsHandler())
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -10,6 +11,6 @@ import (
 func LikeRoutes(app *fiber.App, client *mongo.Client) {
 	post := app.Group("/likes")
 
-	post.Post("/", controllers.LikeUnlikeHandler(client))
+	post.Post("/", middleware.RequireAction("post:like", nil), controllers.LikeUnlikeHandler(client))
 
 }
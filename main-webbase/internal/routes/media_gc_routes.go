@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"main-webbase/config"
+	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Orphaned media GC (root only)
+//
+//	curl -X POST "http://localhost:8000/admin/media/gc?dry_run=true" -H "Authorization: Bearer <JWT>"
+//	curl -X GET  "http://localhost:8000/admin/media/gc/reports" -H "Authorization: Bearer <JWT>"
+func SetupRoutesMediaGC(app *fiber.App, cfg config.Config) {
+	h := controllers.NewMediaGCHandler(services.MediaGCOptionsFromConfig(cfg))
+
+	gc := app.Group("/admin/media/gc")
+	gc.Post("/", middleware.RequireAction("system:admin", nil), h.Run)
+	gc.Get("/reports", h.ListReports)
+	gc.Get("/reports/:id", h.GetReport)
+}
@@ -3,12 +3,32 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 )
 
 func SetupRoutesMembership(api fiber.Router) {
 
     memberships := api.Group("/memberships")
-    memberships.Post("/", controllers.CreateMembership())
+    memberships.Post("/", middleware.RequireAction("membership:assign", middleware.OrgFromBody("org_path")), controllers.CreateMembership())
     memberships.Get("/users", controllers.ListMembershipsWithUsers())
-    memberships.Patch("/:id", controllers.DeactivateMembership())
+    memberships.Get("/history", controllers.MembershipHistory())
+
+    // join requests
+    memberships.Post("/requests", middleware.RequireAction("membership:request", nil), controllers.CreateJoinRequestHandler())
+    memberships.Get("/requests", controllers.ListJoinRequestQueueHandler())
+    memberships.Get("/requests/mine", controllers.ListMyJoinRequestsHandler())
+    memberships.Post("/requests/:id/approve", middleware.RequireAction("membership:assign", middleware.OrgFromJoinRequest("id")), controllers.ApproveJoinRequestHandler())
+    memberships.Post("/requests/:id/reject", middleware.RequireAction("membership:assign", middleware.OrgFromJoinRequest("id")), controllers.RejectJoinRequestHandler())
+    memberships.Delete("/requests/:id", middleware.RequireAction("membership:request", nil), controllers.CancelJoinRequestHandler())
+
+    // invitations
+    memberships.Post("/invites", middleware.RequireAction("membership:assign", middleware.OrgFromBody("org_path")), controllers.CreateOrgInviteHandler())
+    memberships.Get("/invites", controllers.ListOrgInvitesHandler())
+    memberships.Delete("/invites/:id", middleware.RequireAction("membership:assign", middleware.OrgFromInvite("id")), controllers.RevokeOrgInviteHandler())
+    memberships.Post("/invites/:code/redeem", middleware.RequireAction("membership:request", nil), controllers.RedeemOrgInviteHandler())
+
+    // handover ทั้งคณะ
+    memberships.Post("/handover", middleware.RequireAction("membership:assign", middleware.OrgFromBody("org_path")), controllers.HandoverMembershipsHandler())
+    memberships.Get("/handovers", controllers.ListMembershipHandoversHandler())
+    memberships.Patch("/:id", middleware.RequireAction("membership:assign", middleware.OrgFromMembership("id")), controllers.DeactivateMembership())
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -10,5 +11,5 @@ import (
 func NotificationRoutes(app *fiber.App, client *mongo.Client) {
 	noti := app.Group("/notifications")
 	noti.Get("/", controllers.GetUnreadNotifications())
-	noti.Get("/:id", controllers.GetNotificationAndMarkRead())
+	noti.Get("/:id", middleware.DenyImpersonation(), controllers.GetNotificationAndMarkRead())
 }
@@ -2,14 +2,26 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"main-webbase/config"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 )
 
-func SetupRoutesOrg(app *fiber.App) {
+func SetupRoutesOrg(app *fiber.App, cfg config.Config) {
 
     org := app.Group("/org/units")
 
-    org.Post("/", controllers.CreateOrgUnitHandler())
+    org.Post("/", middleware.RequireAction("organize:create", middleware.OrgFromBody("parent_path")), controllers.CreateOrgUnitHandler())
     org.Get("/", controllers.ListOrgUnits())
     org.Get("/tree", controllers.GetOrgTree())
+    org.Get("/resolve", controllers.ResolveOrgPathHandler())
+    org.Post("/move", middleware.RequireAction("organize:create", middleware.OrgFromBody("org_path")), controllers.MoveOrgUnitHandler())
+    org.Post("/archive", middleware.RequireAction("organize:create", middleware.OrgFromBody("org_path")), controllers.ArchiveOrgUnitHandler())
+    org.Post("/restore", middleware.RequireAction("organize:create", middleware.OrgFromBody("org_path")), controllers.RestoreOrgUnitHandler())
+    org.Get("/export", controllers.ExportOrgTreeHandler())
+    org.Post("/import", middleware.RequireAction("organize:create", nil), controllers.ImportOrgTreeHandler())
+
+    // ต้องอยู่ท้ายสุด: /org/units/<org_path> (เช่น /org/units/fac/eng/smo)
+    org.Get("/*", controllers.GetOrgUnitProfileHandler())
+    org.Patch("/*", middleware.RequireAction("organize:create", middleware.OrgFromWildcard()), controllers.UpdateOrgUnitProfileHandler(cfg.UploadDir))
 }
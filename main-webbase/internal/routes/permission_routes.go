@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
+)
+
+func SetupRoutesPermission(app *fiber.App) {
+	permissions := app.Group("/permissions")
+	permissions.Get("/", controllers.ListPermissionsHandler())
+	permissions.Get("/roles", controllers.ListRoleTemplatesHandler())
+	permissions.Post("/roles", middleware.RequireAction("system:admin", nil), controllers.CreateRoleTemplateHandler())
+	permissions.Put("/roles/:name", middleware.RequireAction("system:admin", nil), controllers.UpdateRoleTemplateHandler())
+	permissions.Delete("/roles/:name", middleware.RequireAction("system:admin", nil), controllers.DeleteRoleTemplateHandler())
+}
@@ -3,12 +3,16 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 )
 
 func SetupRoutesPolicy(app fiber.Router) {
     policy := app.Group("/policies")
 
-    policy.Put("/", controllers.UpdatePolicyHandler())
+    policy.Put("/", middleware.RequireAction("policy:manage", middleware.OrgFromBody("org_path")), controllers.UpdatePolicyHandler())
+    policy.Post("/", middleware.RequireAction("policy:manage", middleware.OrgFromBody("org_prefix")), controllers.CreatePolicyRuleHandler())
     policy.Get("/", controllers.ListPolicies())
-    policy.Delete("/", controllers.DeletePolicyHandler())
+    policy.Delete("/", middleware.RequireAction("policy:manage", middleware.OrgFromQuery("org_prefix")), controllers.DeletePolicyHandler())
+    policy.Get("/:id/history", middleware.RequireAction("policy:manage", middleware.OrgFromPolicy("id")), controllers.PolicyHistoryHandler())
+    policy.Post("/:id/rollback", middleware.RequireAction("policy:manage", middleware.OrgFromPolicy("id")), controllers.RollbackPolicyHandler())
 }
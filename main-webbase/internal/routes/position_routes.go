@@ -3,11 +3,14 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 )
 
 func SetupRoutesPosition(app *fiber.App) {
 
     positions := app.Group("/positions")
-    positions.Post("/", controllers.CreatePosition())
+    positions.Post("/", middleware.RequireAction("position:manage", middleware.OrgFromBody("scope.org_path")), controllers.CreatePosition())
     positions.Get("/", controllers.ListPositions())
+    positions.Patch("/:id", middleware.RequireAction("position:manage", middleware.OrgFromPosition("id")), controllers.UpdatePositionHandler())
+    positions.Delete("/:id", middleware.RequireAction("position:manage", middleware.OrgFromPosition("id")), controllers.DeletePositionHandler())
 }
@@ -1,22 +1,29 @@
 package routes
 
 import (
+	"main-webbase/config"
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-func SetupRoutesPost(app *fiber.App, client *mongo.Client) {
+func SetupRoutesPost(app *fiber.App, client *mongo.Client, cfg config.Config) {
 
 	app.Get("/categories", controllers.GetCategories(client))
 	
 	posts := app.Group("/posts")
 	posts.Get("/", controllers.GetPostsVisibilityCursor(client))
 	posts.Get("/feed", controllers.FeedHandler(client))
+	posts.Get("/drafts", controllers.ListDraftPostsHandler(client))
 
-	posts.Post("/", controllers.CreatePostHandler(client))
+	posts.Post("/", middleware.RequireAction("post:create", nil), controllers.CreatePostHandler(client))
 	posts.Get("/:post_id", controllers.GetIndividualPostHandler(client))
-	posts.Put("/:post_id", controllers.UpdatePostHandler(client))
-	posts.Delete("/:post_id", controllers.DeletePostHandler(client))
+	posts.Put("/:post_id", middleware.RequireAction("post:update", middleware.OrgFromPost("post_id")), controllers.UpdatePostHandler(client, cfg.PostEditWindow))
+	posts.Get("/:post_id/revisions", middleware.RequireAction("post:update", middleware.OrgFromPost("post_id")), controllers.PostRevisionsHandler(client))
+	posts.Post("/:post_id/publish", middleware.RequireAction("post:update", middleware.OrgFromPost("post_id")), controllers.PublishPostHandler(client))
+	posts.Put("/:post_id/schedule", middleware.RequireAction("post:update", middleware.OrgFromPost("post_id")), controllers.SchedulePostHandler(client))
+	posts.Delete("/:post_id/schedule", middleware.RequireAction("post:update", middleware.OrgFromPost("post_id")), controllers.CancelScheduledPostHandler(client))
+	posts.Delete("/:post_id", middleware.RequireAction("post:delete", middleware.OrgFromPost("post_id")), controllers.DeletePostHandler(client))
 }
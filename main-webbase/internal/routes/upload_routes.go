@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"main-webbase/config"
+	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
+	"main-webbase/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Resumable video upload (tus-style)
+//
+//	curl -X POST  "http://localhost:8000/uploads" -H "Authorization: Bearer <JWT>" -H "Upload-Length: 10485760" -H "Upload-Metadata: filename Y2xpcC5tcDQ=,filetype dmlkZW8vbXA0"
+//	curl -I       "http://localhost:8000/uploads/<id>" -H "Authorization: Bearer <JWT>"
+//	curl -X PATCH "http://localhost:8000/uploads/<id>" -H "Authorization: Bearer <JWT>" -H "Upload-Offset: 0" -H "Content-Type: application/offset+octet-stream" --data-binary @chunk0
+//	curl -H "Range: bytes=0-1023" "http://localhost:8000/media/videos/<id>" -H "Authorization: Bearer <JWT>"
+func SetupRoutesUpload(app *fiber.App, cfg config.Config) {
+	h := controllers.NewUploadHandler(services.NewUploadStore(cfg))
+
+	uploads := app.Group("/uploads")
+	uploads.Post("/", middleware.RequireAction("profile:update", nil), h.Create)
+	uploads.Get("/quota", h.Quota)
+	uploads.Head("/:id", h.Head)
+	uploads.Get("/:id", h.Get)
+	uploads.Patch("/:id", middleware.RequireAction("profile:update", nil), h.Patch)
+	uploads.Delete("/:id", middleware.RequireAction("profile:update", nil), h.Delete)
+
+	app.Get("/media/videos/:id", h.StreamVideo)
+}
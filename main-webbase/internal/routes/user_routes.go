@@ -2,6 +2,7 @@ package routes
 
 import (
 	"main-webbase/internal/controllers"
+	"main-webbase/internal/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,9 +13,14 @@ func SetupRoutesUser(app *fiber.App) {
     user.Get("/myprofile", controllers.GetMyProfileHandler())
     user.Get("/profile/:id", controllers.GetUserProfileHandler())
     user.Get("/profile", controllers.GetUserProfileByQuery())
-	user.Post("/profile_update", controllers.UpdateMyProfileHandler())
+	user.Post("/profile_update", middleware.RequireAction("profile:update", nil), controllers.UpdateMyProfileHandler())
 	user.Get("/", controllers.GetAllUser())
 
+	// memberships + สิทธิ์การมองเห็น/โพสต์ในนาม
+	user.Get("/me/memberships", controllers.GetMyMembershipsHandler())
+	user.Get("/me/impersonations", controllers.MyImpersonationsHandler())
+	user.Get("/:id/memberships", controllers.GetUserMembershipsHandler())
+
 	// Query by field
 	user.Get("/id/:value", controllers.GetUserBy("id"))
 	user.Get("/firstname/:value", controllers.GetUserBy("firstname"))
@@ -26,6 +32,6 @@ func SetupRoutesUser(app *fiber.App) {
 	user.Get("/advisorid/:value", controllers.GetUserBy("advisorid"))
 
 	// Delete user
-	app.Delete("/users/:id", controllers.DeleteUser())
+	app.Delete("/users/:id", middleware.RequireAction("user:delete", nil), controllers.DeleteUser())
 
 }
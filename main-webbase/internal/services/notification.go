@@ -19,6 +19,12 @@ const (
 	NotiEventReminder    m.NotiType = "EVENT_REMINDER"
 	NotiQAAnswered       m.NotiType = "QA_ANSWERED"
 	NotiQAQuestion       m.NotiType = "QA_QUESTION"
+	NotiJoinRequested    m.NotiType = "JOIN_REQUESTED"
+	NotiJoinApproved     m.NotiType = "JOIN_APPROVED"
+	NotiJoinRejected     m.NotiType = "JOIN_REJECTED"
+	NotiInviteRedeemed   m.NotiType = "INVITE_REDEEMED"
+	NotiTermStarted      m.NotiType = "TERM_STARTED"
+	NotiTermEnded        m.NotiType = "TERM_ENDED"
 )
 
 func BuildTitleBody(t m.NotiType, p m.NotiParams) (title, body string, err error) {
@@ -64,6 +70,43 @@ func BuildTitleBody(t m.NotiType, p m.NotiParams) (title, body string, err error
 		}
 		return "Your event has a new question",
 			fmt.Sprintf("A new question was posted on %s event.", p.EventTitle), nil
+
+	case NotiJoinRequested:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "New join request",
+			fmt.Sprintf("Someone asked to join %s as %s.", p.OrgName, p.PositionKey), nil
+	case NotiJoinApproved:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "Join request approved 🎉",
+			fmt.Sprintf("You are now %s of %s.", p.PositionKey, p.OrgName), nil
+	case NotiJoinRejected:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "Join request declined",
+			fmt.Sprintf("Your request to join %s was not approved.", p.OrgName), nil
+	case NotiInviteRedeemed:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "Invitation used",
+			fmt.Sprintf("A new %s joined %s with your invitation.", p.PositionKey, p.OrgName), nil
+	case NotiTermStarted:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "New term started",
+			fmt.Sprintf("Your term as %s of %s has started.", p.PositionKey, p.OrgName), nil
+	case NotiTermEnded:
+		if p.OrgName == "" {
+			return "", "", errors.New("missing OrgName")
+		}
+		return "Term ended",
+			fmt.Sprintf("Your term as %s of %s has ended. Thank you!", p.PositionKey, p.OrgName), nil
 	}
 	return "", "", fmt.Errorf("unknown noti type: %s", t)
 }
@@ -2,62 +2,33 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"strings"
 	"errors"
 	"fmt"
+	"maps"
+	"sort"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
+	"main-webbase/database"
+	"main-webbase/dto"
+	"main-webbase/internal/accessctx"
+	"main-webbase/internal/authz"
 	"main-webbase/internal/models"
 	repo "main-webbase/internal/repository"
 )
 
 
+// CanManagePolicy ต้องมีสิทธิ์ policy:manage ครอบคลุม org_prefix ของ policy เป้าหมาย
 func CanManagePolicy(userPolicies []models.Policy, target *models.Policy) error {
-	partTrimmed := strings.Trim(target.OrgPrefix, "/")
-	segs := []string{}
-
-	if partTrimmed != "" {
-		segs = strings.Split(partTrimmed, "/")
-	}
-
-	ancestors := []string{"/"}
-	for i := 1; i <= len(segs); i++ {
-		ancestor := "/" + strings.Join(segs[:i], "/")
-		ancestors = append(ancestors, ancestor)
-	}
-	// ได้ ancestors = ["/", "/faculty", "/faculty/eng", "/faculty/eng/smo"] จาก "/faculty/eng/smo"
-	
-	for _, policy := range userPolicies {
-		if !policy.Enabled {
-			continue
-		}
-		hasAction := false
-		for _, act := range policy.Actions {
-			if act == "membership:assign" {
-				hasAction = true
-				break
-			}
-		}
-		if !hasAction {
-			continue
-		}
-
-		if policy.Scope == "exact" && policy.OrgPrefix == target.OrgPrefix {
-			return nil
-		}
-
-		if policy.Scope == "subtree" {
-			for _, anc := range ancestors {
-				if policy.OrgPrefix == anc {
-					return nil
-				}
-			}
-		}
+	if !authz.Authorize(authz.Subject{Policies: userPolicies}, "policy:manage", authz.Target{OrgPath: target.OrgPrefix}).Allowed {
+		return errors.New("no permission to manage this policy")
 	}
-	return errors.New("no permission to manage this policy")
+	return nil
 }
 
-
+// CanManageEvent ต้องมีสิทธิ์ event:manage ที่ org ของ event
 func CanManageEvent(ctx context.Context, userPolicies []models.Policy, EventID string) error {
 	eventID, err := bson.ObjectIDFromHex(EventID)
 	if err != nil {
@@ -73,34 +44,132 @@ func CanManageEvent(ctx context.Context, userPolicies []models.Policy, EventID s
 	if err != nil {
 		return fmt.Errorf("org_node not found: %w", err)
 	}
-	
-	for _, policy := range userPolicies {
-		if !policy.Enabled {
+
+	t := authz.Target{OrgPath: org_node.OrgPath, Attrs: map[string]string{"status": event.Status}}
+	if !authz.Authorize(authz.Subject{Policies: userPolicies}, "event:manage", t).Allowed {
+		return errors.New("no permission to manage this Event")
+	}
+	return nil
+}
+
+// CanManageOrg เช็คว่ามี policy ที่ให้ action นี้ครอบคลุม orgPath (exact ตรง path หรือ subtree จาก ancestor)
+func CanManageOrg(userPolicies []models.Policy, action string, orgPath string) error {
+	if orgPath == "" || !authz.Authorize(authz.Subject{Policies: userPolicies}, action, authz.Target{OrgPath: orgPath}).Allowed {
+		return errors.New("no permission to manage this org unit")
+	}
+	return nil
+}
+
+// ComputeAbilities action ที่ผู้ใช้ทำได้ต่อ org_path ตัดสินด้วย authz.Authorize เหมือนตอน enforce
+// (ImpliedBy, deny, conditions) กับทุก action แบบ org ใน registry
+// org ที่คิด: node ที่ active ซึ่ง policy แบบไม่มีเงื่อนไข (allow หรือ deny) ครอบคลุม — exact → org_prefix เดียว,
+// subtree → ทุก node ใต้ org_prefix; allow ที่มีเงื่อนไขไม่นับเพราะขึ้นกับตัวเป้าหมาย
+// action ที่เจ้าของทำได้ (OwnerAllowed) แยกไว้ใน OwnerAbilities: ระดับบนสุด = ใช้ได้กับ org ที่ไม่อยู่ในรายการ,
+// ใน org ที่อยู่ในรายการ = ที่ยังไม่ถูก deny และไม่ได้มาจาก policy อยู่แล้ว
+func ComputeAbilities(ctx context.Context, userID bson.ObjectID) (*dto.UserAbilitiesResponse, error) {
+	policies, err := MyUserPolicy(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := repo.GetUserMemberships(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := accessctx.LoadOrgTree(ctx, database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	s := authz.Subject{UserID: userID, Policies: policies}
+	candidates := map[string]bool{}
+	for _, m := range memberships {
+		if m.OrgPath == "/" {
+			// root ทำได้ทุกอย่างทุก org (เหมือน SubjectFromCtx)
+			s.IsRoot = true
+			for _, n := range tree.Nodes {
+				if n.Status != models.OrgStatusArchived {
+					candidates[n.OrgPath] = true
+				}
+			}
+			break
+		}
+	}
+	for _, p := range policies {
+		if !p.Enabled || len(p.Actions) == 0 || len(p.Conditions) > 0 {
 			continue
 		}
-		hasAction := false
-		for _, act := range policy.Actions {
-			if act == "event:create" {
-				hasAction = true
-				break
+		switch p.Scope {
+		case "exact":
+			if n, ok := tree.ByPath[p.OrgPrefix]; ok && n.Status == models.OrgStatusArchived {
+				continue
+			}
+			candidates[p.OrgPrefix] = true
+		case "subtree":
+			for _, n := range tree.Subtree(p.OrgPrefix) {
+				if n.Status != models.OrgStatusArchived {
+					candidates[n.OrgPath] = true
+				}
 			}
 		}
-		if !hasAction {
+	}
+
+	var orgActions []authz.Action
+	ownerDefault := map[string]bool{}
+	for _, a := range authz.Actions() {
+		if a.Kind != authz.KindOrg {
 			continue
 		}
-
-		if policy.Scope == "exact" && policy.OrgPrefix == org_node.OrgPath {
-			return nil
+		orgActions = append(orgActions, a)
+		if a.OwnerAllowed {
+			ownerDefault[a.Key] = true
 		}
+	}
 
-		if policy.Scope == "subtree" {
-			for _, anc := range org_node.Ancestors {
-				if policy.OrgPrefix == anc {
-					return nil
-				}
+	owners := []bson.ObjectID{userID}
+	byOrg := map[string]dto.AbilitiesResponse{}
+	paths := make([]string, 0, len(candidates))
+	for path := range candidates {
+		abilities, owned := map[string]bool{}, map[string]bool{}
+		for _, a := range orgActions {
+			d := authz.Authorize(s, a.Key, authz.Target{OrgPath: path, Owners: owners})
+			switch {
+			case !d.Allowed:
+			case d.Reason == authz.ReasonOwner:
+				owned[a.Key] = true
+			default:
+				abilities[a.Key] = true
 			}
 		}
+		// ไม่มีสิทธิ์จาก policy และสิทธิ์เจ้าของเหมือนค่าเริ่มต้น = เหมือน org ที่ไม่อยู่ในรายการ
+		if len(abilities) == 0 && maps.Equal(owned, ownerDefault) {
+			continue
+		}
+		byOrg[path] = dto.AbilitiesResponse{OrgPath: path, Abilities: abilities, OwnerAbilities: owned}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	resp := &dto.UserAbilitiesResponse{
+		UserID:         userID.Hex(),
+		OwnerAbilities: sortedKeys(ownerDefault),
+		Orgs:           make([]dto.AbilitiesResponse, 0, len(paths)),
 	}
+	h := sha256.New()
+	fmt.Fprintf(h, "owner=%s;", strings.Join(resp.OwnerAbilities, ","))
+	for _, path := range paths {
+		org := byOrg[path]
+		resp.Orgs = append(resp.Orgs, org)
+		fmt.Fprintf(h, "%s=%s|%s;", path, strings.Join(sortedKeys(org.Abilities), ","), strings.Join(sortedKeys(org.OwnerAbilities), ","))
+	}
+	resp.Version = `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+	return resp, nil
+}
 
-	return errors.New("no permission to manage this Event")
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/authz"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+var ErrExplainUserNotFound = errors.New("user not found")
+
+// ExplainAuthorization ตอบว่า user ทำ action ที่ orgPath ได้หรือไม่ พร้อม trace
+// subject สร้างแบบเดียวกับ RequireAction (MyUserPolicy + root = membership ที่ "/")
+// และตัดสินด้วย authz.Authorize ผ่าน authz.Explain
+func ExplainAuthorization(ctx context.Context, userID bson.ObjectID, action, orgPath string, attrs map[string]string) (*dto.AuthzExplainResponse, error) {
+	if n, err := repo.CountUsersByIDs(ctx, []bson.ObjectID{userID}); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrExplainUserNotFound
+	}
+
+	memberships, err := repo.GetUserMemberships(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	policies, err := MyUserPolicy(ctx, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	s := authz.Subject{UserID: userID, Policies: policies}
+	resp := &dto.AuthzExplainResponse{
+		UserID:      userID.Hex(),
+		Action:      action,
+		OrgPath:     orgPath,
+		Attrs:       attrs,
+		Memberships: []dto.AuthzExplainMembership{},
+	}
+	for _, m := range memberships {
+		if m.OrgPath == "/" {
+			s.IsRoot = true
+		}
+		em := dto.AuthzExplainMembership{
+			OrgPath:      m.OrgPath,
+			PositionKey:  m.PositionKey,
+			CoversTarget: orgPath == "" || utils.IsUnderOrgPath(orgPath, m.OrgPath),
+		}
+		// MyUserPolicy ย้าย OrgPrefix ของ policy มาไว้ที่ org ของ membership แล้ว
+		for _, p := range policies {
+			if p.PositionKey == m.PositionKey && p.OrgPrefix == m.OrgPath {
+				em.Policies++
+			}
+		}
+		resp.Memberships = append(resp.Memberships, em)
+	}
+	resp.Trace = authz.Explain(s, action, authz.Target{OrgPath: orgPath, Attrs: attrs})
+	return resp, nil
+}
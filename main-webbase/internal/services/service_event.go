@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -24,6 +25,20 @@ func CreateEventWithSchedules(body dto.EventRequestDTO, ctx context.Context) (dt
 		return dto.EventCreateResult{}, fmt.Errorf("invalid NodeID: %w", err)
 	}
 
+	// หน่วยงานที่ถูก archive สร้าง event ในนามไม่ได้
+	orgPaths := []string{body.OrgOfContent}
+	if body.PostedAs != nil {
+		orgPaths = append(orgPaths, body.PostedAs.OrgPath)
+	}
+	for _, p := range orgPaths {
+		if p == "" {
+			continue
+		}
+		if err := EnsureOrgActive(ctx, p); errors.Is(err, ErrOrgArchived) {
+			return dto.EventCreateResult{}, err
+		}
+	}
+
 	event := models.Event{
 		ID:               bson.NewObjectID(),
 		NodeID:           nodeID,
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var (
+	ErrImpersonationInvalid = errors.New("invalid impersonation request")
+	ErrImpersonationTarget  = errors.New("target user not found")
+)
+
+const (
+	impersonationDefaultTTL = 15 * time.Minute
+	impersonationMaxTTL     = 60 * time.Minute
+)
+
+// IssueImpersonationToken ออก token อายุสั้นที่ uid/sub = ผู้ใช้เป้าหมาย และ imp = root ผู้ขอ
+// jti = session id ใช้ผูก audit ของทุก request — middleware ImpersonationGuard บังคับ read-only
+func IssueImpersonationToken(ctx context.Context, admin bson.ObjectID, body dto.ImpersonationCreateDTO) (*dto.ImpersonationTokenResponse, error) {
+	target, err := bson.ObjectIDFromHex(body.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user_id", ErrImpersonationInvalid)
+	}
+	if target == admin {
+		return nil, fmt.Errorf("%w: cannot impersonate yourself", ErrImpersonationInvalid)
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	if body.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrImpersonationInvalid)
+	}
+	ttl := impersonationDefaultTTL
+	if body.TTLMinutes > 0 {
+		ttl = min(time.Duration(body.TTLMinutes)*time.Minute, impersonationMaxTTL)
+	}
+	if n, err := repo.CountUsersByIDs(ctx, []bson.ObjectID{target}); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrImpersonationTarget
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("missing JWT_SECRET")
+	}
+
+	now := time.Now().UTC()
+	s := models.ImpersonationSession{
+		ID:        bson.NewObjectID(),
+		AdminID:   admin,
+		TargetID:  target,
+		Reason:    body.Reason,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid": target.Hex(),
+		"sub": target.Hex(),
+		"imp": admin.Hex(),
+		"jti": s.ID.Hex(),
+		"iat": now.Unix(),
+		"exp": s.ExpiresAt.Unix(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.InsertImpersonationSession(ctx, &s); err != nil {
+		return nil, err
+	}
+	return &dto.ImpersonationTokenResponse{AccessToken: token, Session: s}, nil
+}
+
+// RecordImpersonatedRequest บันทึก request ที่ใช้ impersonation token
+func RecordImpersonatedRequest(ctx context.Context, a *models.ImpersonationAudit) error {
+	a.ID = bson.NewObjectID()
+	a.At = time.Now().UTC()
+	return repo.InsertImpersonationAudit(ctx, a)
+}
+
+// ImpersonationSessions session ล่าสุดพร้อม request ที่เกิดขึ้น — filter ตาม target (ผู้ใช้ดูของตัวเอง) หรือว่าง (root)
+func ImpersonationSessions(ctx context.Context, filter bson.M) ([]dto.ImpersonationSessionView, error) {
+	sessions, err := repo.ListImpersonationSessions(ctx, filter, 50)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]bson.ObjectID, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	audit, err := repo.ListImpersonationAudit(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	bySession := map[bson.ObjectID][]models.ImpersonationAudit{}
+	for _, a := range audit {
+		bySession[a.SessionID] = append(bySession[a.SessionID], a)
+	}
+
+	out := make([]dto.ImpersonationSessionView, 0, len(sessions))
+	for _, s := range sessions {
+		reqs := bySession[s.ID]
+		if reqs == nil {
+			reqs = []models.ImpersonationAudit{}
+		}
+		out = append(out, dto.ImpersonationSessionView{ImpersonationSession: s, Requests: reqs})
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"main-webbase/config"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+// MediaGCOptions ตั้งค่าการเก็บกวาดไฟล์ที่ไม่มีใครอ้างถึงใน upload dir
+type MediaGCOptions struct {
+	Dir     string
+	Grace   time.Duration
+	DryRun  bool
+	Trigger string
+}
+
+func MediaGCOptionsFromConfig(cfg config.Config) MediaGCOptions {
+	return MediaGCOptions{
+		Dir:     cfg.UploadDir,
+		Grace:   cfg.MediaGCGrace,
+		DryRun:  cfg.MediaGCDryRun,
+		Trigger: "schedule",
+	}
+}
+
+// กันไม่ให้ GC รันซ้อนกัน (ticker กับ admin สั่งรันพร้อมกัน)
+var mediaGCMu sync.Mutex
+
+// RunMediaGC สแกน upload dir แล้วลบไฟล์ที่ไม่มี post/event/comment/profile อ้างถึง
+// ต่อเนื่องนานกว่า grace period
+//
+//   - ไฟล์ที่ไม่ถูกอ้างถึงครั้งแรกจะถูกจดไว้ใน media_gc_candidates (first_seen_at)
+//   - ถ้ากลับมาถูกอ้างถึงก่อนครบ grace จะถูกถอดออกจาก candidate
+//   - ไฟล์ .part ของ resumable upload ที่ยังไม่หมดอายุจะไม่ถูกแตะ
+//   - DryRun: ไม่ลบไฟล์และไม่แก้ candidate แค่บันทึก report ว่าจะลบอะไรบ้าง
+func RunMediaGC(ctx context.Context, opts MediaGCOptions) (*models.MediaGCReport, error) {
+	mediaGCMu.Lock()
+	defer mediaGCMu.Unlock()
+
+	now := time.Now().UTC()
+	report := &models.MediaGCReport{
+		StartedAt:    now,
+		DryRun:       opts.DryRun,
+		Trigger:      opts.Trigger,
+		GraceSeconds: int64(opts.Grace / time.Second),
+		Pending:      []models.MediaGCFile{},
+		Deleted:      []models.MediaGCFile{},
+	}
+
+	if !opts.DryRun {
+		if n, err := repo.AbortExpiredUploads(ctx, now); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("abort expired uploads: %v", err))
+		} else if n > 0 {
+			log.Printf("[media-gc] aborted %d expired uploads", n)
+		}
+	}
+
+	entries, err := os.ReadDir(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read upload dir: %w", err)
+	}
+	refs, err := repo.ListReferencedMediaFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("collect media references: %w", err)
+	}
+	parts, err := repo.ListActiveUploadParts(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("list active uploads: %w", err)
+	}
+	candidates, err := repo.ListMediaGCCandidates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list gc candidates: %w", err)
+	}
+
+	var resolved []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		report.Scanned++
+
+		if _, ok := refs[name]; ok {
+			report.Referenced++
+			if _, was := candidates[name]; was {
+				resolved = append(resolved, name)
+			}
+			continue
+		}
+		if _, ok := parts[name]; ok {
+			report.InProgress++
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		cand, tracked := candidates[name]
+		if !tracked {
+			cand = models.MediaGCCandidate{FileName: name, FirstSeenAt: now}
+			if !opts.DryRun {
+				if err := repo.UpsertMediaGCCandidate(ctx, name, info.Size(), now); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+					continue
+				}
+			}
+		} else if !opts.DryRun {
+			_ = repo.UpsertMediaGCCandidate(ctx, name, info.Size(), now)
+		}
+
+		file := models.MediaGCFile{FileName: name, Size: info.Size(), FirstSeenAt: cand.FirstSeenAt}
+		if now.Sub(cand.FirstSeenAt) < opts.Grace {
+			report.Pending = append(report.Pending, file)
+			continue
+		}
+
+		// เช็คซ้ำก่อนลบ เผื่อไฟล์เพิ่งถูกแนบเข้า post ระหว่าง scan
+		if again, err := repo.IsMediaReferenced(ctx, name); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		} else if again {
+			report.Referenced++
+			resolved = append(resolved, name)
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := os.Remove(filepath.Join(opts.Dir, name)); err != nil && !os.IsNotExist(err) {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			_ = repo.MarkUploadFileDeleted(ctx, name, now)
+			resolved = append(resolved, name)
+		}
+		report.Deleted = append(report.Deleted, file)
+		report.FreedBytes += file.Size
+	}
+
+	// candidate ที่ไฟล์หายไปแล้ว (ลบมือ) ก็ไม่ต้องติดตามต่อ
+	onDisk := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		onDisk[e.Name()] = struct{}{}
+	}
+	for name := range candidates {
+		if _, ok := onDisk[name]; !ok {
+			resolved = append(resolved, name)
+		}
+	}
+
+	if !opts.DryRun {
+		if err := repo.DeleteMediaGCCandidates(ctx, resolved); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("cleanup candidates: %v", err))
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	if err := repo.InsertMediaGCReport(ctx, report); err != nil {
+		return report, fmt.Errorf("save gc report: %w", err)
+	}
+
+	log.Printf("[media-gc] dry_run=%t scanned=%d referenced=%d pending=%d deleted=%d freed=%dB errors=%d",
+		report.DryRun, report.Scanned, report.Referenced, len(report.Pending),
+		len(report.Deleted), report.FreedBytes, len(report.Errors))
+	return report, nil
+}
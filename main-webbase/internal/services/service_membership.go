@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var ErrMembershipInvalid = errors.New("invalid membership request")
+var ErrMembershipNoPermission = errors.New("no permission to assign memberships in this org unit")
+var ErrMembershipNotFound = errors.New("membership not found")
+var ErrMembershipExists = errors.New("user already holds this position in this org unit")
+var ErrPositionOutOfScope = errors.New("position cannot be used in this org unit")
+var ErrPositionExclusive = errors.New("position is exclusive per org unit and already held")
+
+// MembershipConflictError ตำแหน่ง exclusive_per_org มีคนดำรงอยู่แล้ว
+// ส่ง end_previous=true มาใหม่เพื่อปิดวาระคนเดิมแล้วแต่งตั้งคนใหม่
+type MembershipConflictError struct {
+	OrgPath     string
+	PositionKey string
+	Holders     []models.Membership
+}
+
+func (e *MembershipConflictError) Error() string {
+	return fmt.Sprintf("position %q at %s is exclusive per org unit and already held", e.PositionKey, e.OrgPath)
+}
+
+func (e *MembershipConflictError) Unwrap() error { return ErrPositionExclusive }
+
+// AssignMembership แต่งตั้ง user เข้าตำแหน่งที่ org_path ผ่าน policy membership:assign ของผู้ทำ
+// และบังคับ position.scope / position.constraints.exclusive_per_org
+func AssignMembership(ctx context.Context, userPolicies []models.Policy, req models.MembershipRequestDTO) (*models.Membership, error) {
+	req.OrgPath = strings.TrimSpace(req.OrgPath)
+	req.PositionKey = strings.TrimSpace(req.PositionKey)
+	if req.OrgPath == "" || req.PositionKey == "" {
+		return nil, fmt.Errorf("%w: org_path and position_key are required", ErrMembershipInvalid)
+	}
+	userID, err := bson.ObjectIDFromHex(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user_id", ErrMembershipInvalid)
+	}
+	if req.TermEnd != nil {
+		if !req.TermEnd.After(time.Now()) {
+			return nil, fmt.Errorf("%w: term_end must be in the future", ErrMembershipInvalid)
+		}
+		if req.JoinedAt != nil && !req.TermEnd.After(*req.JoinedAt) {
+			return nil, fmt.Errorf("%w: term_end must be after joined_at", ErrMembershipInvalid)
+		}
+	}
+
+	if err := CanManageOrg(userPolicies, "membership:assign", req.OrgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+
+	node, err := repo.FindByOrgPath(ctx, req.OrgPath)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, ErrOrgUnitNotFound
+	}
+	if node.Status == models.OrgStatusArchived {
+		return nil, ErrOrgArchived
+	}
+
+	position, err := repo.FindPositionInScope(ctx, req.PositionKey, req.OrgPath)
+	if err != nil {
+		return nil, err
+	}
+	if position == nil {
+		exists, err := repo.PositionKeyExists(ctx, req.PositionKey)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %q is not scoped to %s", ErrPositionOutOfScope, req.PositionKey, req.OrgPath)
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPositionNotFound, req.PositionKey)
+	}
+	if err := ensurePositionOpen(position); err != nil {
+		return nil, err
+	}
+	d, err := delegationFor(ctx, userPolicies, "membership:assign", req.OrgPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.check(position); err != nil {
+		return nil, err
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var ended []models.Membership
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		// guard ทำให้การแต่งตั้งตำแหน่งเดียวกันพร้อมกันต้องเรียงกัน ไม่งั้นสอง transaction เห็น holders ว่างทั้งคู่
+		if err := repo.TouchPositionGuard(tx, req.OrgPath, req.PositionKey); err != nil {
+			return nil, err
+		}
+		holders, err := repo.FindActiveHolders(tx, req.OrgPath, req.PositionKey)
+		if err != nil {
+			return nil, err
+		}
+		others := make([]models.Membership, 0, len(holders))
+		for _, h := range holders {
+			if h.UserID == userID {
+				return nil, ErrMembershipExists
+			}
+			others = append(others, h)
+		}
+
+		if position.Constraints.ExclusivePerOrg && len(others) > 0 {
+			if !req.EndPrevious {
+				return nil, &MembershipConflictError{OrgPath: req.OrgPath, PositionKey: req.PositionKey, Holders: others}
+			}
+			ids := make([]bson.ObjectID, 0, len(others))
+			for _, h := range others {
+				ids = append(ids, h.ID)
+			}
+			if err := repo.DeactivateMembershipsByID(tx, ids, models.MembershipEndReplaced); err != nil {
+				return nil, err
+			}
+			ended = others
+		}
+
+		return nil, repo.InsertMembership(tx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []bson.ObjectID{userID}
+	for _, h := range ended {
+		changed = append(changed, h.UserID)
+	}
+	changebus.MembershipsChanged(changed...)
+
+	holders, err := repo.FindActiveHolders(ctx, req.OrgPath, req.PositionKey)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holders {
+		if holders[i].UserID == userID {
+			return &holders[i], nil
+		}
+	}
+	return nil, ErrMembershipNotFound
+}
+
+// DeactivateMembership ปิด membership โดยผู้ทำต้องมี membership:assign ครอบคลุม org ของ membership นั้น
+func DeactivateMembership(ctx context.Context, userPolicies []models.Policy, id bson.ObjectID) (*models.Membership, error) {
+	m, err := repo.FindMembershipByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMembershipNotFound
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", m.OrgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+	if err := CanDelegatePosition(ctx, userPolicies, "membership:assign", m.OrgPath, m.PositionKey); err != nil && !errors.Is(err, ErrPositionNotFound) {
+		return nil, err
+	}
+
+	if m.Active {
+		if err := repo.DeactivateMembershipsByID(ctx, []bson.ObjectID{m.ID}, models.MembershipEndDeactivated); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		m.Active = false
+		m.EndedAt = &now
+		m.EndReason = models.MembershipEndDeactivated
+		changebus.MembershipsChanged(m.UserID)
+	}
+	return m, nil
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/dto"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var handoverOrder = map[string]int{"end": 0, "continue": 1, "start": 2}
+
+var ErrHandoverStale = errors.New("holders changed since the handover was planned, preview it again")
+
+// handoverHolders membership ที่อยู่ในวาระของตำแหน่งในขอบเขตการส่งมอบ (คณะเดิม)
+func handoverHolders(ms []models.Membership, positions map[string]*models.Position, now time.Time) []models.Membership {
+	out := []models.Membership{}
+	for _, m := range ms {
+		if _, ok := positions[m.PositionKey]; !ok || !m.Active {
+			continue
+		}
+		if m.TermEnd != nil && !m.TermEnd.After(now) {
+			continue // หมดวาระแล้ว รอ job ปิด
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// HandoverMemberships ส่งมอบตำแหน่งทั้งชุดของ org_path:
+// คณะเดิม (membership ที่อยู่ในวาระของตำแหน่งที่เกี่ยวข้อง) เทียบกับรายชื่อใหม่ → end / continue / start
+// apply=false คืนแค่ plan, apply=true ทำทั้งหมดใน transaction เดียวพร้อมบันทึก audit แล้วแจ้งเตือนทุกคนที่เกี่ยวข้อง
+func HandoverMemberships(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, body dto.HandoverDTO, apply bool) (*dto.HandoverPlan, error) {
+	orgPath := strings.TrimSpace(body.OrgPath)
+	if orgPath == "" {
+		return nil, fmt.Errorf("%w: org_path is required", ErrMembershipInvalid)
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", orgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+	if len(body.Assignments) == 0 && len(body.Positions) == 0 {
+		return nil, fmt.Errorf("%w: assignments or positions are required", ErrMembershipInvalid)
+	}
+
+	now := time.Now()
+	joinedAt := now
+	if body.JoinedAt != nil {
+		joinedAt = *body.JoinedAt
+	}
+	if body.TermEnd != nil && (!body.TermEnd.After(now) || !body.TermEnd.After(joinedAt)) {
+		return nil, fmt.Errorf("%w: term_end must be in the future and after joined_at", ErrMembershipInvalid)
+	}
+
+	// ---- ตำแหน่งที่อยู่ในขอบเขตการส่งมอบ (rank ต้องต่ำกว่าผู้ทำ) ----
+	d, err := delegationFor(ctx, userPolicies, "membership:assign", orgPath)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[string]*models.Position{}
+	addPosition := func(key string) error {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return fmt.Errorf("%w: position_key is required", ErrMembershipInvalid)
+		}
+		if _, ok := positions[key]; ok {
+			return nil
+		}
+		_, p, err := validatePositionTarget(ctx, orgPath, key)
+		if err != nil {
+			return err
+		}
+		if err := d.check(p); err != nil {
+			return err
+		}
+		positions[key] = p
+		return nil
+	}
+	for _, key := range body.Positions {
+		if err := addPosition(key); err != nil {
+			return nil, err
+		}
+	}
+
+	// ---- คณะใหม่ ----
+	type slot struct {
+		user bson.ObjectID
+		pos  string
+	}
+	incoming := map[slot]struct{}{}
+	perPosition := map[string]int{}
+	userIDs := []bson.ObjectID{}
+	for _, a := range body.Assignments {
+		uid, err := bson.ObjectIDFromHex(strings.TrimSpace(a.UserID))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid user_id %q", ErrMembershipInvalid, a.UserID)
+		}
+		if err := addPosition(a.PositionKey); err != nil {
+			return nil, err
+		}
+		if err := ensurePositionOpen(positions[strings.TrimSpace(a.PositionKey)]); err != nil {
+			return nil, err
+		}
+		s := slot{uid, strings.TrimSpace(a.PositionKey)}
+		if _, dup := incoming[s]; dup {
+			continue
+		}
+		incoming[s] = struct{}{}
+		perPosition[s.pos]++
+		userIDs = append(userIDs, uid)
+	}
+	for key, n := range perPosition {
+		if positions[key].Constraints.ExclusivePerOrg && n > 1 {
+			return nil, fmt.Errorf("%w: position %q is exclusive per org unit but has %d incoming holders", ErrMembershipInvalid, key, n)
+		}
+	}
+	if len(userIDs) > 0 {
+		found, err := repo.CountUsersByIDs(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		if distinct := countDistinct(userIDs); found != int64(distinct) {
+			return nil, fmt.Errorf("%w: %d of %d users not found", ErrMembershipInvalid, int64(distinct)-found, distinct)
+		}
+	}
+
+	// ---- คณะเดิม ----
+	current, err := repo.FindMembershipsByOrgPath(ctx, orgPath)
+	if err != nil {
+		return nil, err
+	}
+	items := []models.HandoverItem{}
+	held := map[slot]struct{}{}
+	for _, m := range handoverHolders(current, positions, now) {
+		id := m.ID
+		s := slot{m.UserID, m.PositionKey}
+		action := "end"
+		if _, stay := incoming[s]; stay {
+			action = "continue"
+			held[s] = struct{}{}
+		}
+		items = append(items, models.HandoverItem{Action: action, UserID: m.UserID, PositionKey: m.PositionKey, MembershipID: &id})
+	}
+	for s := range incoming {
+		if _, ok := held[s]; ok {
+			continue
+		}
+		items = append(items, models.HandoverItem{Action: "start", UserID: s.user, PositionKey: s.pos})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if handoverOrder[a.Action] != handoverOrder[b.Action] {
+			return handoverOrder[a.Action] < handoverOrder[b.Action]
+		}
+		if a.PositionKey != b.PositionKey {
+			return a.PositionKey < b.PositionKey
+		}
+		return a.UserID.Hex() < b.UserID.Hex()
+	})
+
+	plan := &dto.HandoverPlan{OrgPath: orgPath, Items: make([]dto.HandoverPlanItem, 0, len(items)), Summary: map[string]int{}}
+	for _, it := range items {
+		pi := dto.HandoverPlanItem{Action: it.Action, UserID: it.UserID.Hex(), PositionKey: it.PositionKey}
+		if it.MembershipID != nil {
+			pi.MembershipID = it.MembershipID.Hex()
+		}
+		plan.Items = append(plan.Items, pi)
+		plan.Summary[it.Action]++
+	}
+	if !apply {
+		return plan, nil
+	}
+
+	// ---- apply ----
+	handover := &models.MembershipHandover{
+		ID:          bson.NewObjectID(),
+		OrgPath:     orgPath,
+		PerformedBy: actor,
+		PerformedAt: now,
+		JoinedAt:    joinedAt,
+		TermEnd:     body.TermEnd,
+		Note:        strings.TrimSpace(body.Note),
+		Items:       items,
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	keys := make([]string, 0, len(positions))
+	for key := range positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	planned := map[bson.ObjectID]struct{}{}
+	for _, it := range items {
+		if it.MembershipID != nil {
+			planned[*it.MembershipID] = struct{}{}
+		}
+	}
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		// guard ทุกตำแหน่งในแผนก่อน แล้วอ่านคณะเดิมใหม่: ถ้ามีการแต่งตั้ง/ปิดวาระแทรกเข้ามาหลังวางแผน ให้ล้มแทนการเขียนทับ
+		for _, key := range keys {
+			if err := repo.TouchPositionGuard(tx, orgPath, key); err != nil {
+				return nil, err
+			}
+		}
+		latest, err := repo.FindMembershipsByOrgPath(tx, orgPath)
+		if err != nil {
+			return nil, err
+		}
+		holders := handoverHolders(latest, positions, now)
+		if len(holders) != len(planned) {
+			return nil, ErrHandoverStale
+		}
+		for _, m := range holders {
+			if _, ok := planned[m.ID]; !ok {
+				return nil, ErrHandoverStale
+			}
+		}
+
+		ended := []bson.ObjectID{}
+		for _, it := range items {
+			if it.Action == "end" {
+				ended = append(ended, *it.MembershipID)
+			}
+		}
+		if err := repo.DeactivateMembershipsByID(tx, ended, models.MembershipEndHandover); err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			switch it.Action {
+			case "continue":
+				if body.TermEnd != nil {
+					if err := repo.SetMembershipTermEnd(tx, *it.MembershipID, body.TermEnd); err != nil {
+						return nil, err
+					}
+				}
+			case "start":
+				if err := repo.InsertMembership(tx, models.MembershipRequestDTO{
+					UserID:      it.UserID.Hex(),
+					OrgPath:     orgPath,
+					PositionKey: it.PositionKey,
+					JoinedAt:    &joinedAt,
+					TermEnd:     body.TermEnd,
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, repo.InsertMembershipHandover(tx, handover)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	affected := make([]bson.ObjectID, 0, len(items))
+	for _, it := range items {
+		affected = append(affected, it.UserID)
+		switch it.Action {
+		case "end":
+			notifyJoin(ctx, it.UserID, NotiTermEnded, "membership_handover", handover.ID, orgPath, it.PositionKey)
+		case "start":
+			notifyJoin(ctx, it.UserID, NotiTermStarted, "membership_handover", handover.ID, orgPath, it.PositionKey)
+		}
+	}
+	changebus.MembershipsChanged(affected...)
+
+	plan.Applied = true
+	plan.HandoverID = handover.ID.Hex()
+	return plan, nil
+}
+
+func ListMembershipHandovers(ctx context.Context, userPolicies []models.Policy, orgPath string) ([]models.MembershipHandover, error) {
+	orgPath = strings.TrimSpace(orgPath)
+	if orgPath == "" {
+		return nil, fmt.Errorf("%w: org_path is required", ErrMembershipInvalid)
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", orgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+	return repo.ListMembershipHandovers(ctx, orgPath)
+}
+
+func countDistinct(ids []bson.ObjectID) int {
+	seen := make(map[bson.ObjectID]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+	return len(seen)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+// ExpireMembershipTerms job ที่ปิดวาระที่เลย term_end และล้าง cache สิทธิ์ของผู้ใช้ที่เกี่ยวข้อง
+func ExpireMembershipTerms(ctx context.Context) (int, error) {
+	users, err := repo.ExpireMembershipTerms(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if len(users) > 0 {
+		changebus.MembershipsChanged(users...)
+	}
+	return len(users), nil
+}
+
+// MembershipHistoryQuery ต้องระบุ user_id หรือ org_path อย่างน้อยหนึ่งอย่าง
+// AsOf != nil → เฉพาะวาระที่ดำรงอยู่ ณ เวลานั้น
+type MembershipHistoryQuery struct {
+	UserID  *bson.ObjectID
+	OrgPath string
+	AsOf    *time.Time
+}
+
+func MembershipHistory(ctx context.Context, q MembershipHistoryQuery) ([]models.Membership, error) {
+	q.OrgPath = strings.TrimSpace(q.OrgPath)
+	if q.UserID == nil && q.OrgPath == "" {
+		return nil, fmt.Errorf("%w: user_id or org_path is required", ErrMembershipInvalid)
+	}
+
+	filter := bson.M{}
+	if q.AsOf != nil {
+		filter = utils.MembershipAsOfFilter(*q.AsOf)
+	}
+	if q.UserID != nil {
+		filter["user_id"] = *q.UserID
+	}
+	if q.OrgPath != "" {
+		filter["org_path"] = q.OrgPath
+	}
+	return repo.ListMembershipHistory(ctx, filter)
+}
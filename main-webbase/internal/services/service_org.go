@@ -10,9 +10,12 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 
 	"main-webbase/database"
+	"main-webbase/internal/accessctx"
+	"main-webbase/internal/changebus"
 	"main-webbase/dto"
 	"main-webbase/internal/models"
 	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
 )
 
 func AllUserOrg(userID bson.ObjectID) ([]string, error) {
@@ -21,7 +24,9 @@ func AllUserOrg(userID bson.ObjectID) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := collection_membership.Find(ctx, bson.M{"user_id": userID, "active": true})
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["user_id"] = userID
+	cursor, err := collection_membership.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +92,9 @@ func CreateOrgUnit(body dto.OrgUnitDTO, ctx context.Context) (*models.OrgUnitNod
 		if parentNode == nil {
 			return nil, errors.New("parent path not found")
 		}
+		if parentNode.Status == models.OrgStatusArchived {
+			return nil, ErrOrgArchived
+		}
 	}
 
 	duplicateNode, err := repo.FindByOrgPath(ctx, orgpath)
@@ -141,15 +149,17 @@ func CreateOrgUnit(body dto.OrgUnitDTO, ctx context.Context) (*models.OrgUnitNod
 	if err := repo.NodeCreate(ctx, *node); err != nil {
 		return nil, errors.New("insert to DB failed")
 	}
+	changebus.OrgUnitsChanged()
 
 	return node, nil
 }
 
 func BuildOrgTree(ctx context.Context, query dto.OrgUnitTreeQuery) ([]*dto.OrgUnitTree, error) {
-	orgUnits, err := repo.FindByPrefix(ctx, query.Start)
+	tree, err := accessctx.LoadOrgTree(ctx, database.DB)
 	if err != nil {
 		return nil, err
 	}
+	orgUnits := tree.Subtree(query.Start)
 
 	if len(orgUnits) == 0 {
 		return []*dto.OrgUnitTree{}, nil
@@ -159,11 +169,15 @@ func BuildOrgTree(ctx context.Context, query dto.OrgUnitTreeQuery) ([]*dto.OrgUn
 	links := map[string]string{}
 
 	for _, orgunit := range orgUnits {
+		if orgunit.Status == models.OrgStatusArchived && !query.IncludeArchived {
+			continue
+		}
 		node := &dto.OrgUnitTree{
 			OrgPath: 	orgunit.OrgPath,
 			Type:    	orgunit.Type,
 			Label:   	orgunit.Name,
 			ShortName:	orgunit.ShortName,
+			Status:     orgunit.Status,
 			Children:   []*dto.OrgUnitTree{},
 			Sort:       orgunit.Depth,
 		}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/internal/authz"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+var ErrOrgArchived = errors.New("org unit is archived")
+var ErrOrgNotArchived = errors.New("org unit is not archived")
+var ErrOrgParentArchived = errors.New("an ancestor of this org unit is archived; restore it first")
+
+// CanManageOrgFromAncestor ต้องมี policy แบบ subtree ที่ ancestor (ไม่นับตัว unit เอง)
+// คนที่อยู่ใน unit นั้นจึง archive หน่วยงานของตัวเองไม่ได้ และ deny ที่ครอบคลุม unit ชนะเสมอ
+func CanManageOrgFromAncestor(userPolicies []models.Policy, action string, orgPath string) error {
+	if authz.DenyingPolicy(userPolicies, action, authz.Target{OrgPath: orgPath}) != nil {
+		return ErrOrgNoPermission
+	}
+	for _, anc := range utils.OrgAncestors(orgPath) {
+		for _, policy := range userPolicies {
+			if !policy.Enabled || policy.IsDeny() || len(policy.Conditions) > 0 || policy.Scope != "subtree" || policy.OrgPrefix != anc {
+				continue
+			}
+			for _, act := range policy.Actions {
+				if act == action {
+					return nil
+				}
+			}
+		}
+	}
+	return ErrOrgNoPermission
+}
+
+// EnsureOrgActive ใช้กันการสร้าง content/สมาชิกใหม่ภายใต้หน่วยงานที่ถูก archive
+func EnsureOrgActive(ctx context.Context, orgPath string) error {
+	node, err := repo.FindByOrgPath(ctx, orgPath)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return ErrOrgUnitNotFound
+	}
+	if node.Status != models.OrgStatusActive {
+		return ErrOrgArchived
+	}
+	return nil
+}
+
+// ArchiveOrgUnit ปิดหน่วยงานและทุกหน่วยงานลูก: membership/policy ถูกปิด, post ถูกซ่อน,
+// event ถูกตั้ง inactive ทั้งหมดใน transaction เดียว และจำไว้ใน org_archives เพื่อ restore
+func ArchiveOrgUnit(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, orgPath, reason string) (*models.OrgArchive, error) {
+	orgPath = strings.TrimRight(strings.TrimSpace(orgPath), "/")
+	if orgPath == "" {
+		return nil, fmt.Errorf("%w: org_path is required and cannot be root", ErrOrgMoveInvalid)
+	}
+
+	node, err := repo.FindByOrgPath(ctx, orgPath)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, ErrOrgUnitNotFound
+	}
+	if node.Status == models.OrgStatusArchived {
+		return nil, ErrOrgArchived
+	}
+	if err := CanManageOrgFromAncestor(userPolicies, "organize:create", orgPath); err != nil {
+		return nil, err
+	}
+
+	archive := models.OrgArchive{
+		ID:         bson.NewObjectID(),
+		OrgPath:    orgPath,
+		NodeID:     node.ID,
+		ArchivedBy: actor,
+		Reason:     strings.TrimSpace(reason),
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		now := time.Now().UTC()
+		counts, err := archiveOrgSubtree(tx, actor, orgPath, archive.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		archive.ArchivedAt = now
+		archive.Counts = counts
+		return nil, repo.InsertOrgArchive(tx, archive)
+	})
+	if err != nil {
+		return nil, err
+	}
+	changebus.OrgUnitsChanged()
+	changebus.MembershipsChanged()
+	changebus.PoliciesChanged()
+	return &archive, nil
+}
+
+// archiveOrgSubtree repo.ArchiveOrgSubtree + บันทึก version ของ policy ที่ถูกปิด (ต้องอยู่ใน transaction)
+func archiveOrgSubtree(tx context.Context, actor bson.ObjectID, orgPath string, ref bson.ObjectID, now time.Time) (map[string]int64, error) {
+	policies, err := repo.ListPoliciesUnder(tx, orgPath)
+	if err != nil {
+		return nil, err
+	}
+	var counts map[string]int64
+	err = versionBulkPolicyWrite(tx, models.PolicyOpArchive, actor, policies, func() error {
+		counts, err = repo.ArchiveOrgSubtree(tx, orgPath, ref, now)
+		return err
+	})
+	return counts, err
+}
+
+// restoreOrgSubtree repo.RestoreOrgSubtree + บันทึก version ของ policy ที่ถูกเปิดคืน (ต้องอยู่ใน transaction)
+func restoreOrgSubtree(tx context.Context, actor bson.ObjectID, ref bson.ObjectID, now time.Time) (map[string]int64, error) {
+	policies, err := repo.ListPoliciesByArchiveRef(tx, ref)
+	if err != nil {
+		return nil, err
+	}
+	var counts map[string]int64
+	err = versionBulkPolicyWrite(tx, models.PolicyOpRestore, actor, policies, func() error {
+		counts, err = repo.RestoreOrgSubtree(tx, ref, now)
+		return err
+	})
+	return counts, err
+}
+
+// RestoreOrgUnit คืนค่าทุกอย่างที่ archive ครั้งล่าสุดของ path นี้ปิดไป
+func RestoreOrgUnit(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, orgPath string) (*models.OrgArchive, error) {
+	orgPath = strings.TrimRight(strings.TrimSpace(orgPath), "/")
+	if orgPath == "" {
+		return nil, fmt.Errorf("%w: org_path is required and cannot be root", ErrOrgMoveInvalid)
+	}
+	if err := CanManageOrgFromAncestor(userPolicies, "organize:create", orgPath); err != nil {
+		return nil, err
+	}
+
+	archive, err := repo.FindOpenOrgArchive(ctx, orgPath)
+	if err != nil {
+		return nil, err
+	}
+	if archive == nil {
+		return nil, ErrOrgNotArchived
+	}
+	if anc, err := repo.FindArchivedAncestor(ctx, orgPath); err != nil {
+		return nil, err
+	} else if anc != nil {
+		return nil, ErrOrgParentArchived
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		now := time.Now().UTC()
+		counts, err := restoreOrgSubtree(tx, actor, archive.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := repo.MarkOrgArchiveRestored(tx, archive.ID, actor, now); err != nil {
+			return nil, err
+		}
+		archive.Counts = counts
+		archive.RestoredAt = &now
+		archive.RestoredBy = &actor
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	changebus.OrgUnitsChanged()
+	changebus.MembershipsChanged()
+	changebus.PoliciesChanged()
+	return archive, nil
+}
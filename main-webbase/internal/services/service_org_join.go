@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+var ErrJoinRequestNotFound = errors.New("join request not found")
+var ErrJoinRequestExists = errors.New("a pending join request already exists for this position")
+var ErrJoinRequestClosed = errors.New("join request is no longer pending")
+var ErrInviteNotFound = errors.New("invitation not found")
+var ErrInviteUnavailable = errors.New("invitation is expired, revoked, fully used or already used by you")
+
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+// validateJoinTarget เช็คว่า org ยัง active และ position ใช้ได้ที่ org นี้และยังรับคนใหม่ (ไม่ deprecated)
+func validateJoinTarget(ctx context.Context, orgPath, positionKey string) (*models.OrgUnitNode, error) {
+	node, position, err := validatePositionTarget(ctx, orgPath, positionKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePositionOpen(position); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// validatePositionTarget เหมือน validateJoinTarget แต่ไม่สนสถานะตำแหน่ง (ใช้ตอนปิดวาระคนเดิม)
+func validatePositionTarget(ctx context.Context, orgPath, positionKey string) (*models.OrgUnitNode, *models.Position, error) {
+	if orgPath == "" || positionKey == "" {
+		return nil, nil, fmt.Errorf("%w: org_path and position_key are required", ErrMembershipInvalid)
+	}
+	node, err := repo.FindByOrgPath(ctx, orgPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node == nil {
+		return nil, nil, ErrOrgUnitNotFound
+	}
+	if node.Status == models.OrgStatusArchived {
+		return nil, nil, ErrOrgArchived
+	}
+	position, err := repo.FindPositionInScope(ctx, positionKey, orgPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if position == nil {
+		return nil, nil, fmt.Errorf("%w: %q is not scoped to %s", ErrPositionOutOfScope, positionKey, orgPath)
+	}
+	return node, position, nil
+}
+
+func orgNameOf(ctx context.Context, orgPath string) string {
+	if node, err := repo.FindByOrgPath(ctx, orgPath); err == nil && node != nil && node.Name != "" {
+		return node.Name
+	}
+	return orgPath
+}
+
+// notifyJoin แจ้งเตือนแบบ best-effort: ล้มเหลวแค่ log ไม่ทำให้ flow หลักพัง
+func notifyJoin(ctx context.Context, userID bson.ObjectID, typ models.NotiType, entity string, refID bson.ObjectID, orgPath, positionKey string) {
+	err := NotifyOne(ctx, database.DB.Collection("notification"), userID, typ,
+		models.Ref{Entity: entity, ID: refID},
+		models.NotiParams{OrgName: orgNameOf(ctx, orgPath), PositionKey: positionKey})
+	if err != nil {
+		log.Printf("[org-join] notify %s to %s: %v", typ, userID.Hex(), err)
+	}
+}
+
+// ---------- Join requests ----------
+
+func CreateJoinRequest(ctx context.Context, userID bson.ObjectID, body dto.JoinRequestCreateDTO) (*models.JoinRequest, error) {
+	orgPath := strings.TrimSpace(body.OrgPath)
+	positionKey := strings.TrimSpace(body.PositionKey)
+	if _, err := validateJoinTarget(ctx, orgPath, positionKey); err != nil {
+		return nil, err
+	}
+
+	holders, err := repo.FindActiveHolders(ctx, orgPath, positionKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holders {
+		if h.UserID == userID {
+			return nil, ErrMembershipExists
+		}
+	}
+	pending, err := repo.HasPendingJoinRequest(ctx, userID, orgPath, positionKey)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrJoinRequestExists
+	}
+
+	now := time.Now()
+	r := &models.JoinRequest{
+		ID:          bson.NewObjectID(),
+		UserID:      userID,
+		OrgPath:     orgPath,
+		PositionKey: positionKey,
+		Message:     strings.TrimSpace(body.Message),
+		Status:      models.JoinRequestPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repo.InsertJoinRequest(ctx, r); err != nil {
+		return nil, err
+	}
+
+	assigners, err := repo.FindAssignerUserIDs(ctx, orgPath)
+	if err != nil {
+		log.Printf("[org-join] find assigners of %s: %v", orgPath, err)
+	}
+	for _, uid := range assigners {
+		notifyJoin(ctx, uid, NotiJoinRequested, "join_request", r.ID, orgPath, positionKey)
+	}
+	return r, nil
+}
+
+func ListMyJoinRequests(ctx context.Context, userID bson.ObjectID) ([]models.JoinRequest, error) {
+	return repo.ListJoinRequests(ctx, bson.M{"user_id": userID})
+}
+
+// ListJoinRequestQueue คิวคำขอที่ผู้ทำมีสิทธิ์ membership:assign
+// orgPath ว่าง = ทุกหน่วยงานที่ดูแล, status ว่าง = pending
+func ListJoinRequestQueue(ctx context.Context, userPolicies []models.Policy, orgPath string, status string) ([]models.JoinRequest, error) {
+	if status == "" {
+		status = models.JoinRequestPending
+	}
+	filter := bson.M{"status": status}
+	if orgPath = strings.TrimSpace(orgPath); orgPath != "" {
+		if err := CanManageOrg(userPolicies, "membership:assign", orgPath); err != nil {
+			return nil, ErrMembershipNoPermission
+		}
+		filter["org_path"] = bson.M{"$regex": utils.OrgSubtreePattern(orgPath)}
+	}
+
+	all, err := repo.ListJoinRequests(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]models.JoinRequest, 0, len(all))
+	for _, r := range all {
+		if CanManageOrg(userPolicies, "membership:assign", r.OrgPath) == nil {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// ApproveJoinRequest ล็อกคำขอเป็น approved ก่อน แล้วสร้าง membership ผ่าน AssignMembership
+// ถ้าสร้างไม่สำเร็จจะคืนสถานะเป็น pending
+func ApproveJoinRequest(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, id bson.ObjectID, body dto.JoinRequestDecisionDTO) (*models.JoinRequest, error) {
+	r, err := repo.FindJoinRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", r.OrgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+
+	now := time.Now()
+	locked, err := repo.DecideJoinRequest(ctx, id, models.JoinRequestPending, bson.M{
+		"status":        models.JoinRequestApproved,
+		"decided_by":    actor,
+		"decided_at":    now,
+		"decision_note": strings.TrimSpace(body.Note),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if locked == nil {
+		return nil, ErrJoinRequestClosed
+	}
+
+	m, err := AssignMembership(ctx, userPolicies, models.MembershipRequestDTO{
+		UserID:      r.UserID.Hex(),
+		OrgPath:     r.OrgPath,
+		PositionKey: r.PositionKey,
+		TermEnd:     body.TermEnd,
+		EndPrevious: body.EndPrevious,
+	})
+	if err != nil {
+		if _, rerr := repo.DecideJoinRequest(ctx, id, models.JoinRequestApproved, bson.M{
+			"status": models.JoinRequestPending, "decided_by": nil, "decided_at": nil, "decision_note": "",
+		}); rerr != nil {
+			log.Printf("[org-join] revert join request %s: %v", id.Hex(), rerr)
+		}
+		return nil, err
+	}
+
+	locked, err = repo.DecideJoinRequest(ctx, id, models.JoinRequestApproved, bson.M{"membership_id": m.ID})
+	if err != nil {
+		return nil, err
+	}
+	notifyJoin(ctx, r.UserID, NotiJoinApproved, "join_request", r.ID, r.OrgPath, r.PositionKey)
+	return locked, nil
+}
+
+func RejectJoinRequest(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, id bson.ObjectID, note string) (*models.JoinRequest, error) {
+	r, err := repo.FindJoinRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrJoinRequestNotFound
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", r.OrgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+
+	out, err := repo.DecideJoinRequest(ctx, id, models.JoinRequestPending, bson.M{
+		"status":        models.JoinRequestRejected,
+		"decided_by":    actor,
+		"decided_at":    time.Now(),
+		"decision_note": strings.TrimSpace(note),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrJoinRequestClosed
+	}
+	notifyJoin(ctx, r.UserID, NotiJoinRejected, "join_request", r.ID, r.OrgPath, r.PositionKey)
+	return out, nil
+}
+
+// CancelJoinRequest ผู้ขอยกเลิกคำขอของตัวเองที่ยัง pending
+func CancelJoinRequest(ctx context.Context, userID bson.ObjectID, id bson.ObjectID) (*models.JoinRequest, error) {
+	r, err := repo.FindJoinRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.UserID != userID {
+		return nil, ErrJoinRequestNotFound
+	}
+	out, err := repo.DecideJoinRequest(ctx, id, models.JoinRequestPending, bson.M{"status": models.JoinRequestCancelled})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrJoinRequestClosed
+	}
+	return out, nil
+}
+
+// ---------- Invitations ----------
+
+func newInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+func CreateOrgInvite(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, body dto.OrgInviteCreateDTO) (*models.OrgInvite, error) {
+	orgPath := strings.TrimSpace(body.OrgPath)
+	positionKey := strings.TrimSpace(body.PositionKey)
+	if orgPath != "" {
+		if err := CanManageOrg(userPolicies, "membership:assign", orgPath); err != nil {
+			return nil, ErrMembershipNoPermission
+		}
+	}
+	if _, err := validateJoinTarget(ctx, orgPath, positionKey); err != nil {
+		return nil, err
+	}
+	if err := CanDelegatePosition(ctx, userPolicies, "membership:assign", orgPath, positionKey); err != nil {
+		return nil, err
+	}
+	if body.MaxUses < 0 || body.ExpiresInHours < 0 {
+		return nil, fmt.Errorf("%w: max_uses and expires_in_hours cannot be negative", ErrMembershipInvalid)
+	}
+
+	ttl := defaultInviteTTL
+	if body.ExpiresInHours > 0 {
+		ttl = time.Duration(body.ExpiresInHours) * time.Hour
+	}
+	now := time.Now()
+	if body.TermEnd != nil && !body.TermEnd.After(now) {
+		return nil, fmt.Errorf("%w: term_end must be in the future", ErrMembershipInvalid)
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	inv := &models.OrgInvite{
+		ID:          bson.NewObjectID(),
+		Code:        code,
+		OrgPath:     orgPath,
+		PositionKey: positionKey,
+		CreatedBy:   actor,
+		ExpiresAt:   now.Add(ttl),
+		MaxUses:     body.MaxUses,
+		RedeemedBy:  []bson.ObjectID{},
+		TermEnd:     body.TermEnd,
+		CreatedAt:   now,
+	}
+	if err := repo.InsertOrgInvite(ctx, inv); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func ListOrgInvites(ctx context.Context, userPolicies []models.Policy, orgPath string) ([]models.OrgInvite, error) {
+	orgPath = strings.TrimSpace(orgPath)
+	if orgPath == "" {
+		return nil, fmt.Errorf("%w: org_path is required", ErrMembershipInvalid)
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", orgPath); err != nil {
+		return nil, ErrMembershipNoPermission
+	}
+	return repo.ListOrgInvites(ctx, orgPath)
+}
+
+func RevokeOrgInvite(ctx context.Context, userPolicies []models.Policy, id bson.ObjectID) error {
+	inv, err := repo.FindOrgInviteByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inv == nil {
+		return ErrInviteNotFound
+	}
+	if err := CanManageOrg(userPolicies, "membership:assign", inv.OrgPath); err != nil {
+		return ErrMembershipNoPermission
+	}
+	return repo.RevokeOrgInvite(ctx, id)
+}
+
+// RedeemOrgInvite ใช้โค้ดเชิญ: สิทธิ์แต่งตั้งมาจาก policy ปัจจุบันของผู้สร้างโค้ด
+// (ถ้าผู้สร้างหมดสิทธิ์แล้ว โค้ดก็ใช้ไม่ได้)
+func RedeemOrgInvite(ctx context.Context, userID bson.ObjectID, code string) (*models.Membership, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	inv, err := repo.ClaimOrgInvite(ctx, code, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		existing, err := repo.FindOrgInviteByCode(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, ErrInviteNotFound
+		}
+		return nil, ErrInviteUnavailable
+	}
+
+	m, err := func() (*models.Membership, error) {
+		creatorPolicies, err := MyUserPolicy(ctx, inv.CreatedBy.Hex())
+		if err != nil {
+			return nil, err
+		}
+		return AssignMembership(ctx, creatorPolicies, models.MembershipRequestDTO{
+			UserID:      userID.Hex(),
+			OrgPath:     inv.OrgPath,
+			PositionKey: inv.PositionKey,
+			TermEnd:     inv.TermEnd,
+		})
+	}()
+	if err != nil {
+		if rerr := repo.ReleaseOrgInvite(ctx, inv.ID, userID); rerr != nil {
+			log.Printf("[org-join] release invite %s: %v", inv.ID.Hex(), rerr)
+		}
+		return nil, err
+	}
+
+	notifyJoin(ctx, inv.CreatedBy, NotiInviteRedeemed, "org_invite", inv.ID, inv.OrgPath, inv.PositionKey)
+	return m, nil
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/internal/changebus"
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+var ErrOrgUnitNotFound = errors.New("org unit not found")
+var ErrOrgParentNotFound = errors.New("new parent path not found")
+var ErrOrgPathTaken = errors.New("an org unit already exists at the destination path")
+var ErrOrgMoveCycle = errors.New("cannot move an org unit into itself or its descendants")
+var ErrOrgMoveInvalid = errors.New("invalid move request")
+var ErrOrgNoPermission = errors.New("no permission to manage this org unit")
+
+// orgShortName ใช้กติกาเดียวกับ CreateOrgUnit: "PARENTLEAF • SLUG" หรือ "SLUG" ถ้าอยู่ใต้ root
+func orgShortName(parentPath, slug string) string {
+	if parentPath == "/" {
+		return strings.ToUpper(slug)
+	}
+	return strings.TrimSpace(strings.ToUpper(utils.OrgLeaf(parentPath)) + " • " + strings.ToUpper(slug))
+}
+
+// MoveOrgUnit ย้าย (re-parent) และ/หรือเปลี่ยน slug/ชื่อของหน่วยงาน พร้อมเขียน org_path ใหม่
+// ให้ทุกเอกสารที่อ้างถึง subtree นี้ภายใน transaction เดียว แล้วเก็บ redirect จาก path เดิม
+//
+// ผู้ทำต้องมี organize:create ครอบคลุมทั้งตำแหน่งเดิมและตำแหน่งใหม่
+func MoveOrgUnit(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, body dto.OrgUnitMoveDTO) (*dto.OrgUnitMoveReport, error) {
+	from := strings.TrimRight(strings.TrimSpace(body.OrgPath), "/")
+	if from == "" || !strings.HasPrefix(from, "/") {
+		return nil, fmt.Errorf("%w: org_path is required and cannot be root", ErrOrgMoveInvalid)
+	}
+
+	node, err := repo.FindByOrgPath(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, ErrOrgUnitNotFound
+	}
+
+	parent := strings.TrimSpace(body.NewParentPath)
+	if parent == "" {
+		parent = node.ParentPath
+	}
+	if parent != "/" {
+		parent = strings.TrimRight(parent, "/")
+	}
+	slug := strings.ToLower(strings.TrimSpace(body.NewSlug))
+	if slug == "" {
+		slug = utils.OrgLeaf(from)
+	} else if !utils.ValidOrgSlug(slug) {
+		return nil, fmt.Errorf("%w: slug may only contain a-z, 0-9, '-' and '_'", ErrOrgMoveInvalid)
+	}
+	name := strings.TrimSpace(body.NewName)
+	if name == "" {
+		name = node.Name
+	}
+
+	to := parent + "/" + slug
+	if parent == "/" {
+		to = "/" + slug
+	}
+
+	if utils.IsUnderOrgPath(parent, from) {
+		return nil, ErrOrgMoveCycle
+	}
+	if to == from && name == node.Name {
+		return nil, fmt.Errorf("%w: nothing to change", ErrOrgMoveInvalid)
+	}
+
+	if err := CanManageOrg(userPolicies, "organize:create", from); err != nil {
+		return nil, ErrOrgNoPermission
+	}
+	if err := CanManageOrg(userPolicies, "organize:create", to); err != nil {
+		return nil, ErrOrgNoPermission
+	}
+
+	if parent != "/" {
+		parentNode, err := repo.FindByOrgPath(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		if parentNode == nil {
+			return nil, ErrOrgParentNotFound
+		}
+	}
+	if to != from {
+		dup, err := repo.FindByOrgPath(ctx, to)
+		if err != nil {
+			return nil, err
+		}
+		if dup != nil {
+			return nil, ErrOrgPathTaken
+		}
+	}
+
+	subtree, err := repo.FindByPrefix(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.OrgUnitMoveReport{From: from, To: to, Updated: map[string]int64{}}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		now := time.Now().UTC()
+		report.NodesMoved = 0
+		clear(report.Updated)
+
+		for _, n := range subtree {
+			newPath := utils.RebaseOrgPath(n.OrgPath, from, to)
+			n.ParentPath = utils.OrgParent(newPath)
+			if n.OrgPath == from {
+				n.Slug = slug
+				n.Name = name
+			}
+			// shortname ขึ้นกับ leaf ของ parent เปลี่ยนเฉพาะตัวที่ย้ายกับลูกโดยตรง
+			if n.OrgPath == from || utils.OrgParent(n.OrgPath) == from {
+				n.ShortName = orgShortName(n.ParentPath, utils.OrgLeaf(newPath))
+			}
+			n.OrgPath = newPath
+			n.Ancestors = utils.OrgAncestors(newPath)
+			n.Depth = len(n.Ancestors)
+			n.UpdatedAt = now
+
+			if err := repo.UpdateOrgUnitPlacement(tx, n); err != nil {
+				return nil, fmt.Errorf("update org unit %s: %w", n.ID.Hex(), err)
+			}
+			report.NodesMoved++
+		}
+
+		if to != from {
+			// policies.org_prefix อยู่ใน OrgPathRefs ด้วย -> บันทึก version ของ policy ที่ถูกย้าย
+			policies, err := repo.ListPoliciesUnder(tx, from)
+			if err != nil {
+				return nil, err
+			}
+			err = versionBulkPolicyWrite(tx, models.PolicyOpMove, actor, policies, func() error {
+				for _, ref := range repo.OrgPathRefs {
+					n, err := repo.RebaseOrgPathRef(tx, ref, from, to)
+					if err != nil {
+						return fmt.Errorf("rewrite %s.%s: %w", ref.Collection, ref.Field, err)
+					}
+					key := ref.Collection + "." + ref.Field
+					if ref.ArrayField != "" {
+						key = ref.Collection + "." + ref.ArrayField + "." + ref.Field
+					}
+					report.Updated[key] = n
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			n, err := repo.RecomputeMembershipAncestors(tx, to)
+			if err != nil {
+				return nil, fmt.Errorf("recompute membership ancestors: %w", err)
+			}
+			report.Updated["memberships.org_ancestors"] = n
+
+			if err := repo.DeleteOrgPathRedirectsUnder(tx, to); err != nil {
+				return nil, err
+			}
+			if err := repo.UpsertOrgPathRedirect(tx, from, to, node.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changebus.OrgUnitsChanged()
+	changebus.MembershipsChanged()
+	changebus.PoliciesChanged()
+	return report, nil
+}
+
+// ResolveOrgPath แปลง path เดิมที่ถูกย้ายไปแล้วให้เป็น path ปัจจุบัน (คืน path เดิมถ้าไม่มี redirect)
+func ResolveOrgPath(ctx context.Context, path string) (string, bool, error) {
+	node, err := repo.FindByOrgPath(ctx, path)
+	if err != nil {
+		return "", false, err
+	}
+	if node != nil {
+		return path, false, nil
+	}
+
+	r, err := repo.FindOrgPathRedirect(ctx, path)
+	if err != nil {
+		return "", false, err
+	}
+	if r == nil {
+		return path, false, nil
+	}
+	return utils.RebaseOrgPath(path, r.From, r.To), true, nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+const orgProfileUpcomingLimit = 5
+
+// OrgRedirectError ใช้บอก controller ว่า path นี้ถูกย้ายไปแล้ว ให้ redirect ไป path ใหม่
+type OrgRedirectError struct {
+	To string
+}
+
+func (e *OrgRedirectError) Error() string {
+	return fmt.Sprintf("org unit moved to %s", e.To)
+}
+
+// orgDisplayName เลือกชื่อตามภาษา (th/en) ถ้าไม่มีใช้ name ตามเดิม
+func orgDisplayName(node *models.OrgUnitNode, lang string) string {
+	if lang != "" {
+		if n := strings.TrimSpace(node.Names[lang]); n != "" {
+			return n
+		}
+	}
+	return node.Name
+}
+
+// GetOrgUnitProfile คืนโปรไฟล์หน่วยงาน + สถิติสด และ event ที่กำลังจะมาถึงที่ viewer มองเห็น
+func GetOrgUnitProfile(ctx context.Context, orgPath, lang string, viewerID bson.ObjectID, userPolicies []models.Policy) (*dto.OrgUnitProfile, error) {
+	node, err := repo.FindByOrgPath(ctx, orgPath)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		current, redirected, err := ResolveOrgPath(ctx, orgPath)
+		if err != nil {
+			return nil, err
+		}
+		if redirected {
+			return nil, &OrgRedirectError{To: current}
+		}
+		return nil, ErrOrgUnitNotFound
+	}
+
+	p := &dto.OrgUnitProfile{
+		OrgPath:        node.OrgPath,
+		ParentPath:     node.ParentPath,
+		Name:           node.Name,
+		DisplayName:    orgDisplayName(node, lang),
+		Names:          node.Names,
+		ShortName:      node.ShortName,
+		Slug:           node.Slug,
+		Type:           node.Type,
+		Status:         node.Status,
+		Description:    node.Description,
+		LogoURL:        node.LogoURL,
+		Contact:        node.Contact,
+		Social:         node.Social,
+		CanEdit:        CanManageOrg(userPolicies, "organize:create", node.OrgPath) == nil,
+		UpcomingEvents: []dto.OrgUnitUpcomingEvent{},
+	}
+
+	counts, err := repo.CountActiveMembersByPosition(ctx, node.OrgPath)
+	if err != nil {
+		return nil, err
+	}
+	p.Stats.MembersByPosition = make([]dto.OrgUnitPositionCount, 0, len(counts))
+	for _, c := range counts {
+		row := dto.OrgUnitPositionCount{PositionKey: c.PositionKey, Count: c.Count}
+		if pos, err := repo.FindPositionByKeyandPath(ctx, c.PositionKey, node.OrgPath); err == nil && pos != nil {
+			row.Display = pos.Display
+		}
+		p.Stats.MembersByPosition = append(p.Stats.MembersByPosition, row)
+		p.Stats.MemberCount += c.Count
+	}
+
+	if p.Stats.PostCount, err = repo.CountActivePostsAs(ctx, node.OrgPath); err != nil {
+		return nil, err
+	}
+	if p.Stats.EventCount, err = repo.CountEventsOfOrg(ctx, node.OrgPath); err != nil {
+		return nil, err
+	}
+	if p.Stats.ChildCount, err = repo.CountOrgChildren(ctx, node.OrgPath); err != nil {
+		return nil, err
+	}
+
+	upcoming, err := repo.FindUpcomingEventsOfOrg(ctx, node.OrgPath, time.Now().UTC(), 0)
+	if err != nil {
+		return nil, err
+	}
+	userOrgs, err := AllUserOrg(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range upcoming {
+		ev := u.Event
+		if !CheckVisibleEvent(ctx, &ev, userOrgs, viewerID) {
+			continue
+		}
+		p.UpcomingEvents = append(p.UpcomingEvents, dto.OrgUnitUpcomingEvent{
+			EventID:    ev.ID.Hex(),
+			Topic:      ev.Topic,
+			PictureURL: ev.PictureURL,
+			StartsAt:   u.Schedule.Time_start,
+			EndsAt:     u.Schedule.Time_end,
+			Location:   u.Schedule.Location,
+		})
+		if len(p.UpcomingEvents) >= orgProfileUpcomingLimit {
+			break
+		}
+	}
+
+	return p, nil
+}
+
+func validProfileURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// UpdateOrgUnitProfile แก้โปรไฟล์หน่วยงาน (ต้องมี organize:create ครอบคลุมหน่วยงานนี้)
+func UpdateOrgUnitProfile(ctx context.Context, userPolicies []models.Policy, orgPath string, body dto.OrgUnitProfileUpdateDTO) (*models.OrgUnitNode, error) {
+	node, set, err := orgProfileChanges(ctx, userPolicies, orgPath, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(set) == 0 {
+		return node, nil
+	}
+
+	node.UpdatedAt = time.Now().UTC()
+	set["updated_at"] = node.UpdatedAt
+	if err := repo.UpdateOrgUnitProfile(ctx, node.ID, set); err != nil {
+		return nil, err
+	}
+	changebus.OrgUnitsChanged()
+	return node, nil
+}
+
+// ValidateOrgUnitProfile ตรวจแบบเดียวกับ UpdateOrgUnitProfile แต่ไม่เขียน
+// controller เรียกก่อนบันทึกไฟล์โลโก้ เพื่อไม่ให้มีไฟล์ค้างเมื่อคำขอใช้ไม่ได้ (ไม่มีสิทธิ์, archived, ค่าผิด)
+func ValidateOrgUnitProfile(ctx context.Context, userPolicies []models.Policy, orgPath string, body dto.OrgUnitProfileUpdateDTO) error {
+	_, _, err := orgProfileChanges(ctx, userPolicies, orgPath, body)
+	return err
+}
+
+// orgProfileChanges คืน node ที่ใส่ค่าใหม่แล้วกับ field ที่ต้อง $set (ยังไม่เขียน)
+func orgProfileChanges(ctx context.Context, userPolicies []models.Policy, orgPath string, body dto.OrgUnitProfileUpdateDTO) (*models.OrgUnitNode, bson.M, error) {
+	node, err := repo.FindByOrgPath(ctx, orgPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if node == nil {
+		return nil, nil, ErrOrgUnitNotFound
+	}
+	if node.Status == models.OrgStatusArchived {
+		return nil, nil, ErrOrgArchived
+	}
+	if err := CanManageOrg(userPolicies, "organize:create", node.OrgPath); err != nil {
+		return nil, nil, ErrOrgNoPermission
+	}
+
+	set := bson.M{}
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			return nil, nil, fmt.Errorf("%w: name cannot be empty", ErrOrgMoveInvalid)
+		}
+		set["name"] = name
+		node.Name = name
+	}
+	if body.Names != nil {
+		set["names"] = body.Names
+		node.Names = body.Names
+	}
+	if body.Description != nil {
+		set["description"] = strings.TrimSpace(*body.Description)
+		node.Description = strings.TrimSpace(*body.Description)
+	}
+	if body.LogoURL != nil {
+		if *body.LogoURL != "" && !validProfileURL(*body.LogoURL) {
+			return nil, nil, fmt.Errorf("%w: logo_url must be an http(s) URL", ErrOrgMoveInvalid)
+		}
+		set["logo_url"] = *body.LogoURL
+		node.LogoURL = *body.LogoURL
+	}
+	if body.Contact != nil {
+		if body.Contact.Website != "" && !validProfileURL(body.Contact.Website) {
+			return nil, nil, fmt.Errorf("%w: contact.website must be an http(s) URL", ErrOrgMoveInvalid)
+		}
+		set["contact"] = body.Contact
+		node.Contact = body.Contact
+	}
+	if body.Social != nil {
+		for k, v := range body.Social {
+			if v != "" && !validProfileURL(v) {
+				return nil, nil, fmt.Errorf("%w: social.%s must be an http(s) URL", ErrOrgMoveInvalid, k)
+			}
+		}
+		set["social"] = body.Social
+		node.Social = body.Social
+	}
+	return node, set, nil
+}
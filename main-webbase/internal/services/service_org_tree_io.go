@@ -0,0 +1,838 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"gopkg.in/yaml.v2"
+
+	"main-webbase/database"
+	"main-webbase/internal/changebus"
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+const OrgTreeDocumentVersion = 1
+
+var ErrOrgTreeInvalid = errors.New("invalid org tree document")
+
+func normalizeOrgRoot(root string) string {
+	root = strings.TrimSpace(root)
+	if root == "" || root == "/" {
+		return "/"
+	}
+	return "/" + strings.Trim(root, "/")
+}
+
+// DecodeOrgTreeDocument อ่านเอกสารจาก body (format: "yaml" หรือ "json")
+func DecodeOrgTreeDocument(body []byte, format string) (*dto.OrgTreeDocument, error) {
+	var doc dto.OrgTreeDocument
+	var err error
+	if format == "yaml" {
+		err = yaml.Unmarshal(body, &doc)
+	} else {
+		err = json.Unmarshal(body, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOrgTreeInvalid, err)
+	}
+	return &doc, nil
+}
+
+// EncodeOrgTreeDocument เขียนเอกสารเป็น yaml หรือ json (indent เพื่อเก็บลง git ได้สวย)
+func EncodeOrgTreeDocument(doc *dto.OrgTreeDocument, format string) ([]byte, error) {
+	if format == "yaml" {
+		return yaml.Marshal(doc)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// ExportOrgTree ดึง units / positions / policies (และ memberships ถ้าขอ) ของ subtree เป็นเอกสารเดียว
+// เรียงลำดับคงที่ เพื่อให้ diff ใน git อ่านง่าย
+func ExportOrgTree(ctx context.Context, root string, withMemberships bool) (*dto.OrgTreeDocument, error) {
+	root = normalizeOrgRoot(root)
+	if root != "/" {
+		node, err := repo.FindByOrgPath(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, ErrOrgUnitNotFound
+		}
+	}
+
+	now := time.Now().UTC()
+	doc := &dto.OrgTreeDocument{Version: OrgTreeDocumentVersion, Root: root, ExportedAt: &now}
+
+	units, err := repo.FindByPrefix(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Depth != units[j].Depth {
+			return units[i].Depth < units[j].Depth
+		}
+		return units[i].OrgPath < units[j].OrgPath
+	})
+	for _, u := range units {
+		if u.OrgPath == "/" {
+			continue
+		}
+		status := ""
+		if u.Status == models.OrgStatusArchived {
+			status = models.OrgStatusArchived
+		}
+		doc.Units = append(doc.Units, dto.OrgTreeUnit{OrgPath: u.OrgPath, Name: u.Name, Type: u.Type, Status: status})
+	}
+
+	positions, err := repo.ListPositionsUnder(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Scope.OrgPath != positions[j].Scope.OrgPath {
+			return positions[i].Scope.OrgPath < positions[j].Scope.OrgPath
+		}
+		return positions[i].Key < positions[j].Key
+	})
+	for _, p := range positions {
+		doc.Positions = append(doc.Positions, dto.OrgTreePosition{
+			Key:             p.Key,
+			OrgPath:         p.Scope.OrgPath,
+			Display:         p.Display,
+			Rank:            p.Rank,
+			Inherit:         p.Scope.Inherit,
+			ExclusivePerOrg: p.Constraints.ExclusivePerOrg,
+			Status:          p.Status,
+		})
+	}
+
+	policies, err := repo.ListPoliciesUnder(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].OrgPrefix != policies[j].OrgPrefix {
+			return policies[i].OrgPrefix < policies[j].OrgPrefix
+		}
+		return policies[i].PositionKey < policies[j].PositionKey
+	})
+	for _, p := range policies {
+		if p.ArchiveRef != nil {
+			// ถูกปิดเพราะ archive ไม่ใช่เพราะตั้งใจปิด -> export ตามสถานะก่อน archive
+			p.Enabled = true
+		}
+		doc.Policies = append(doc.Policies, dto.OrgTreePolicy{
+			PositionKey: p.PositionKey,
+			OrgPrefix:   p.OrgPrefix,
+			Scope:       p.Scope,
+			Actions:     p.Actions,
+			Enabled:     p.Enabled,
+			Effect:      p.Effect,
+			Conditions:  p.Conditions,
+		})
+	}
+
+	if withMemberships {
+		memberships, err := repo.ListMembershipsUnder(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		list := []dto.OrgTreeMembership{}
+		for _, m := range memberships {
+			if !m.Active && m.ArchiveRef == nil {
+				continue
+			}
+			list = append(list, dto.OrgTreeMembership{UserID: m.UserID.Hex(), OrgPath: m.OrgPath, PositionKey: m.PositionKey})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if a.OrgPath != b.OrgPath {
+				return a.OrgPath < b.OrgPath
+			}
+			if a.PositionKey != b.PositionKey {
+				return a.PositionKey < b.PositionKey
+			}
+			return a.UserID < b.UserID
+		})
+		doc.Memberships = &list
+	}
+
+	return doc, nil
+}
+
+func validateOrgTreeDocument(doc *dto.OrgTreeDocument) error {
+	if doc.Version != 0 && doc.Version != OrgTreeDocumentVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrOrgTreeInvalid, doc.Version)
+	}
+	doc.Root = normalizeOrgRoot(doc.Root)
+
+	seen := map[string]bool{}
+	for i, u := range doc.Units {
+		u.OrgPath = strings.TrimSpace(u.OrgPath)
+		if u.OrgPath == "" || u.OrgPath == "/" || !utils.IsUnderOrgPath(u.OrgPath, doc.Root) {
+			return fmt.Errorf("%w: unit %q is outside root %q", ErrOrgTreeInvalid, u.OrgPath, doc.Root)
+		}
+		if strings.TrimSpace(u.Name) == "" || strings.TrimSpace(u.Type) == "" {
+			return fmt.Errorf("%w: unit %q needs name and type", ErrOrgTreeInvalid, u.OrgPath)
+		}
+		if u.Status != "" && u.Status != models.OrgStatusActive && u.Status != models.OrgStatusArchived {
+			return fmt.Errorf("%w: unit %q has unknown status %q", ErrOrgTreeInvalid, u.OrgPath, u.Status)
+		}
+		if seen[u.OrgPath] {
+			return fmt.Errorf("%w: duplicate unit %q", ErrOrgTreeInvalid, u.OrgPath)
+		}
+		seen[u.OrgPath] = true
+		doc.Units[i] = u
+	}
+	for _, p := range doc.Positions {
+		if p.Key == "" || !utils.IsUnderOrgPath(p.OrgPath, doc.Root) {
+			return fmt.Errorf("%w: position %q@%q is invalid or outside root", ErrOrgTreeInvalid, p.Key, p.OrgPath)
+		}
+	}
+	for _, p := range doc.Policies {
+		if p.PositionKey == "" || !utils.IsUnderOrgPath(p.OrgPrefix, doc.Root) {
+			return fmt.Errorf("%w: policy %q@%q is invalid or outside root", ErrOrgTreeInvalid, p.PositionKey, p.OrgPrefix)
+		}
+		if p.Scope != "exact" && p.Scope != "subtree" {
+			return fmt.Errorf("%w: policy %q@%q scope must be exact or subtree", ErrOrgTreeInvalid, p.PositionKey, p.OrgPrefix)
+		}
+		if p.Effect != "" && p.Effect != models.PolicyEffectAllow && p.Effect != models.PolicyEffectDeny {
+			return fmt.Errorf("%w: policy %q@%q effect must be allow or deny", ErrOrgTreeInvalid, p.PositionKey, p.OrgPrefix)
+		}
+	}
+	if doc.Memberships != nil {
+		for _, m := range *doc.Memberships {
+			if _, err := bson.ObjectIDFromHex(m.UserID); err != nil {
+				return fmt.Errorf("%w: membership user_id %q", ErrOrgTreeInvalid, m.UserID)
+			}
+			if m.PositionKey == "" || !utils.IsUnderOrgPath(m.OrgPath, doc.Root) {
+				return fmt.Errorf("%w: membership %s@%q is invalid or outside root", ErrOrgTreeInvalid, m.UserID, m.OrgPath)
+			}
+		}
+	}
+	return nil
+}
+
+// orgTreeOp หนึ่งขั้นของแผน พร้อมฟังก์ชันที่ทำจริงตอน apply
+type orgTreeOp struct {
+	item  dto.OrgTreePlanItem
+	apply func(ctx context.Context, now time.Time) error
+}
+
+func change(field string, from, to any) string {
+	return fmt.Sprintf("%s: %v -> %v", field, from, to)
+}
+
+// diffOrgTree เทียบเอกสารกับฐานข้อมูลแล้วคืนรายการ op ตามลำดับที่ต้อง apply
+// (สร้าง unit จากบนลงล่าง -> update/restore -> positions -> policies -> memberships -> archive)
+func diffOrgTree(ctx context.Context, doc *dto.OrgTreeDocument, actor bson.ObjectID) ([]orgTreeOp, error) {
+	var ops []orgTreeOp
+
+	existing, err := repo.FindByPrefix(ctx, doc.Root)
+	if err != nil {
+		return nil, err
+	}
+	dbUnits := map[string]models.OrgUnitNode{}
+	for _, u := range existing {
+		if u.OrgPath == "/" {
+			continue
+		}
+		dbUnits[u.OrgPath] = u
+	}
+
+	// สถานะปลายทางของแต่ละ unit: archived ถ้าเอกสารบอก archived หรือไม่มีในเอกสาร
+	want := map[string]dto.OrgTreeUnit{}
+	for _, u := range doc.Units {
+		want[u.OrgPath] = u
+	}
+	archivedTarget := func(path string) bool {
+		for _, p := range append(utils.OrgAncestors(path), path) {
+			if p == "/" || !utils.IsUnderOrgPath(p, doc.Root) {
+				continue
+			}
+			if u, ok := want[p]; ok {
+				if u.Status == models.OrgStatusArchived {
+					return true
+				}
+				continue
+			}
+			if _, inDB := dbUnits[p]; inDB {
+				return true
+			}
+		}
+		return false
+	}
+
+	// --- units: create / update / restore ---
+	units := append([]dto.OrgTreeUnit(nil), doc.Units...)
+	sort.Slice(units, func(i, j int) bool {
+		di, dj := strings.Count(units[i].OrgPath, "/"), strings.Count(units[j].OrgPath, "/")
+		if di != dj {
+			return di < dj
+		}
+		return units[i].OrgPath < units[j].OrgPath
+	})
+	for _, u := range units {
+		u := u
+		cur, ok := dbUnits[u.OrgPath]
+		if !ok {
+			leaf := utils.OrgLeaf(u.OrgPath)
+			if !utils.ValidOrgSlug(leaf) {
+				return nil, fmt.Errorf("%w: unit %q: slug may only contain a-z, 0-9, '-' and '_'", ErrOrgTreeInvalid, u.OrgPath)
+			}
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "create", Kind: "unit", Key: u.OrgPath},
+				apply: func(ctx context.Context, now time.Time) error {
+					_, err := CreateOrgUnit(dto.OrgUnitDTO{
+						ParentPath: utils.OrgParent(u.OrgPath),
+						Name:       u.Name,
+						Slug:       leaf,
+						Type:       u.Type,
+					}, ctx)
+					return err
+				},
+			})
+			if u.Status == models.OrgStatusArchived {
+				ops = append(ops, archiveOp(u.OrgPath, actor))
+			}
+			continue
+		}
+
+		var changes []string
+		set := bson.M{}
+		if cur.Name != u.Name {
+			changes = append(changes, change("name", cur.Name, u.Name))
+			set["name"] = u.Name
+		}
+		if cur.Type != u.Type {
+			changes = append(changes, change("type", cur.Type, u.Type))
+			set["type"] = u.Type
+		}
+		if len(set) > 0 {
+			id := cur.ID
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "update", Kind: "unit", Key: u.OrgPath, Changes: changes},
+				apply: func(ctx context.Context, now time.Time) error {
+					set["updated_at"] = now
+					return repo.SetDocFields(ctx, "org_units", id, set)
+				},
+			})
+		}
+
+		if cur.Status == models.OrgStatusArchived && !archivedTarget(u.OrgPath) {
+			open, err := repo.FindOpenOrgArchive(ctx, u.OrgPath)
+			if err != nil {
+				return nil, err
+			}
+			if open != nil {
+				archiveID := open.ID
+				ops = append(ops, orgTreeOp{
+					item: dto.OrgTreePlanItem{Action: "restore", Kind: "unit", Key: u.OrgPath},
+					apply: func(ctx context.Context, now time.Time) error {
+						if _, err := restoreOrgSubtree(ctx, actor, archiveID, now); err != nil {
+							return err
+						}
+						return repo.MarkOrgArchiveRestored(ctx, archiveID, actor, now)
+					},
+				})
+			}
+		}
+	}
+
+	// --- positions: create / update (ไม่ลบ position ที่ไม่มีในเอกสาร) ---
+	dbPositions, err := repo.ListPositionsUnder(ctx, doc.Root)
+	if err != nil {
+		return nil, err
+	}
+	posByKey := map[string]models.Position{}
+	for _, p := range dbPositions {
+		posByKey[p.Key+"@"+p.Scope.OrgPath] = p
+	}
+	for _, p := range doc.Positions {
+		p := p
+		key := p.Key + "@" + p.OrgPath
+		status := orgTreePosition(p).Status
+		cur, ok := posByKey[key]
+		if !ok {
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "create", Kind: "position", Key: key},
+				apply: func(ctx context.Context, now time.Time) error {
+					pos := orgTreePosition(p)
+					pos.ID = bson.NewObjectID()
+					pos.CreatedAt, pos.UpdatedAt = now, now
+					return repo.InsertPosition(ctx, pos)
+				},
+			})
+			continue
+		}
+
+		var changes []string
+		set := bson.M{}
+		if !reflect.DeepEqual(cur.Display, p.Display) && !(len(cur.Display) == 0 && len(p.Display) == 0) {
+			changes = append(changes, change("display", cur.Display, p.Display))
+			set["display"] = p.Display
+		}
+		if cur.Rank != p.Rank {
+			changes = append(changes, change("rank", cur.Rank, p.Rank))
+			set["rank"] = p.Rank
+		}
+		if cur.Scope.Inherit != p.Inherit {
+			changes = append(changes, change("inherit", cur.Scope.Inherit, p.Inherit))
+			set["scope.inherit"] = p.Inherit
+		}
+		if cur.Constraints.ExclusivePerOrg != p.ExclusivePerOrg {
+			changes = append(changes, change("exclusive_per_org", cur.Constraints.ExclusivePerOrg, p.ExclusivePerOrg))
+			set["constraints.exclusive_per_org"] = p.ExclusivePerOrg
+		}
+		if cur.Status != status {
+			changes = append(changes, change("status", cur.Status, status))
+			set["status"] = status
+		}
+		if len(set) > 0 {
+			id := cur.ID
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "update", Kind: "position", Key: key, Changes: changes},
+				apply: func(ctx context.Context, now time.Time) error {
+					set["updatedAt"] = now
+					return repo.SetDocFields(ctx, "positions", id, set)
+				},
+			})
+		}
+	}
+
+	// --- policies: create / update / archive(ปิด) ---
+	dbPolicies, err := repo.ListPoliciesUnder(ctx, doc.Root)
+	if err != nil {
+		return nil, err
+	}
+	polByKey := map[string]models.Policy{}
+	for _, p := range dbPolicies {
+		polByKey[policyIdentity(p.PositionKey, p.OrgPrefix, p.Effect, p.Conditions)] = p
+	}
+	inDoc := map[string]bool{}
+	for _, p := range doc.Policies {
+		p := p
+		key := policyIdentity(p.PositionKey, p.OrgPrefix, p.Effect, p.Conditions)
+		inDoc[key] = true
+		if archivedTarget(p.OrgPrefix) {
+			continue
+		}
+		cur, ok := polByKey[key]
+		if !ok || !sameStringSet(cur.Actions, p.Actions) {
+			if err := ValidateCatalogActions(ctx, p.Actions); err != nil {
+				return nil, fmt.Errorf("%w: policy %s: %w", ErrOrgTreeInvalid, key, err)
+			}
+		}
+		if !ok {
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "create", Kind: "policy", Key: key},
+				apply: func(ctx context.Context, now time.Time) error {
+					return savePolicyIn(ctx, actor, nil, models.Policy{
+						ID:          bson.NewObjectID(),
+						PositionKey: p.PositionKey,
+						Scope:       p.Scope,
+						OrgPrefix:   p.OrgPrefix,
+						Actions:     p.Actions,
+						Enabled:     p.Enabled,
+						Effect:      normalizeEffect(p.Effect),
+						Conditions:  p.Conditions,
+						CreatedAt:   now,
+						UpdatedAt:   now,
+					})
+				},
+			})
+			continue
+		}
+
+		var changes []string
+		next := cur
+		if cur.Scope != p.Scope {
+			changes = append(changes, change("scope", cur.Scope, p.Scope))
+			next.Scope = p.Scope
+		}
+		if !sameStringSet(cur.Actions, p.Actions) {
+			changes = append(changes, change("actions", cur.Actions, p.Actions))
+			next.Actions = p.Actions
+		}
+		if cur.Enabled != p.Enabled {
+			changes = append(changes, change("enabled", cur.Enabled, p.Enabled))
+			next.Enabled = p.Enabled
+		}
+		if len(changes) > 0 {
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "update", Kind: "policy", Key: key, Changes: changes},
+				apply: func(ctx context.Context, now time.Time) error {
+					next.UpdatedAt = now
+					return savePolicyIn(ctx, actor, &cur, next)
+				},
+			})
+		}
+	}
+	for key, p := range polByKey {
+		if inDoc[key] || !p.Enabled || archivedTarget(p.OrgPrefix) {
+			continue
+		}
+		cur := p
+		ops = append(ops, orgTreeOp{
+			item: dto.OrgTreePlanItem{Action: "archive", Kind: "policy", Key: key, Changes: []string{change("enabled", true, false)}},
+			apply: func(ctx context.Context, now time.Time) error {
+				next := cur
+				next.Enabled = false
+				next.UpdatedAt = now
+				return savePolicyIn(ctx, actor, &cur, next)
+			},
+		})
+	}
+
+	// --- memberships (เฉพาะเมื่อเอกสารมี section นี้) ---
+	if doc.Memberships != nil {
+		dbMembers, err := repo.ListMembershipsUnder(ctx, doc.Root)
+		if err != nil {
+			return nil, err
+		}
+		memByKey := map[string]models.Membership{}
+		for _, m := range dbMembers {
+			memByKey[m.UserID.Hex()+"@"+m.OrgPath+"#"+m.PositionKey] = m
+		}
+
+		// ตำแหน่งหลัง apply: ของเดิมใต้ root ถูกแทนด้วยที่อยู่ในเอกสาร
+		finalPos := map[string]models.Position{}
+		for k, p := range posByKey {
+			finalPos[k] = p
+		}
+		for _, p := range doc.Positions {
+			finalPos[p.Key+"@"+p.OrgPath] = orgTreePosition(p)
+		}
+		// ผู้ถือหลัง apply ต่อ org#position = membership ในเอกสาร (section นี้แทนของเดิมทั้งหมด)
+		wantMem := map[string]bool{}
+		holders := map[string]int{}
+		for _, m := range *doc.Memberships {
+			key := m.UserID + "@" + m.OrgPath + "#" + m.PositionKey
+			if !wantMem[key] && !archivedTarget(m.OrgPath) {
+				holders[m.OrgPath+"#"+m.PositionKey]++
+			}
+			wantMem[key] = true
+		}
+		// ตรวจแบบเดียวกับ AssignMembership (validatePositionTarget / ensurePositionOpen / exclusive) กับสถานะหลัง apply
+		// เพราะ unit และตำแหน่งอาจถูกสร้างในแผนเดียวกัน
+		checkMembership := func(m dto.OrgTreeMembership) error {
+			if _, ok := want[m.OrgPath]; !ok && m.OrgPath != "/" {
+				return ErrOrgUnitNotFound
+			}
+			pos, err := orgTreePositionFor(ctx, finalPos, doc.Root, m.PositionKey, m.OrgPath)
+			if err != nil {
+				return err
+			}
+			if pos == nil {
+				return fmt.Errorf("%w: %q is not scoped to %s", ErrPositionOutOfScope, m.PositionKey, m.OrgPath)
+			}
+			if err := ensurePositionOpen(pos); err != nil {
+				return err
+			}
+			if n := holders[m.OrgPath+"#"+m.PositionKey]; pos.Constraints.ExclusivePerOrg && n > 1 {
+				return fmt.Errorf("%w: %q at %s would have %d holders", ErrPositionExclusive, m.PositionKey, m.OrgPath, n)
+			}
+			return nil
+		}
+
+		// ปิดของเดิมก่อนเพิ่มของใหม่ ตำแหน่ง exclusive ที่เปลี่ยนคนถือจะได้ไม่ชนกันตอน apply
+		for key, m := range memByKey {
+			if wantMem[key] || !m.Active || archivedTarget(m.OrgPath) {
+				continue
+			}
+			id := m.ID
+			ops = append(ops, orgTreeOp{
+				item: dto.OrgTreePlanItem{Action: "archive", Kind: "membership", Key: key, Changes: []string{change("active", true, false)}},
+				apply: func(ctx context.Context, now time.Time) error {
+					return repo.SetDocFields(ctx, "memberships", id, bson.M{"active": false, "updated_at": now})
+				},
+			})
+		}
+		added := map[string]bool{}
+		for _, m := range *doc.Memberships {
+			m := m
+			key := m.UserID + "@" + m.OrgPath + "#" + m.PositionKey
+			if added[key] || archivedTarget(m.OrgPath) {
+				continue
+			}
+			added[key] = true
+			cur, ok := memByKey[key]
+			if ok && (cur.Active || cur.ArchiveRef != nil) {
+				continue
+			}
+			if err := checkMembership(m); err != nil {
+				return nil, fmt.Errorf("%w: membership %s: %w", ErrOrgTreeInvalid, key, err)
+			}
+			switch {
+			case !ok:
+				uid, _ := bson.ObjectIDFromHex(m.UserID)
+				ops = append(ops, orgTreeOp{
+					item: dto.OrgTreePlanItem{Action: "create", Kind: "membership", Key: key},
+					apply: func(ctx context.Context, now time.Time) error {
+						if err := checkMembershipTarget(ctx, m.OrgPath, m.PositionKey); err != nil {
+							return err
+						}
+						return repo.InsertMembershipDoc(ctx, models.Membership{
+							ID:           bson.NewObjectID(),
+							UserID:       uid,
+							OrgPath:      m.OrgPath,
+							PositionKey:  m.PositionKey,
+							Active:       true,
+							OrgAncestors: utils.OrgAncestors(m.OrgPath),
+							CreatedAt:    now,
+							UpdatedAt:    now,
+						})
+					},
+				})
+			default:
+				id := cur.ID
+				ops = append(ops, orgTreeOp{
+					item: dto.OrgTreePlanItem{Action: "update", Kind: "membership", Key: key, Changes: []string{change("active", false, true)}},
+					apply: func(ctx context.Context, now time.Time) error {
+						if err := checkMembershipTarget(ctx, m.OrgPath, m.PositionKey); err != nil {
+							return err
+						}
+						return repo.SetDocFields(ctx, "memberships", id, bson.M{"active": true, "updated_at": now})
+					},
+				})
+			}
+		}
+	}
+
+	// --- units: archive (เฉพาะ unit บนสุดของแต่ละก้อน เพราะ archive ครอบ subtree อยู่แล้ว) ---
+	var toArchive []string
+	for _, u := range existing {
+		if u.OrgPath == "/" || u.Status != models.OrgStatusActive {
+			continue
+		}
+		if w, ok := want[u.OrgPath]; ok && w.Status != models.OrgStatusArchived {
+			continue
+		}
+		toArchive = append(toArchive, u.OrgPath)
+	}
+	sort.Strings(toArchive)
+	for i, p := range toArchive {
+		covered := false
+		for _, q := range toArchive[:i] {
+			if utils.IsUnderOrgPath(p, q) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			ops = append(ops, archiveOp(p, actor))
+		}
+	}
+
+	return ops, nil
+}
+
+// orgTreePosition ตำแหน่งตามเอกสาร (ยังไม่มี id/เวลา)
+func orgTreePosition(p dto.OrgTreePosition) models.Position {
+	status := p.Status
+	if status == "" {
+		status = models.PositionStatusActive
+	}
+	return models.Position{
+		Key:         p.Key,
+		Display:     p.Display,
+		Rank:        p.Rank,
+		Status:      status,
+		Constraints: models.Constraints{ExclusivePerOrg: p.ExclusivePerOrg},
+		Scope:       models.Scope{OrgPath: p.OrgPath, Inherit: p.Inherit},
+	}
+}
+
+// orgTreePositionFor เหมือน repo.FindPositionInScope แต่ใช้ตำแหน่งหลัง apply สำหรับ path ใต้ root
+// ส่วนตำแหน่งที่อยู่เหนือ root (เอกสารแก้ไม่ได้) ยังอ่านจากฐานข้อมูล
+func orgTreePositionFor(ctx context.Context, final map[string]models.Position, root, key, orgPath string) (*models.Position, error) {
+	paths := append([]string{orgPath}, utils.OrgAncestors(orgPath)...)
+	slices.Reverse(paths[1:])
+	for _, p := range paths {
+		if !utils.IsUnderOrgPath(p, root) {
+			break
+		}
+		if pos, ok := final[key+"@"+p]; ok && (p == orgPath || pos.Scope.Inherit) {
+			return &pos, nil
+		}
+	}
+	if root == "/" {
+		return nil, nil
+	}
+	pos, err := repo.FindPositionInScope(ctx, key, utils.OrgParent(root))
+	if err != nil || pos == nil || !pos.Scope.Inherit {
+		return nil, err
+	}
+	return pos, nil
+}
+
+// checkMembershipTarget ตรวจซ้ำตอน apply กับข้อมูลจริงใน transaction (unit/ตำแหน่งในแผนถูกเขียนไปก่อนแล้ว)
+func checkMembershipTarget(ctx context.Context, orgPath, positionKey string) error {
+	_, position, err := validatePositionTarget(ctx, orgPath, positionKey)
+	if err != nil {
+		return err
+	}
+	if err := ensurePositionOpen(position); err != nil {
+		return err
+	}
+	if !position.Constraints.ExclusivePerOrg {
+		return nil
+	}
+	if err := repo.TouchPositionGuard(ctx, orgPath, positionKey); err != nil {
+		return err
+	}
+	held, err := repo.ListCurrentHoldersAt(ctx, positionKey, []string{orgPath})
+	if err != nil {
+		return err
+	}
+	if len(held) > 0 {
+		return &MembershipConflictError{OrgPath: orgPath, PositionKey: positionKey, Holders: held}
+	}
+	return nil
+}
+
+func archiveOp(path string, actor bson.ObjectID) orgTreeOp {
+	return orgTreeOp{
+		item: dto.OrgTreePlanItem{Action: "archive", Kind: "unit", Key: path},
+		apply: func(ctx context.Context, now time.Time) error {
+			node, err := repo.FindByOrgPath(ctx, path)
+			if err != nil {
+				return err
+			}
+			if node == nil {
+				return ErrOrgUnitNotFound
+			}
+			ref := bson.NewObjectID()
+			counts, err := archiveOrgSubtree(ctx, actor, path, ref, now)
+			if err != nil {
+				return err
+			}
+			return repo.InsertOrgArchive(ctx, models.OrgArchive{
+				ID:         ref,
+				OrgPath:    path,
+				NodeID:     node.ID,
+				ArchivedBy: actor,
+				ArchivedAt: now,
+				Reason:     "org tree import",
+				Counts:     counts,
+			})
+		},
+	}
+}
+
+// policyIdentity key ของ policy ในเอกสาร: position@org_prefix (+ "!deny" และเงื่อนไขที่เรียงแล้ว)
+// allow กับ deny หรือ policy ที่เงื่อนไขต่างกันของตำแหน่งเดียวกันจึงเป็นคนละรายการ
+func policyIdentity(positionKey, orgPrefix, effect string, conditions map[string]string) string {
+	key := positionKey + "@" + orgPrefix
+	if normalizeEffect(effect) == models.PolicyEffectDeny {
+		key += "!deny"
+	}
+	if len(conditions) > 0 {
+		names := make([]string, 0, len(conditions))
+		for k := range conditions {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			key += "?" + k + "=" + conditions[k]
+		}
+	}
+	return key
+}
+
+// normalizeEffect allow เก็บเป็นค่าว่าง (policy เดิมไม่มี field นี้)
+func normalizeEffect(effect string) string {
+	if effect == models.PolicyEffectDeny {
+		return models.PolicyEffectDeny
+	}
+	return ""
+}
+
+func sameStringSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := map[string]int{}
+	for _, s := range a {
+		m[s]++
+	}
+	for _, s := range b {
+		m[s]--
+		if m[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func planFromOps(root string, ops []orgTreeOp) *dto.OrgTreePlan {
+	plan := &dto.OrgTreePlan{Root: root, Items: []dto.OrgTreePlanItem{}, Summary: map[string]int{}}
+	for _, op := range ops {
+		plan.Items = append(plan.Items, op.item)
+		plan.Summary[op.item.Action]++
+	}
+	return plan
+}
+
+// ImportOrgTree เทียบเอกสารกับฐานข้อมูลแล้วคืนแผน ถ้า apply=true จะทำตามแผนใน transaction เดียว
+// การ import เอกสารเดิมซ้ำจะได้แผนว่าง (idempotent)
+func ImportOrgTree(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, doc *dto.OrgTreeDocument, apply bool) (*dto.OrgTreePlan, error) {
+	if err := validateOrgTreeDocument(doc); err != nil {
+		return nil, err
+	}
+	if err := CanManageOrg(userPolicies, "organize:create", doc.Root); err != nil {
+		return nil, ErrOrgNoPermission
+	}
+
+	if !apply {
+		ops, err := diffOrgTree(ctx, doc, actor)
+		if err != nil {
+			return nil, err
+		}
+		return planFromOps(doc.Root, ops), nil
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var plan *dto.OrgTreePlan
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		// diff ใหม่ใน transaction เพื่อให้แผนตรงกับข้อมูล ณ ตอนเขียนจริง
+		ops, err := diffOrgTree(tx, doc, actor)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		for _, op := range ops {
+			if err := op.apply(tx, now); err != nil {
+				return nil, fmt.Errorf("%s %s %s: %w", op.item.Action, op.item.Kind, op.item.Key, err)
+			}
+		}
+		plan = planFromOps(doc.Root, ops)
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.Applied = true
+	if len(plan.Items) > 0 {
+		changebus.OrgUnitsChanged()
+		changebus.MembershipsChanged()
+		changebus.PoliciesChanged()
+	}
+	return plan, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var (
+	ErrRoleTemplateNotFound = errors.New("role template not found")
+	ErrRoleTemplateExists   = errors.New("role template already exists")
+	ErrRoleTemplateBuiltin  = errors.New("built-in role template cannot be deleted")
+)
+
+// PermissionCatalog catalog จัดกลุ่มตาม category (ลำดับตาม category แล้ว key)
+func PermissionCatalog(ctx context.Context) ([]dto.PermissionCategory, error) {
+	perms, err := repo.ListPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []dto.PermissionCategory{}
+	for _, p := range perms {
+		if n := len(out); n == 0 || out[n-1].Category != p.Category {
+			out = append(out, dto.PermissionCategory{
+				Category: p.Category,
+				Label:    p.CategoryLabel,
+				LabelTH:  p.CategoryLabelTH,
+			})
+		}
+		last := &out[len(out)-1]
+		last.Permissions = append(last.Permissions, p)
+	}
+	return out, nil
+}
+
+// ValidateCatalogActions action ของ policy/template ต้องมีอยู่ใน permission catalog
+func ValidateCatalogActions(ctx context.Context, actions []string) error {
+	unknown, err := repo.UnknownPermissionKeys(ctx, actions)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: actions not in the permission catalog: %s", ErrPolicyInvalid, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func ListRoleTemplates(ctx context.Context) ([]models.Role, error) {
+	return repo.ListRoleTemplates(ctx)
+}
+
+// ApplyRoleTemplate รวม action ของ template เข้ากับ actions ที่ส่งมา (ไม่ซ้ำ, คงลำดับ)
+func ApplyRoleTemplate(ctx context.Context, name string, actions []string) ([]string, error) {
+	r, err := repo.FindRoleTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrRoleTemplateNotFound
+	}
+	out := slices.Clone(actions)
+	for _, a := range r.Permissions {
+		if !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func validateRoleTemplate(ctx context.Context, body *dto.RoleTemplateDTO) error {
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Permissions) == 0 {
+		return fmt.Errorf("%w: name and permissions are required", ErrPolicyInvalid)
+	}
+	return ValidateCatalogActions(ctx, body.Permissions)
+}
+
+func CreateRoleTemplate(ctx context.Context, body dto.RoleTemplateDTO) (*models.Role, error) {
+	if err := validateRoleTemplate(ctx, &body); err != nil {
+		return nil, err
+	}
+	if r, err := repo.FindRoleTemplate(ctx, body.Name); err != nil {
+		return nil, err
+	} else if r != nil {
+		return nil, ErrRoleTemplateExists
+	}
+	now := time.Now().UTC()
+	r := &models.Role{
+		ID:          bson.NewObjectID(),
+		Name:        body.Name,
+		Label:       body.Label,
+		LabelTH:     body.LabelTH,
+		Permissions: body.Permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repo.InsertRoleTemplate(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateRoleTemplate แทนที่ label และ permissions ของ template (ไม่มีผลกับตำแหน่งที่เคยใช้ template ไปแล้ว)
+func UpdateRoleTemplate(ctx context.Context, name string, body dto.RoleTemplateDTO) (*models.Role, error) {
+	body.Name = name
+	if err := validateRoleTemplate(ctx, &body); err != nil {
+		return nil, err
+	}
+	r, err := repo.FindRoleTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrRoleTemplateNotFound
+	}
+	r.Label, r.LabelTH, r.Permissions = body.Label, body.LabelTH, body.Permissions
+	r.UpdatedAt = time.Now().UTC()
+	err = repo.UpdateRoleTemplate(ctx, name, bson.M{
+		"label":       r.Label,
+		"label_th":    r.LabelTH,
+		"permissions": r.Permissions,
+		"updated_at":  r.UpdatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func DeleteRoleTemplate(ctx context.Context, name string) error {
+	r, err := repo.FindRoleTemplate(ctx, name)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return ErrRoleTemplateNotFound
+	}
+	if r.Builtin {
+		return ErrRoleTemplateBuiltin
+	}
+	return repo.DeleteRoleTemplate(ctx, name)
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var (
+	ErrPolicyNotFound        = errors.New("policy not found")
+	ErrPolicyVersionNotFound = errors.New("policy version not found")
+	ErrPolicyNoPermission    = errors.New("no permission to manage this policy")
+)
+
+// PolicyOpBaseline สถานะของ policy ก่อนมีการบันทึกประวัติ (policy ที่สร้างพร้อมตำแหน่ง/นำเข้า/มีอยู่ก่อนแล้ว)
+// เก็บไว้ครั้งเดียวตอนมีการเปลี่ยนแปลงแรก เพื่อให้ rollback กลับไปสถานะตั้งต้นได้
+const PolicyOpBaseline = "baseline"
+
+// policyDiff รายการสิ่งที่เปลี่ยนระหว่าง before/after (รูปแบบเดียวกับ change ของ org tree)
+func policyDiff(before, after *models.Policy) []string {
+	switch {
+	case before == nil && after == nil:
+		return []string{}
+	case before == nil:
+		return []string{change("policy", "-", "created"), change("actions", "[]", after.Actions)}
+	case after == nil:
+		return []string{change("policy", "exists", "deleted")}
+	}
+
+	diff := []string{}
+	var added, removed []string
+	for _, a := range after.Actions {
+		if !slices.Contains(before.Actions, a) {
+			added = append(added, "+"+a)
+		}
+	}
+	for _, a := range before.Actions {
+		if !slices.Contains(after.Actions, a) {
+			removed = append(removed, "-"+a)
+		}
+	}
+	if len(added)+len(removed) > 0 {
+		diff = append(diff, fmt.Sprintf("actions: %v", append(added, removed...)))
+	}
+	if before.OrgPrefix != after.OrgPrefix {
+		diff = append(diff, change("org_prefix", before.OrgPrefix, after.OrgPrefix))
+	}
+	if before.PositionKey != after.PositionKey {
+		diff = append(diff, change("position_key", before.PositionKey, after.PositionKey))
+	}
+	if before.Enabled != after.Enabled {
+		diff = append(diff, change("enabled", before.Enabled, after.Enabled))
+	}
+	if before.Scope != after.Scope {
+		diff = append(diff, change("scope", before.Scope, after.Scope))
+	}
+	if normalizeEffect(before.Effect) != normalizeEffect(after.Effect) {
+		diff = append(diff, change("effect", before.Effect, after.Effect))
+	}
+	if !maps.Equal(before.Conditions, after.Conditions) {
+		diff = append(diff, change("conditions", before.Conditions, after.Conditions))
+	}
+	return diff
+}
+
+// recordPolicyVersion ต้องเรียกใน transaction เดียวกับการเขียน policy
+// ถ้ายังไม่มีประวัติและเป็นการแก้ policy ที่มีอยู่แล้ว จะเก็บ baseline เป็น version 1 ก่อน
+func recordPolicyVersion(ctx context.Context, op string, actor bson.ObjectID, before, after *models.Policy, rolledBackTo int) (*models.PolicyVersion, error) {
+	policyID := bson.ObjectID{}
+	if after != nil {
+		policyID = after.ID
+	} else if before != nil {
+		policyID = before.ID
+	}
+
+	latest, err := repo.LatestPolicyVersion(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	next := 1
+	if latest != nil {
+		next = latest.Version + 1
+	} else if before != nil {
+		base := &models.PolicyVersion{
+			ID:       bson.NewObjectID(),
+			PolicyID: policyID,
+			Version:  next,
+			Op:       PolicyOpBaseline,
+			At:       before.UpdatedAt,
+			After:    before,
+			Diff:     []string{},
+		}
+		if err := repo.InsertPolicyVersion(ctx, base); err != nil {
+			return nil, err
+		}
+		next++
+	}
+
+	v := &models.PolicyVersion{
+		ID:           bson.NewObjectID(),
+		PolicyID:     policyID,
+		Version:      next,
+		Op:           op,
+		Actor:        actor,
+		At:           time.Now().UTC(),
+		Before:       before,
+		After:        after,
+		Diff:         policyDiff(before, after),
+		RolledBackTo: rolledBackTo,
+	}
+	if err := repo.InsertPolicyVersion(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// withPolicyTx รันการเขียน policy + บันทึก version ใน transaction เดียว แล้วแจ้ง changebus หลัง commit
+func withPolicyTx(ctx context.Context, fn func(tx context.Context) error) error {
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		return nil, fn(tx)
+	})
+	if err != nil {
+		return err
+	}
+	changebus.PoliciesChanged()
+	return nil
+}
+
+// UpdatePolicyActions แทนที่ actions ของ policy ทั้งชุดและบันทึก version (actor = ผู้แก้)
+func UpdatePolicyActions(ctx context.Context, actor bson.ObjectID, policy *models.Policy, actions []string) error {
+	before := *policy
+	policy.Actions = actions
+	policy.UpdatedAt = time.Now().UTC()
+
+	return withPolicyTx(ctx, func(tx context.Context) error {
+		if err := UpdatedPolicy(tx, policy); err != nil {
+			return err
+		}
+		_, err := recordPolicyVersion(tx, models.PolicyOpUpdate, actor, &before, policy, 0)
+		return err
+	})
+}
+
+// DeletePoliciesVersioned ลบ policy ทีละตัวพร้อมบันทึก version op=delete (ไว้ rollback กลับมาได้)
+func DeletePoliciesVersioned(ctx context.Context, actor bson.ObjectID, targets []models.Policy) (int64, error) {
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	err := withPolicyTx(ctx, func(tx context.Context) error {
+		return deletePoliciesIn(tx, actor, targets)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(targets)), nil
+}
+
+// deletePoliciesIn เหมือน DeletePoliciesVersioned แต่ใช้ transaction ของผู้เรียก (ลบตำแหน่ง)
+func deletePoliciesIn(tx context.Context, actor bson.ObjectID, targets []models.Policy) error {
+	for i := range targets {
+		if err := repo.DeletePolicyByID(tx, targets[i].ID); err != nil {
+			return err
+		}
+		if _, err := recordPolicyVersion(tx, models.PolicyOpDelete, actor, &targets[i], nil, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// savePolicyIn เขียน policy ทั้งเอกสารแล้วบันทึก version ใน transaction ของผู้เรียก
+// before == nil = สร้างใหม่ (op=create) นอกนั้น op=update — ใช้กับการเขียน policy ที่ไม่ได้มาจาก handler ของ policy เอง
+// (เปลี่ยน key ของตำแหน่ง, import org tree) เพื่อให้ทุกการเปลี่ยนแปลงมีประวัติและ rollback ได้
+func savePolicyIn(tx context.Context, actor bson.ObjectID, before *models.Policy, after models.Policy) error {
+	op := models.PolicyOpUpdate
+	if before == nil {
+		op = models.PolicyOpCreate
+		if err := repo.InsertPolicy(tx, after); err != nil {
+			return err
+		}
+	} else if err := repo.ReplacePolicy(tx, after); err != nil {
+		return err
+	}
+	_, err := recordPolicyVersion(tx, op, actor, before, &after, 0)
+	return err
+}
+
+// versionBulkPolicyWrite รัน write (เขียน policy แบบ bulk: ย้าย/archive/restore หน่วยงาน) แล้วบันทึก version
+// ให้ทุก policy ใน before ที่เปลี่ยนจริง — ต้องเรียกใน transaction เดียวกับ write
+func versionBulkPolicyWrite(tx context.Context, op string, actor bson.ObjectID, before []models.Policy, write func() error) error {
+	if err := write(); err != nil {
+		return err
+	}
+	if len(before) == 0 {
+		return nil
+	}
+	ids := make([]bson.ObjectID, 0, len(before))
+	for _, p := range before {
+		ids = append(ids, p.ID)
+	}
+	after, err := repo.FindPoliciesByIDs(tx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[bson.ObjectID]*models.Policy, len(after))
+	for i := range after {
+		byID[after[i].ID] = &after[i]
+	}
+	for i := range before {
+		a, ok := byID[before[i].ID]
+		if !ok || len(policyDiff(&before[i], a)) == 0 {
+			continue
+		}
+		if _, err := recordPolicyVersion(tx, op, actor, &before[i], a, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PolicyHistory ประวัติของ policy ใหม่สุดก่อน (policy ที่ถูกลบไปแล้วก็ยังดูได้)
+func PolicyHistory(ctx context.Context, policyID bson.ObjectID) ([]models.PolicyVersion, error) {
+	list, err := repo.ListPolicyVersions(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		if p, err := repo.FindPolicyByID(ctx, policyID); err != nil {
+			return nil, err
+		} else if p == nil {
+			return nil, ErrPolicyNotFound
+		}
+	}
+	return list, nil
+}
+
+// RollbackPolicy คืน policy ให้เป็นสถานะหลัง version ที่เลือก (สร้างกลับมาใหม่ถ้าถูกลบไปแล้ว)
+// ต้องผ่าน CanManagePolicy ทั้งสถานะปัจจุบันและสถานะที่จะคืน, actions ต้องยังอยู่ใน catalog และ CanEditPolicy สำหรับ actions ที่จะคืนให้
+// การ rollback ถูกบันทึกเป็น version ใหม่ (op=rollback, rolled_back_to=version)
+func RollbackPolicy(ctx context.Context, userPolicies []models.Policy, actor, policyID bson.ObjectID, version int) (*models.PolicyVersion, error) {
+	target, err := repo.FindPolicyVersion(ctx, policyID, version)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrPolicyVersionNotFound
+	}
+	if target.After == nil {
+		return nil, fmt.Errorf("%w: version %d is a delete, pick an earlier version", ErrPolicyInvalid, version)
+	}
+
+	current, err := repo.FindPolicyByID(ctx, policyID)
+	if err != nil {
+		return nil, err
+	}
+	restored := *target.After
+	restored.ID = policyID
+	restored.UpdatedAt = time.Now().UTC()
+	if restored.ArchiveRef != nil {
+		// version ที่บันทึกตอน archive: ถูกปิดเพราะ archive ไม่ใช่เพราะตั้งใจปิด
+		restored.ArchiveRef = nil
+		restored.Enabled = true
+	}
+
+	if current != nil {
+		if err := CanManagePolicy(userPolicies, current); err != nil {
+			return nil, ErrPolicyNoPermission
+		}
+		if current.ArchiveRef != nil {
+			return nil, ErrOrgArchived
+		}
+		// position_key / org_prefix เปลี่ยนได้จากการเปลี่ยน key ของตำแหน่งและการย้ายหน่วยงานเท่านั้น
+		// -> คงตามปัจจุบัน ไม่คืนไปเป็น key/path ที่ไม่มีแล้ว
+		restored.PositionKey = current.PositionKey
+		restored.OrgPrefix = current.OrgPrefix
+	} else {
+		// ถูกลบไปแล้ว: หน่วยงานอาจถูกย้ายหรือ archive หลังจากนั้น
+		path, _, err := ResolveOrgPath(ctx, restored.OrgPrefix)
+		if err != nil {
+			return nil, err
+		}
+		restored.OrgPrefix = path
+		if path != "/" {
+			if err := EnsureOrgActive(ctx, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := CanManagePolicy(userPolicies, &restored); err != nil {
+		return nil, ErrPolicyNoPermission
+	}
+	// action ใน version เก่าอาจถูกเอาออกจาก catalog ไปแล้ว
+	if err := ValidateCatalogActions(ctx, restored.Actions); err != nil {
+		return nil, err
+	}
+	if err := CanEditPolicy(ctx, userPolicies, &restored, restored.Actions); err != nil {
+		return nil, err
+	}
+	if current == nil {
+		// ถูกลบไปแล้ว: ห้ามสร้างซ้ำกับ policy ตัวใหม่ที่มี position/org_prefix/effect/conditions เดียวกัน
+		if dup, err := repo.FindPolicyRule(ctx, restored); err != nil {
+			return nil, err
+		} else if dup != nil {
+			return nil, ErrPolicyExists
+		}
+	}
+
+	var v *models.PolicyVersion
+	err = withPolicyTx(ctx, func(tx context.Context) error {
+		if err := repo.ReplacePolicy(tx, restored); err != nil {
+			return err
+		}
+		var err error
+		v, err = recordPolicyVersion(tx, models.PolicyOpRollback, actor, current, &restored, version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/dto"
+	"main-webbase/internal/changebus"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+var ErrPositionNoPermission = errors.New("no permission to manage this position")
+var ErrPositionInvalid = errors.New("invalid position")
+var ErrPositionKeyTaken = errors.New("position key already exists in this scope")
+var ErrPositionDeprecated = errors.New("position is deprecated and cannot take new members")
+var ErrPositionInUse = errors.New("position still has active memberships")
+
+// ensurePositionOpen ตำแหน่ง deprecated รับ membership ใหม่ไม่ได้
+func ensurePositionOpen(p *models.Position) error {
+	if p != nil && p.Status == models.PositionStatusDeprecated {
+		return fmt.Errorf("%w: %q", ErrPositionDeprecated, p.Key)
+	}
+	return nil
+}
+
+// positionRef ที่ที่ position key ถูกอ้างถึง (collection, field ของ key, field ของ org path)
+type positionRef struct {
+	collection, keyField, pathField string
+}
+
+var (
+	refMemberships = positionRef{"memberships", "position_key", "org_path"}
+	refPolicies    = positionRef{"policies", "position_key", "org_prefix"}
+	refPosts       = positionRef{"posts", "postAs.position_key", "postAs.org_path"}
+	refEvents      = positionRef{"events", "postedas.position_key", "postedas.org_path"}
+	refRequests    = positionRef{"org_join_requests", "position_key", "org_path"}
+	refInvites     = positionRef{"org_invites", "position_key", "org_path"}
+)
+
+// positionPaths org path ใน ref ที่ key นี้ resolve มาที่ตำแหน่ง pos จริง
+// (ตัด unit ลูกที่มีตำแหน่ง key เดียวกันของตัวเองซ้อนอยู่ออก)
+func positionPaths(ctx context.Context, pos *models.Position, ref positionRef) ([]string, error) {
+	candidates, err := repo.DistinctPositionPaths(ctx, ref.collection, ref.keyField, ref.pathField, pos.Key, pos.Scope)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(candidates))
+	for _, path := range candidates {
+		p, err := repo.FindPositionInScope(ctx, pos.Key, path)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil && p.ID == pos.ID {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// authorizePosition ต้องมี position:manage ที่ scope ของตำแหน่ง และตำแหน่งต้อง rank ต่ำกว่าผู้ทำ
+func authorizePosition(ctx context.Context, userPolicies []models.Policy, pos *models.Position) (delegation, error) {
+	if err := CanManageOrg(userPolicies, "position:manage", pos.Scope.OrgPath); err != nil {
+		return delegation{}, ErrPositionNoPermission
+	}
+	d, err := delegationFor(ctx, userPolicies, "position:manage", pos.Scope.OrgPath)
+	if err != nil {
+		return d, err
+	}
+	return d, d.check(pos)
+}
+
+// UpdatePosition แก้ key/display/rank/constraints/scope/status
+// เปลี่ยน key แล้ว cascade ไปทุกที่ที่อ้างตำแหน่งนี้ (memberships, policies, posts, events, คำขอ, โค้ดเชิญ) ใน transaction เดียว
+func UpdatePosition(ctx context.Context, userPolicies []models.Policy, actor, id bson.ObjectID, body dto.PositionUpdateDTO) (*dto.PositionChangeReport, error) {
+	pos, err := repo.FindPositionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pos == nil {
+		return nil, ErrPositionNotFound
+	}
+	d, err := authorizePosition(ctx, userPolicies, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	memberPaths, err := positionPaths(ctx, pos, refMemberships)
+	if err != nil {
+		return nil, err
+	}
+	holders, err := repo.ListCurrentHoldersAt(ctx, pos.Key, memberPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	next := *pos
+	set := bson.M{}
+	if body.Key != nil {
+		key := strings.TrimSpace(*body.Key)
+		if key == "" {
+			return nil, fmt.Errorf("%w: key cannot be empty", ErrPositionInvalid)
+		}
+		next.Key = key
+	}
+	if body.Display != nil {
+		next.Display = body.Display
+		set["display"] = body.Display
+	}
+	if body.Rank != nil {
+		if !d.unlimited && *body.Rank >= d.rank {
+			return nil, fmt.Errorf("%w: rank %d is not below yours (%d)", ErrRankTooHigh, *body.Rank, d.rank)
+		}
+		next.Rank = *body.Rank
+		set["rank"] = next.Rank
+	}
+	if body.Constraints != nil {
+		if body.Constraints.ExclusivePerOrg && !pos.Constraints.ExclusivePerOrg {
+			perOrg := map[string]int{}
+			for _, m := range holders {
+				if perOrg[m.OrgPath]++; perOrg[m.OrgPath] > 1 {
+					return nil, fmt.Errorf("%w: %s has more than one holder", ErrPositionExclusive, m.OrgPath)
+				}
+			}
+		}
+		next.Constraints = *body.Constraints
+		set["constraints"] = next.Constraints
+	}
+	if body.Scope != nil {
+		scope := models.Scope{OrgPath: strings.TrimSpace(body.Scope.OrgPath), Inherit: body.Scope.Inherit}
+		if scope.OrgPath == "" {
+			return nil, fmt.Errorf("%w: scope.org_path cannot be empty", ErrPositionInvalid)
+		}
+		if scope.OrgPath != pos.Scope.OrgPath {
+			if err := CanManageOrg(userPolicies, "position:manage", scope.OrgPath); err != nil {
+				return nil, ErrPositionNoPermission
+			}
+		}
+		// ผู้ถือตำแหน่งปัจจุบันต้องยังอยู่ใน scope ใหม่
+		for _, m := range holders {
+			if m.OrgPath != scope.OrgPath && !(scope.Inherit && utils.IsUnderOrgPath(m.OrgPath, scope.OrgPath)) {
+				return nil, fmt.Errorf("%w: holder at %s would fall outside the new scope", ErrPositionInUse, m.OrgPath)
+			}
+		}
+		next.Scope = scope
+		set["scope"] = scope
+	}
+	if body.Status != nil {
+		switch *body.Status {
+		case models.PositionStatusActive, models.PositionStatusDeprecated:
+		default:
+			return nil, fmt.Errorf("%w: status must be active or deprecated", ErrPositionInvalid)
+		}
+		next.Status = *body.Status
+		set["status"] = next.Status
+	}
+	renamed := next.Key != pos.Key
+	if renamed || next.Scope.OrgPath != pos.Scope.OrgPath {
+		taken, err := repo.PositionKeyTakenAt(ctx, next.Key, next.Scope.OrgPath, pos.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, fmt.Errorf("%w: %q at %s", ErrPositionKeyTaken, next.Key, next.Scope.OrgPath)
+		}
+	}
+	if renamed {
+		set["key"] = next.Key
+	}
+
+	report := &dto.PositionChangeReport{Position: &next}
+	if len(set) == 0 {
+		return report, nil
+	}
+
+	// paths ของเอกสารอื่นคำนวณจากตำแหน่งเดิมก่อนเขียน
+	refs := map[positionRef][]string{refMemberships: memberPaths}
+	if renamed {
+		for _, ref := range []positionRef{refPolicies, refEvents, refRequests, refInvites, refPosts} {
+			if refs[ref], err = positionPaths(ctx, pos, ref); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		next.UpdatedAt = time.Now().UTC()
+		set["updatedAt"] = next.UpdatedAt
+		if err := repo.SetDocFields(tx, "positions", pos.ID, set); err != nil {
+			return nil, err
+		}
+		if !renamed {
+			return nil, nil
+		}
+		counts := []*int64{&report.Memberships, &report.Events, &report.Requests, &report.Invites}
+		for i, ref := range []positionRef{refMemberships, refEvents, refRequests, refInvites} {
+			n, err := repo.RenamePositionKeyAt(tx, ref.collection, ref.keyField, ref.pathField, pos.Key, next.Key, refs[ref])
+			if err != nil {
+				return nil, err
+			}
+			*counts[i] = n
+		}
+		// policy เปลี่ยนทีละตัวเพื่อให้มี version
+		policies, err := repo.ListPoliciesForKeyAt(tx, pos.Key, refs[refPolicies])
+		if err != nil {
+			return nil, err
+		}
+		for i := range policies {
+			after := policies[i]
+			after.PositionKey = next.Key
+			after.UpdatedAt = next.UpdatedAt
+			if err := savePolicyIn(tx, actor, &policies[i], after); err != nil {
+				return nil, err
+			}
+		}
+		report.Policies = int64(len(policies))
+		n, err := repo.RepointPosts(tx, pos, next.Key, pos.ID, refs[refPosts])
+		if err != nil {
+			return nil, err
+		}
+		report.Posts = n
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changebus.PoliciesChanged()
+	if renamed {
+		changebus.MembershipsChanged(holderIDs(holders)...)
+	}
+	return report, nil
+}
+
+// DeletePosition ลบตำแหน่งพร้อม policy ของมัน
+// ถ้ายังมีผู้ถืออยู่ต้องระบุ replacement: ย้ายผู้ถือ, โพสต์, event, คำขอและโค้ดเชิญไปที่ตำแหน่งแทน
+// (ผู้ที่ถือตำแหน่งแทนอยู่แล้วที่ org เดียวกันจะถูกปิด membership เดิมแทนการย้าย)
+func DeletePosition(ctx context.Context, userPolicies []models.Policy, actor, id bson.ObjectID, replacement string) (*dto.PositionChangeReport, error) {
+	pos, err := repo.FindPositionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pos == nil {
+		return nil, ErrPositionNotFound
+	}
+	d, err := authorizePosition(ctx, userPolicies, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[positionRef][]string{}
+	for _, ref := range []positionRef{refMemberships, refPolicies, refEvents, refRequests, refInvites, refPosts} {
+		if refs[ref], err = positionPaths(ctx, pos, ref); err != nil {
+			return nil, err
+		}
+	}
+	holders, err := repo.ListCurrentHoldersAt(ctx, pos.Key, refs[refMemberships])
+	if err != nil {
+		return nil, err
+	}
+
+	replacement = strings.TrimSpace(replacement)
+	report := &dto.PositionChangeReport{Deleted: true, Replacement: replacement}
+	if replacement == "" && len(holders) > 0 {
+		return nil, fmt.Errorf("%w: %d active holder(s); pass replacement=<position_key> to migrate them", ErrPositionInUse, len(holders))
+	}
+
+	var target *models.Position
+	var migrate, end []bson.ObjectID
+	if replacement != "" {
+		if replacement == pos.Key {
+			return nil, fmt.Errorf("%w: replacement must be a different position", ErrPositionInvalid)
+		}
+		if target, err = repo.FindPositionInScope(ctx, replacement, pos.Scope.OrgPath); err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return nil, fmt.Errorf("%w: %q is not available at %s", ErrPositionOutOfScope, replacement, pos.Scope.OrgPath)
+		}
+		if err := ensurePositionOpen(target); err != nil {
+			return nil, err
+		}
+		if err := d.check(target); err != nil {
+			return nil, err
+		}
+
+		// ตรวจทีละ org: ตำแหน่งแทนต้องใช้ได้ที่นั่น และไม่ชน exclusive
+		byOrg := map[string][]models.Membership{}
+		for _, m := range holders {
+			byOrg[m.OrgPath] = append(byOrg[m.OrgPath], m)
+		}
+		for orgPath, ms := range byOrg {
+			rp, err := repo.FindPositionInScope(ctx, replacement, orgPath)
+			if err != nil {
+				return nil, err
+			}
+			if rp == nil {
+				return nil, fmt.Errorf("%w: %q is not available at %s", ErrPositionOutOfScope, replacement, orgPath)
+			}
+			current, err := repo.ListCurrentHoldersAt(ctx, replacement, []string{orgPath})
+			if err != nil {
+				return nil, err
+			}
+			already := map[bson.ObjectID]bool{}
+			for _, h := range current {
+				already[h.UserID] = true
+			}
+			moving := 0
+			for _, m := range ms {
+				if already[m.UserID] {
+					end = append(end, m.ID)
+					continue
+				}
+				migrate = append(migrate, m.ID)
+				moving++
+			}
+			if rp.Constraints.ExclusivePerOrg && len(current)+moving > 1 {
+				return nil, fmt.Errorf("%w: %q at %s would have %d holders", ErrPositionExclusive, replacement, orgPath, len(current)+moving)
+			}
+		}
+	}
+
+	session, err := database.DB.Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(tx context.Context) (any, error) {
+		if target != nil {
+			if err := repo.SetMembershipPosition(tx, migrate, replacement); err != nil {
+				return nil, err
+			}
+			if err := repo.DeactivateMembershipsByID(tx, end, models.MembershipEndReplaced); err != nil {
+				return nil, err
+			}
+			report.Memberships, report.Ended = int64(len(migrate)), int64(len(end))
+
+			counts := []*int64{&report.Events, &report.Requests, &report.Invites}
+			for i, ref := range []positionRef{refEvents, refRequests, refInvites} {
+				n, err := repo.RenamePositionKeyAt(tx, ref.collection, ref.keyField, ref.pathField, pos.Key, replacement, refs[ref])
+				if err != nil {
+					return nil, err
+				}
+				*counts[i] = n
+			}
+			n, err := repo.RepointPosts(tx, pos, replacement, target.ID, refs[refPosts])
+			if err != nil {
+				return nil, err
+			}
+			report.Posts = n
+		}
+		policies, err := repo.ListPoliciesForKeyAt(tx, pos.Key, refs[refPolicies])
+		if err != nil {
+			return nil, err
+		}
+		if err := deletePoliciesIn(tx, actor, policies); err != nil {
+			return nil, err
+		}
+		report.Policies = int64(len(policies))
+		return nil, repo.DeletePositionByID(tx, pos.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changebus.PoliciesChanged()
+	if len(holders) > 0 {
+		changebus.MembershipsChanged(holderIDs(holders)...)
+	}
+	return report, nil
+}
+
+func holderIDs(ms []models.Membership) []bson.ObjectID {
+	ids := make([]bson.ObjectID, 0, len(ms))
+	for _, m := range ms {
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -11,9 +14,12 @@ import (
 	"main-webbase/internal/models"
 	"main-webbase/database"
 	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
 )
 
-func CreatePositionWithPolicy(body dto.PositionCreateDTO, ctx context.Context) (*models.Position, *models.Policy, error) {
+// CreatePositionWithPolicy ผู้สร้างต้องถือตำแหน่ง rank สูงกว่าตำแหน่งใหม่ (position:manage ที่ scope นั้น)
+// และ policy ของตำแหน่งให้ได้เฉพาะ action ที่ผู้สร้างมีอยู่แล้ว (เหมือน CanEditPolicy) — ตรวจก่อนบันทึก
+func CreatePositionWithPolicy(userPolicies []models.Policy, body dto.PositionCreateDTO, ctx context.Context) (*models.Position, *models.Policy, error) {
 	now := time.Now().UTC()
 
 	if body.Key == "" {
@@ -22,6 +28,17 @@ func CreatePositionWithPolicy(body dto.PositionCreateDTO, ctx context.Context) (
 	if body.Scope == nil || body.Scope.OrgPath == "" {
 		return nil, nil, errors.New("scope org_path cannot be empty")
 	}
+	// preset จาก role template รวมกับ actions ที่ส่งมาเอง
+	if body.Policy.Role != "" {
+		actions, err := ApplyRoleTemplate(ctx, body.Policy.Role, body.Policy.Actions)
+		if err != nil {
+			return nil, nil, err
+		}
+		body.Policy.Actions = actions
+	}
+	if err := ValidateCatalogActions(ctx, body.Policy.Actions); err != nil {
+		return nil, nil, err
+	}
 
 	position := &models.Position{
 		ID:          bson.NewObjectID(),
@@ -35,11 +52,6 @@ func CreatePositionWithPolicy(body dto.PositionCreateDTO, ctx context.Context) (
 		UpdatedAt:   now,
 	}
 
-	positionCol := database.DB.Collection("positions")
-	if _, err := positionCol.InsertOne(ctx, position); err != nil {
-		return nil, nil, err
-	}
-
 	policy := &models.Policy{
 		ID:          bson.NewObjectID(),
 		PositionKey: position.Key,
@@ -51,6 +63,22 @@ func CreatePositionWithPolicy(body dto.PositionCreateDTO, ctx context.Context) (
 		UpdatedAt:   now,
 	}
 
+	d, err := delegationFor(ctx, userPolicies, "position:manage", position.Scope.OrgPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := d.check(position); err != nil {
+		return nil, nil, err
+	}
+	if err := canEditPolicyOf(ctx, userPolicies, position, policy, policy.Actions); err != nil {
+		return nil, nil, err
+	}
+
+	positionCol := database.DB.Collection("positions")
+	if _, err := positionCol.InsertOne(ctx, position); err != nil {
+		return nil, nil, err
+	}
+
 	policyCol := database.DB.Collection("policies")
 	if _, err := policyCol.InsertOne(ctx, policy); err != nil {
 		// Rollback if error
@@ -61,6 +89,10 @@ func CreatePositionWithPolicy(body dto.PositionCreateDTO, ctx context.Context) (
 	return position, policy, nil
 }
 
+// MyUserPolicy policy ที่มีผลกับผู้ใช้ตาม membership ปัจจุบัน
+// policy ของตำแหน่งที่กำหนดไว้ที่ unit แม่ (ancestor) สืบทอดลงมาถึงผู้ถือตำแหน่งใน unit ลูกด้วย:
+// OrgPrefix ของผลลัพธ์ถูกย้ายมาอยู่ที่ org ของ membership (scope exact/subtree นับจากตรงนั้น)
+// และจำ org_prefix เดิมไว้ใน InheritedFrom — ผลลัพธ์เรียงตาม org_prefix แล้ว _id ให้ผลคงที่
 func MyUserPolicy(ctx context.Context, uid string) ([]models.Policy, error) {
 	memberships, err := repo.GetUserMemberships(ctx, uid)
 	if err != nil {
@@ -75,7 +107,7 @@ func MyUserPolicy(ctx context.Context, uid string) ([]models.Policy, error) {
 	for _, m := range memberships {
 		orFilters = append(orFilters, bson.M{
 			"position_key": m.PositionKey,
-			"org_prefix":   m.OrgPath,
+			"org_prefix":   bson.M{"$in": append(utils.OrgAncestors(m.OrgPath), m.OrgPath)},
 		})
 	}
 
@@ -86,11 +118,31 @@ func MyUserPolicy(ctx context.Context, uid string) ([]models.Policy, error) {
 	}
 	defer cursor.Close(ctx)
 
-	var policies []models.Policy
-	if err := cursor.All(ctx, &policies); err != nil {
+	var defined []models.Policy
+	if err := cursor.All(ctx, &defined); err != nil {
 		return nil, err
 	}
 
+	policies := make([]models.Policy, 0, len(defined))
+	for _, m := range memberships {
+		for _, p := range defined {
+			if p.PositionKey != m.PositionKey || !utils.IsUnderOrgPath(m.OrgPath, p.OrgPrefix) {
+				continue
+			}
+			if p.OrgPrefix != m.OrgPath {
+				p.InheritedFrom = p.OrgPrefix
+				p.OrgPrefix = m.OrgPath
+			}
+			policies = append(policies, p)
+		}
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].OrgPrefix != policies[j].OrgPrefix {
+			return policies[i].OrgPrefix < policies[j].OrgPrefix
+		}
+		return policies[i].ID.Hex() < policies[j].ID.Hex()
+	})
+
 	return policies, nil
 }
 
@@ -106,4 +158,69 @@ func UpdatedPolicy(ctx context.Context, policy *models.Policy) (error) {
 		}},
 	)
 	return err
-}
\ No newline at end of file
+}
+var ErrPolicyInvalid = errors.New("invalid policy")
+var ErrPolicyExists = errors.New("policy with the same position, org_prefix, effect and conditions already exists")
+
+// CreatePolicyRule เพิ่ม policy แยกจาก policy หลักของตำแหน่ง (deny หรือ allow แบบมีเงื่อนไข)
+// action ต้องอยู่ใน permission catalog — บันทึกเป็น version แรก (op=create) ของ policy
+func CreatePolicyRule(ctx context.Context, userPolicies []models.Policy, actor bson.ObjectID, body dto.PolicyRuleCreateDTO) (*models.Policy, error) {
+	body.PositionKey = strings.TrimSpace(body.PositionKey)
+	body.OrgPrefix = strings.TrimSpace(body.OrgPrefix)
+	if body.PositionKey == "" || body.OrgPrefix == "" {
+		return nil, fmt.Errorf("%w: position_key and org_prefix are required", ErrPolicyInvalid)
+	}
+	if body.Scope != "exact" && body.Scope != "subtree" {
+		return nil, fmt.Errorf("%w: scope must be exact or subtree", ErrPolicyInvalid)
+	}
+	if body.Effect != models.PolicyEffectAllow && body.Effect != models.PolicyEffectDeny {
+		return nil, fmt.Errorf("%w: effect must be allow or deny", ErrPolicyInvalid)
+	}
+	if len(body.Actions) == 0 {
+		return nil, fmt.Errorf("%w: actions are required", ErrPolicyInvalid)
+	}
+	if err := ValidateCatalogActions(ctx, body.Actions); err != nil {
+		return nil, err
+	}
+	if body.Effect == models.PolicyEffectAllow && len(body.Conditions) == 0 {
+		return nil, fmt.Errorf("%w: unconditional allow belongs to the position policy (PUT /policies)", ErrPolicyInvalid)
+	}
+	if ok, err := repo.PositionKeyExists(ctx, body.PositionKey); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrPositionNotFound
+	}
+
+	now := time.Now().UTC()
+	policy := models.Policy{
+		ID:          bson.NewObjectID(),
+		PositionKey: body.PositionKey,
+		Scope:       body.Scope,
+		OrgPrefix:   body.OrgPrefix,
+		Actions:     body.Actions,
+		Enabled:     true,
+		Effect:      normalizeEffect(body.Effect),
+		Conditions:  body.Conditions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := CanEditPolicy(ctx, userPolicies, &policy, policy.Actions); err != nil {
+		return nil, err
+	}
+	if dup, err := repo.FindPolicyRule(ctx, policy); err != nil {
+		return nil, err
+	} else if dup != nil {
+		return nil, ErrPolicyExists
+	}
+	err := withPolicyTx(ctx, func(tx context.Context) error {
+		if err := repo.InsertPolicy(tx, policy); err != nil {
+			return err
+		}
+		_, err := recordPolicyVersion(tx, models.PolicyOpCreate, actor, nil, &policy, 0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/internal/authz"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	"main-webbase/internal/utils"
+)
+
+// Rank: ค่ามาก = ตำแหน่งสูงกว่า ผู้ทำมอบ/ถอด/แก้ policy ได้เฉพาะตำแหน่งที่ rank ต่ำกว่าตัวเองอย่างเคร่งครัด
+var ErrRankTooHigh = errors.New("position rank must be lower than your own")
+
+// delegation เพดาน rank ของผู้ทำสำหรับ action หนึ่งที่ org หนึ่ง
+type delegation struct {
+	rank      int
+	unlimited bool // ถือตำแหน่งที่ "/" (root) → ไม่จำกัด
+}
+
+func (d delegation) allows(p *models.Position) bool {
+	return d.unlimited || p.Rank < d.rank
+}
+
+func (d delegation) check(p *models.Position) error {
+	if d.allows(p) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q has rank %d, yours is %d", ErrRankTooHigh, p.Key, p.Rank, d.rank)
+}
+
+// delegationFor หา rank สูงสุดของตำแหน่งที่ให้ action ครอบคลุม orgPath
+// (policy จาก MyUserPolicy มี OrgPrefix = org ของ membership ผู้ถือ จึงหาตำแหน่งได้ตรงตัว)
+func delegationFor(ctx context.Context, userPolicies []models.Policy, action, orgPath string) (delegation, error) {
+	d := delegation{rank: -1 << 31}
+	seen := map[string]bool{}
+	for _, p := range authz.GrantingPolicies(userPolicies, action, authz.Target{OrgPath: orgPath}) {
+		if p.OrgPrefix == "/" {
+			return delegation{unlimited: true}, nil
+		}
+		k := p.PositionKey + "@" + p.OrgPrefix
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		pos, err := repo.FindPositionInScope(ctx, p.PositionKey, p.OrgPrefix)
+		if err != nil {
+			return d, err
+		}
+		if pos != nil && pos.Rank > d.rank {
+			d.rank = pos.Rank
+		}
+	}
+	return d, nil
+}
+
+// CanDelegatePosition ผู้ทำต้องถือตำแหน่ง rank สูงกว่าตำแหน่งเป้าหมาย ใน subtree ที่ให้ action นี้
+func CanDelegatePosition(ctx context.Context, userPolicies []models.Policy, action, orgPath, positionKey string) error {
+	d, err := delegationFor(ctx, userPolicies, action, orgPath)
+	if err != nil {
+		return err
+	}
+	if d.unlimited {
+		return nil
+	}
+	pos, err := repo.FindPositionInScope(ctx, positionKey, orgPath)
+	if err != nil {
+		return err
+	}
+	if pos == nil {
+		return ErrPositionNotFound
+	}
+	return d.check(pos)
+}
+
+// PositionRanks rank ของ (position_key, org_path) หลายคู่ — cache ในตัวเพื่อลด query
+type PositionRanks struct {
+	ctx   context.Context
+	cache map[string]int
+}
+
+func NewPositionRanks(ctx context.Context) *PositionRanks {
+	return &PositionRanks{ctx: ctx, cache: map[string]int{}}
+}
+
+// Of rank ของตำแหน่งที่ใช้ได้ที่ orgPath (ไม่พบ = 0)
+func (r *PositionRanks) Of(positionKey, orgPath string) int {
+	k := positionKey + "@" + orgPath
+	if v, ok := r.cache[k]; ok {
+		return v
+	}
+	rank := 0
+	if p, err := repo.FindPositionInScope(r.ctx, positionKey, orgPath); err == nil && p != nil {
+		rank = p.Rank
+	}
+	r.cache[k] = rank
+	return rank
+}
+
+// UserRankNear rank สูงสุดของผู้ใช้จาก membership ปัจจุบันที่อยู่ใน orgPath, ancestor หรือ unit ลูก
+// ใช้จัดลำดับคน (เช่น organizer ของ event) ตามตำแหน่งในหน่วยงานนั้น
+func (r *PositionRanks) UserRankNear(userID bson.ObjectID, orgPath string) int {
+	filter := utils.CurrentMembershipFilter(time.Now())
+	filter["user_id"] = userID
+	cur, err := database.DB.Collection("memberships").Find(r.ctx, filter)
+	if err != nil {
+		return 0
+	}
+	defer cur.Close(r.ctx)
+
+	var ms []models.Membership
+	if err := cur.All(r.ctx, &ms); err != nil {
+		return 0
+	}
+	best := 0
+	for _, m := range ms {
+		if !utils.IsUnderOrgPath(orgPath, m.OrgPath) && !utils.IsUnderOrgPath(m.OrgPath, orgPath) {
+			continue
+		}
+		if rank := r.Of(m.PositionKey, m.OrgPath); rank > best {
+			best = rank
+		}
+	}
+	return best
+}
+
+var ErrActionNotHeld = errors.New("cannot grant an action you do not hold yourself")
+
+// CanEditPolicy ใช้ก่อนสร้าง/แก้/ลบ policy: ตำแหน่งของ policy ต้อง rank ต่ำกว่าผู้แก้
+// และ allow ที่จะให้ต้องเป็น action ที่ผู้แก้มีอยู่แล้วที่ org นั้น (กัน vice-head ยกสิทธิ์ head ให้ตำแหน่งอื่น)
+func CanEditPolicy(ctx context.Context, userPolicies []models.Policy, target *models.Policy, grant []string) error {
+	return canEditPolicyOf(ctx, userPolicies, nil, target, grant)
+}
+
+// canEditPolicyOf เหมือน CanEditPolicy แต่ pos != nil = ตำแหน่งของ policy ที่ยังไม่ถูกบันทึก
+// (สร้างตำแหน่งพร้อม policy ใน CreatePositionWithPolicy)
+func canEditPolicyOf(ctx context.Context, userPolicies []models.Policy, pos *models.Position, target *models.Policy, grant []string) error {
+	d, err := delegationFor(ctx, userPolicies, "policy:manage", target.OrgPrefix)
+	if err != nil {
+		return err
+	}
+	if d.unlimited {
+		return nil
+	}
+	if pos == nil {
+		if pos, err = repo.FindPositionInScope(ctx, target.PositionKey, target.OrgPrefix); err != nil {
+			return err
+		}
+	}
+	if pos == nil {
+		return ErrPositionNotFound
+	}
+	if err := d.check(pos); err != nil {
+		return err
+	}
+	if target.IsDeny() {
+		return nil
+	}
+	s := authz.Subject{Policies: userPolicies}
+	for _, a := range grant {
+		if !authz.Authorize(s, a, authz.Target{OrgPath: target.OrgPrefix}).Allowed {
+			return fmt.Errorf("%w: %s", ErrActionNotHeld, a)
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"main-webbase/dto"
+	"main-webbase/internal/authz"
 	"main-webbase/internal/models"
 	repo "main-webbase/internal/repository"
 	u "main-webbase/internal/utils"
@@ -25,7 +26,11 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 	now := time.Now().UTC()
 
 	var resp dto.PostResponse
-	postsCol := db.Collection("posts")
+
+	// หน่วยงานที่ถูก archive โพสต์ในนามไม่ได้
+	if err := EnsureOrgActive(ctx, body.PostAs.OrgPath); errors.Is(err, ErrOrgArchived) {
+		return resp, err
+	}
 
 	// 0) เตรียม RolePathID / PositionID จาก DTO (lookup ด้วย org_path, position_key)
 	rolePathID, err := repo.ResolveOrgNodeIDByPath(db, body.PostAs.OrgPath, ctx)
@@ -44,6 +49,18 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 		return resp, err
 	}
 
+	// 0.05) ร่าง / ตั้งเวลา / เผยแพร่ทันที
+	status, publishAt, err := parsePostSchedule(body.Status, body.PublishAt, now)
+	if err != nil {
+		return resp, err
+	}
+
+	// 0.06) access = org → กลุ่มผู้เห็นแบบ event เก็บไว้ในโพสต์
+	targeting, err := postTargeting(ctx, db, body.Visibility)
+	if err != nil {
+		return resp, err
+	}
+
 	// 0.1) เตรียม tags จาก PostText
 	tagsSlice := u.ExtractHashtags(body.PostText)
 
@@ -51,7 +68,7 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 	if err != nil {
 		return resp, ErrUserIDInvalid
 	}
-	// 1) Insert post
+	// 1) เตรียม post
 	// --- Generate tags string from org_path ---
 	orgPath := body.PostAs.OrgPath
 	var tags string
@@ -68,6 +85,7 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 
 	// --- Create Post object ---
 	post := models.Post{
+		ID:           bson.NewObjectID(),
 		UserID:       UserIDs,
 		RolePathID:   rolePathID,
 		PositionID:   positionID,
@@ -84,59 +102,29 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 		UpdatedAt:    now,
 		LikeCount:    0,
 		CommentCount: 0,
-		Status:       "active",
+		Status:       status,
+		PublishAt:    publishAt,
+		Targeting:    targeting,
 	}
 
-	res, err := postsCol.InsertOne(ctx, post)
-	if err != nil {
-		return resp, err
-	}
-	fmt.Println("🆗 post created with ID:", res.InsertedID)
-	post.ID = res.InsertedID.(bson.ObjectID)
-
-	// helper: rollback ทุกอย่างที่อาจสร้างไปแล้ว (best-effort)
-	rollback := func() {
-		_, _ = postsCol.DeleteOne(ctx, bson.M{"_id": post.ID})
-		_, _ = db.Collection("post_categories").DeleteMany(ctx, bson.M{"post_id": post.ID})
-		_, _ = db.Collection("post_role_visible").DeleteMany(ctx, bson.M{"post_id": post.ID})
-		_, _ = db.Collection("hashtags").DeleteMany(ctx, bson.M{"post_id": post.ID})
-	}
-
-	// 2) hashtags (non-critical; ลงทั้งตาราง post_hashtag และเก็บ string ใน post.Tags แล้ว)
-	if err := repo.RebuildHashtags(db, post, body.PostText, ctx); err != nil {
-		fmt.Println("⚠️ hashtag insert failed:", err)
-	}
-
-	// 3) categories (critical)
-	if len(body.CategoryIDs) > 0 {
-		if err := repo.ReplaceCategories(db, post.ID, body.CategoryIDs, ctx); err != nil {
-			rollback()
-			return resp, err
-		}
-	}
-
-	// 4) role visibility (critical): ACCESS=private → บันทึกลง post_rolevisible โดยแปลง org_path → node_id (ObjectID)
-	if body.Visibility.Access == "private" {
-		if err := repo.ReplaceRoleVisibility(db, post.ID, body.Visibility, ctx); err != nil {
-			rollback()
-			return resp, err
-		}
-	}
-
-	// 5) ดึง user info (critical)
-	colUsers := db.Collection("users")
-	userInfo, err := repo.FindUserInfo(colUsers, UserIDs, ctx)
+	// 2) ดึง user info ก่อนเขียน (อ่านอย่างเดียว ไม่ต้องอยู่ใน transaction)
+	userInfo, err := repo.FindUserInfo(db.Collection("users"), UserIDs, ctx)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			rollback()
 			return resp, ErrUserNotFound
 		}
-		rollback()
+		return resp, err
+	}
+
+	// 3) post + hashtags + categories + role visibility ใน transaction เดียว
+	steps := createPostSteps(db, post, body)
+	if err := runPostSteps(ctx, client, "create post", steps...); err != nil {
 		return resp, err
 	}
 
 	// 6) ประกอบ response (ส่ง string id กลับตาม requirement)
 	resp = dto.PostResponse{
+		PostID:       post.ID.Hex(),
 		UserID:       UserID,
 		Name:         userInfo.FirstName, // แก้เป็น display name ที่ต้องการได้
 		Username:     userInfo.Username,
@@ -151,13 +139,44 @@ func CreatePostWithMeta(client *mongo.Client, UserID string, body dto.CreatePost
 		OrgOfContent: body.PostAs.OrgPath, // ส่ง org_path ให้ FE
 		CreatedAt:    post.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:    post.UpdatedAt.Format(time.RFC3339),
-		Status:       "active",
+		Status:       status,
+		PublishAt:    formatPublishAt(publishAt),
 	}
 
 	return resp, nil
 }
 
-func GetPostDetail(ctx context.Context, db *mongo.Database, loginUserID bson.ObjectID, postID bson.ObjectID) (dto.PostResponse, error) {
+// createPostSteps ขั้นของการสร้างโพสต์ (ขั้นที่ไม่จำเป็นตาม status/body ไม่ถูกใส่)
+func createPostSteps(db *mongo.Database, post models.Post, body dto.CreatePostDTO) []postStep {
+	steps := []postStep{
+		{"insert post", func(tx context.Context) error {
+			_, err := db.Collection("posts").InsertOne(tx, post)
+			return err
+		}},
+	}
+	// ร่าง/ตั้งเวลา: index hashtag (และนับ trending) ตอนเผยแพร่เท่านั้น
+	if post.Status == models.PostStatusActive {
+		steps = append(steps, postStep{"hashtags", func(tx context.Context) error {
+			return repo.RebuildHashtags(db, post, body.PostText, tx)
+		}})
+	}
+	if len(body.CategoryIDs) > 0 {
+		steps = append(steps, postStep{"categories", func(tx context.Context) error {
+			return repo.ReplaceCategories(db, post.ID, body.CategoryIDs, tx)
+		}})
+	}
+	// ACCESS=private → บันทึกลง post_role_visibility โดยแปลง org_path → node_id (ObjectID)
+	if body.Visibility.Access == "private" {
+		steps = append(steps, postStep{"role visibility", func(tx context.Context) error {
+			return repo.ReplaceRoleVisibility(db, post.ID, body.Visibility, tx)
+		}})
+	}
+	return steps
+}
+
+// GetPostDetail ร่าง/โพสต์ตั้งเวลา เห็นได้เฉพาะผู้ที่แก้โพสต์นั้นได้ (ผู้เขียน, co-manager ของ org) — คนอื่นได้ not found
+// โพสต์ที่มี targeting ใช้กฎเดียวกับฟีด (repo.PostAudienceMatch) และรายชื่อ allow/deny ส่งให้เฉพาะผู้ที่แก้โพสต์ได้
+func GetPostDetail(ctx context.Context, db *mongo.Database, viewer authz.Subject, roles []models.ViewerRole, postID bson.ObjectID) (dto.PostResponse, error) {
 	var out dto.PostResponse
 
 	colPosts := db.Collection("posts")
@@ -169,12 +188,21 @@ func GetPostDetail(ctx context.Context, db *mongo.Database, loginUserID bson.Obj
 	colLikes := db.Collection("like")
 
 	// 1) post
-	post, err := repo.FindPostByID(colPosts, postID, ctx)
+	post, err := repo.FindPostVisibleTo(ctx, colPosts, postID, viewer.UserID, roles)
 	if err != nil {
 		return out, fmt.Errorf("post not found or fetch error: %w", err)
 	}
 
-	if post.Status != "active" {
+	t := authz.Target{OrgPath: post.PostAs.OrgPath, Owners: []bson.ObjectID{post.UserID}, Attrs: map[string]string{"status": post.Status}}
+	canEdit := authz.Authorize(viewer, "post:update", t).Allowed
+
+	switch post.Status {
+	case models.PostStatusActive:
+	case models.PostStatusDraft, models.PostStatusScheduled:
+		if !canEdit {
+			return out, fmt.Errorf("post is not active: %w", mongo.ErrNoDocuments)
+		}
+	default:
 		return out, fmt.Errorf("post is not active")
 	}
 
@@ -209,6 +237,12 @@ func GetPostDetail(ctx context.Context, db *mongo.Database, loginUserID bson.Obj
 	if err != nil {
 		return out, fmt.Errorf("fetch visibility: %w", err)
 	}
+	if post.Targeting != nil {
+		vis = targetingVisibility(post.Targeting)
+		if !canEdit {
+			vis.AllowUserIDs, vis.DenyUserIDs = nil, nil
+		}
+	}
 
 	// 6) categories
 	catIDs, err := repo.FindCategoryIDs(colCats, post.ID, ctx)
@@ -216,10 +250,11 @@ func GetPostDetail(ctx context.Context, db *mongo.Database, loginUserID bson.Obj
 		return out, fmt.Errorf("fetch categories: %w", err)
 	}
 	// 7) is_like
-	isLiked, err := repo.CheckIsLiked(ctx, colLikes, loginUserID, post.ID, "post")
+	isLiked, err := repo.CheckIsLiked(ctx, colLikes, viewer.UserID, post.ID, "post")
 
 	// 8) map -> response
 	out = dto.PostResponse{
+		PostID:       post.ID.Hex(),
 		UserID:       post.UserID.Hex(),
 		Name:         fullName,
 		Username:     user.Username,
@@ -238,7 +273,9 @@ func GetPostDetail(ctx context.Context, db *mongo.Database, loginUserID bson.Obj
 		OrgOfContent: orgPath,
 		CreatedAt:    post.CreatedAt.UTC().Format(time.RFC3339),
 		UpdatedAt:    post.UpdatedAt.UTC().Format(time.RFC3339),
+		EditedAt:     formatEditedAt(post.EditedAt),
 		Status:       post.Status,
+		PublishAt:    formatPublishAt(post.PublishAt),
 		Isliked:   isLiked,
 	}
 	return out, nil
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var ErrPostInvalidAudience = errors.New("invalid post audience")
+
+// postTargeting แปลง visibility แบบ access = org เป็น models.Visibility ที่เก็บในโพสต์ (nil = public/private แบบเดิม)
+// อ่านอย่างเดียว — เรียกก่อนเปิด transaction
+func postTargeting(ctx context.Context, db *mongo.Database, v dto.Visibility) (*models.Visibility, error) {
+	if v.Access != "org" {
+		return nil, nil
+	}
+	t := &models.Visibility{
+		Access:           "org",
+		IncludePositions: trimNonEmpty(v.IncludePositions),
+		ExcludePositions: trimNonEmpty(v.ExcludePositions),
+	}
+	for _, a := range v.Targets {
+		switch a.Scope {
+		case "":
+			a.Scope = "subtree"
+		case "exact", "subtree":
+		default:
+			return nil, fmt.Errorf("%w: scope must be exact or subtree", ErrPostInvalidAudience)
+		}
+		if _, err := repo.ResolveOrgNodeIDByPath(db, a.OrgPath, ctx); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, fmt.Errorf("%w: org_path not found: %s", ErrPostInvalidAudience, a.OrgPath)
+			}
+			return nil, err
+		}
+		t.Audience = append(t.Audience, a)
+	}
+	var err error
+	if t.AllowUserIDs, err = hexUserIDs(v.AllowUserIDs); err != nil {
+		return nil, err
+	}
+	if t.DenyUserIDs, err = hexUserIDs(v.DenyUserIDs); err != nil {
+		return nil, err
+	}
+	if len(t.Audience) == 0 && len(t.IncludePositions) == 0 && len(t.AllowUserIDs) == 0 {
+		return nil, fmt.Errorf("%w: targets, include_positions or allow_user_ids is required", ErrPostInvalidAudience)
+	}
+	return t, nil
+}
+
+// hexUserIDs ตรวจว่าเป็น ObjectID hex (เก็บเป็น string แบบเดียวกับ event)
+func hexUserIDs(ids []string) ([]string, error) {
+	out := make([]string, 0, len(ids))
+	for _, id := range trimNonEmpty(ids) {
+		oid, err := bson.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: user id %q", ErrPostInvalidAudience, id)
+		}
+		out = append(out, oid.Hex())
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+func trimNonEmpty(in []string) []string {
+	var out []string
+	for _, s := range in {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// targetingVisibility แปลง targeting ของโพสต์กลับเป็นรูป dto สำหรับ response
+func targetingVisibility(t *models.Visibility) dto.Visibility {
+	return dto.Visibility{
+		Access:           "org",
+		Audience:         []string{},
+		Targets:          t.Audience,
+		IncludePositions: t.IncludePositions,
+		ExcludePositions: t.ExcludePositions,
+		AllowUserIDs:     t.AllowUserIDs,
+		DenyUserIDs:      t.DenyUserIDs,
+	}
+}
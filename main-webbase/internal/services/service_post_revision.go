@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var ErrPostEditWindowClosed = errors.New("edit window has passed: only moderators can edit this post")
+
+func formatEditedAt(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// originalPostRevision ต้นฉบับของโพสต์ (revision 1) — อ่านใน transaction เดียวกับการแก้
+func originalPostRevision(tx context.Context, db *mongo.Database, post models.Post) (*models.PostRevision, error) {
+	cats, err := repo.FindCategoryIDs(db.Collection("post_categories"), post.ID, tx)
+	if err != nil {
+		return nil, err
+	}
+	vis, err := repo.FindVisibilityPaths(db.Collection("post_role_visibility"), db.Collection("org_units"), post.ID, tx)
+	if err != nil {
+		return nil, err
+	}
+	if post.Targeting != nil {
+		vis.Access = "org"
+	}
+	return &models.PostRevision{
+		ID:          bson.NewObjectID(),
+		PostID:      post.ID,
+		Revision:    1,
+		PostText:    post.PostText,
+		Media:       post.Media,
+		CategoryIDs: nonNilStrings(cats),
+		Access:      vis.Access,
+		Audience:    vis.Audience,
+		Targeting:   post.Targeting,
+		PostAs:      post.PostAs,
+		Status:      post.Status,
+		EditedBy:    post.UserID,
+		EditedAt:    post.CreatedAt,
+	}, nil
+}
+
+// editedPostRevision เนื้อหาหลังการแก้ตาม payload ของ UpdatePostFull
+func editedPostRevision(post models.Post, in dto.UpdatePostFullDTO, revision int, editor bson.ObjectID) *models.PostRevision {
+	r := &models.PostRevision{
+		ID:          bson.NewObjectID(),
+		PostID:      post.ID,
+		Revision:    revision,
+		PostText:    post.PostText,
+		Media:       post.Media,
+		CategoryIDs: nonNilStrings(in.CategoryIDs),
+		Access:      "public",
+		PostAs:      models.PostAs{OrgPath: in.PostAs.OrgPath, PositionKey: in.PostAs.PositionKey},
+		Status:      post.Status,
+		EditedBy:    editor,
+		EditedAt:    time.Now().UTC(),
+	}
+	if post.EditedAt != nil {
+		r.EditedAt = *post.EditedAt
+	}
+	switch {
+	case post.Targeting != nil:
+		r.Access, r.Targeting = "org", post.Targeting
+	case in.Visibility.Access == "private":
+		r.Access, r.Audience = "private", in.Visibility.Audience
+	}
+	return r
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// PostRevisions ประวัติการแก้ของโพสต์ ใหม่สุดก่อน (ว่าง = ยังไม่เคยแก้)
+func PostRevisions(ctx context.Context, db *mongo.Database, postID bson.ObjectID) ([]models.PostRevision, error) {
+	return repo.ListPostRevisions(ctx, db, postID)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/dto"
+	"main-webbase/internal/authz"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+	u "main-webbase/internal/utils"
+)
+
+var (
+	ErrPostInvalidSchedule = errors.New("invalid post status or publishAt")
+	ErrPostNotUnpublished  = errors.New("post is not a draft or scheduled")
+)
+
+// scheduledPublishBatch จำนวนโพสต์ตั้งเวลาสูงสุดที่เผยแพร่ต่อรอบของ publisher
+const scheduledPublishBatch = 100
+
+// parsePostSchedule แปลง status/publishAt ตอนสร้างโพสต์
+// "" หรือ active → เผยแพร่ทันที, draft → ร่าง, scheduled → ต้องมี publishAt (RFC3339) ในอนาคต
+func parsePostSchedule(status, publishAt string, now time.Time) (string, *time.Time, error) {
+	switch status {
+	case "", models.PostStatusActive:
+		return models.PostStatusActive, nil, nil
+	case models.PostStatusDraft:
+		return models.PostStatusDraft, nil, nil
+	case models.PostStatusScheduled:
+		at, err := time.Parse(time.RFC3339, publishAt)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: publishAt must be RFC3339", ErrPostInvalidSchedule)
+		}
+		if !at.After(now) {
+			return "", nil, fmt.Errorf("%w: publishAt must be in the future", ErrPostInvalidSchedule)
+		}
+		at = at.UTC()
+		return models.PostStatusScheduled, &at, nil
+	}
+	return "", nil, fmt.Errorf("%w: unknown status %q", ErrPostInvalidSchedule, status)
+}
+
+func formatPublishAt(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// publishPost เปลี่ยนโพสต์ที่ตรง filter เป็น active แล้วทำ index hashtag (นับ trending ตามวันที่เผยแพร่)
+// ใน transaction เดียวกัน — คืน nil ถ้าไม่มีโพสต์ตรง filter
+func publishPost(ctx context.Context, client *mongo.Client, db *mongo.Database, filter bson.M) (*models.Post, error) {
+	var published *models.Post
+	err := runPostSteps(ctx, client, "publish post",
+		postStep{"publish", func(tx context.Context) error {
+			var err error
+			published, err = repo.PublishPostDoc(tx, db, filter, time.Now().UTC())
+			return err
+		}},
+		postStep{"hashtags", func(tx context.Context) error {
+			if published == nil {
+				return nil
+			}
+			return repo.RebuildHashtags(db, *published, published.PostText, tx)
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return published, nil
+}
+
+// PublishPost เผยแพร่ร่าง/โพสต์ตั้งเวลาทันที — หน่วยงานที่โพสต์ในนามต้องยังไม่ถูก archive
+func PublishPost(ctx context.Context, client *mongo.Client, db *mongo.Database, postID bson.ObjectID) (*models.Post, error) {
+	current, err := repo.FindPostByID(db.Collection("posts"), postID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := EnsureOrgActive(ctx, current.PostAs.OrgPath); errors.Is(err, ErrOrgArchived) {
+		return nil, err
+	}
+	post, err := publishPost(ctx, client, db, bson.M{"_id": postID, "status": bson.M{"$in": repo.UnpublishedPostStatuses}})
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotUnpublished
+	}
+	return post, nil
+}
+
+// SchedulePost ตั้ง/เลื่อนเวลาเผยแพร่ของร่างหรือโพสต์ตั้งเวลา
+func SchedulePost(ctx context.Context, db *mongo.Database, postID bson.ObjectID, at time.Time) error {
+	if !at.After(time.Now()) {
+		return fmt.Errorf("%w: publishAt must be in the future", ErrPostInvalidSchedule)
+	}
+	at = at.UTC()
+	ok, err := repo.SetPostSchedule(ctx, db, postID, repo.UnpublishedPostStatuses, models.PostStatusScheduled, &at)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPostNotUnpublished
+	}
+	return nil
+}
+
+// CancelScheduledPost ยกเลิกการตั้งเวลา → กลับเป็นร่าง (ลบทิ้งได้ผ่าน DELETE /posts/:id)
+func CancelScheduledPost(ctx context.Context, db *mongo.Database, postID bson.ObjectID) error {
+	ok, err := repo.SetPostSchedule(ctx, db, postID, bson.A{models.PostStatusScheduled}, models.PostStatusDraft, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPostNotUnpublished
+	}
+	return nil
+}
+
+// ListUnpublishedPosts ร่าง/โพสต์ตั้งเวลาที่ผู้เรียกเห็นได้: ของตัวเอง
+// และของ org ที่ผู้เรียกมีสิทธิ์ post:update (co-manager ของ org ที่โพสต์ในนาม)
+func ListUnpublishedPosts(ctx context.Context, db *mongo.Database, s authz.Subject) ([]dto.PostResponse, error) {
+	var orgFilters []bson.M
+	if s.IsRoot {
+		orgFilters = []bson.M{{}}
+	} else {
+		seen := map[string]bool{}
+		for _, p := range s.Policies {
+			if !p.Enabled || p.Effect == "deny" || p.OrgPrefix == "" || seen[p.OrgPrefix] {
+				continue
+			}
+			seen[p.OrgPrefix] = true
+			orgFilters = append(orgFilters, bson.M{"postAs.org_path": bson.M{"$regex": "^" + regexp.QuoteMeta(p.OrgPrefix)}})
+		}
+	}
+
+	posts, err := repo.FindUnpublishedPosts(ctx, db, s.UserID, orgFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	// query ข้างบนกว้างไว้ก่อน ตัดสินจริงด้วย authz เหมือน RequireAction("post:update", OrgFromPost)
+	out := make([]dto.PostResponse, 0, len(posts))
+	for _, p := range posts {
+		t := authz.Target{OrgPath: p.PostAs.OrgPath, Owners: []bson.ObjectID{p.UserID}, Attrs: map[string]string{"status": p.Status}}
+		if !authz.Authorize(s, "post:update", t).Allowed {
+			continue
+		}
+		out = append(out, dto.PostResponse{
+			PostID:       p.ID.Hex(),
+			UserID:       p.UserID.Hex(),
+			PostText:     u.MaskProfanity(p.PostText),
+			Media:        p.Media,
+			Hashtag:      p.Hashtag,
+			PostAs:       dto.PostAs{OrgPath: p.PostAs.OrgPath, PositionKey: p.PostAs.PositionKey, Tag: p.Tags},
+			OrgOfContent: p.PostAs.OrgPath,
+			CreatedAt:    p.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt:    p.UpdatedAt.UTC().Format(time.RFC3339),
+			Status:       p.Status,
+			PublishAt:    formatPublishAt(p.PublishAt),
+		})
+	}
+	return out, nil
+}
+
+// RunScheduledPublisher เผยแพร่โพสต์ตั้งเวลาที่ถึง publish_at แล้ว (เรียกจาก ticker ใน main)
+// filter ซ้ำ status/publish_at ตอนเผยแพร่ กันชนกับการแก้/ยกเลิก/เลื่อนเวลา หรือ instance อื่น
+func RunScheduledPublisher(ctx context.Context, client *mongo.Client) (int, error) {
+	db := client.Database("unicom")
+	now := time.Now().UTC()
+
+	ids, err := repo.ListDueScheduledPostIDs(ctx, db, now, scheduledPublishBatch)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		// archive ปกติเปลี่ยนโพสต์เป็น archived ไปแล้ว — เช็คซ้ำกันกรณีชนกับการ archive ระหว่างรอบ
+		current, err := repo.FindPostByID(db.Collection("posts"), id, ctx)
+		if err != nil {
+			log.Printf("[post-publisher] post %s: %v", id.Hex(), err)
+			continue
+		}
+		if err := EnsureOrgActive(ctx, current.PostAs.OrgPath); errors.Is(err, ErrOrgArchived) {
+			log.Printf("[post-publisher] post %s: skipped, %s is archived", id.Hex(), current.PostAs.OrgPath)
+			continue
+		}
+		post, err := publishPost(ctx, client, db, bson.M{"_id": id, "status": models.PostStatusScheduled, "publish_at": bson.M{"$lte": now}})
+		if err != nil {
+			log.Printf("[post-publisher] post %s: %v", id.Hex(), err)
+			continue
+		}
+		if post != nil {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// postTxMaxAttempts จำนวนครั้งสูงสุดที่รัน transaction ของโพสต์ใหม่เมื่อเจอ error แบบ transient
+const postTxMaxAttempts = 3
+
+// postStep หนึ่งขั้นของการสร้าง/แก้/ลบโพสต์ (posts, hashtags, post_categories, post_role_visibility)
+type postStep struct {
+	name string
+	run  func(tx context.Context) error
+}
+
+// postTxRunner รัน fn ใน transaction แบบ mongo.Session.WithTransaction: fn คืน nil → commit,
+// error ที่มี label TransientTransactionError → abort แล้วรัน fn ใหม่, error อื่น → abort และคืน error นั้น
+type postTxRunner func(ctx context.Context, fn func(tx context.Context) (any, error)) error
+
+// runPostSteps รันทุกขั้นใน transaction เดียวแบบเดียวกับ CommentRepository.Create
+// ขั้นที่ล้มด้วย error แบบ transient (network, write conflict — driver ติด label TransientTransactionError)
+// ทำให้ server abort ทั้ง transaction จึง retry โดยรันทุกขั้นใหม่ตั้งแต่ต้น ไม่เกิน postTxMaxAttempts ครั้ง
+// error อื่นหรือครบจำนวนครั้งแล้ว → abort และคืน error ที่บอกชื่อขั้น (ไม่มีโพสต์ครึ่ง ๆ กลาง ๆ ค้างอยู่)
+func runPostSteps(ctx context.Context, client *mongo.Client, op string, steps ...postStep) error {
+	sess, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	return runPostStepsIn(ctx, func(ctx context.Context, fn func(tx context.Context) (any, error)) error {
+		_, err := sess.WithTransaction(ctx, fn)
+		return err
+	}, op, steps...)
+}
+
+// runPostStepsIn ตรรกะของ runPostSteps โดยรับ transaction runner เข้ามา (เทสใส่ runner ปลอมได้)
+func runPostStepsIn(ctx context.Context, run postTxRunner, op string, steps ...postStep) error {
+	attempt := 0
+	return run(ctx, func(tx context.Context) (any, error) {
+		attempt++
+		for _, s := range steps {
+			err := s.run(tx)
+			if err == nil {
+				continue
+			}
+			if !isTransientTxError(err) {
+				return nil, fmt.Errorf("%s: %s: %w", op, s.name, err)
+			}
+			if attempt >= postTxMaxAttempts {
+				// %v ตัด label ออก ให้ WithTransaction หยุด retry
+				return nil, fmt.Errorf("%s: %s: giving up after %d attempts: %v", op, s.name, attempt, err)
+			}
+			log.Printf("[post-tx] %s: step %s failed (attempt %d/%d), retrying: %v", op, s.name, attempt, postTxMaxAttempts, err)
+			return nil, err
+		}
+		return nil, nil
+	})
+}
+
+// isTransientTxError error ที่ WithTransaction จะรัน callback ใหม่ให้
+func isTransientTxError(err error) bool {
+	var le mongo.LabeledError
+	return errors.As(err, &le) && le.HasErrorLabel("TransientTransactionError")
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"main-webbase/dto"
+	"main-webbase/internal/models"
+)
+
+type stagedKey struct{}
+
+// fakeTx ทำตัวเหมือน mongo.Session.WithTransaction: ทุกครั้งที่รัน fn จะได้ staging ใหม่,
+// fn คืน nil → commit staging, error ที่มี label TransientTransactionError → ทิ้ง staging แล้วรันใหม่,
+// error อื่น → ทิ้ง staging แล้วคืน error
+type fakeTx struct {
+	attempts int
+	commits  [][]string
+}
+
+func (f *fakeTx) run(ctx context.Context, fn func(tx context.Context) (any, error)) error {
+	for {
+		f.attempts++
+		staged := &[]string{}
+		_, err := fn(context.WithValue(ctx, stagedKey{}, staged))
+		if err == nil {
+			f.commits = append(f.commits, *staged)
+			return nil
+		}
+		if !isTransientTxError(err) || f.attempts >= 10 {
+			return err
+		}
+	}
+}
+
+func (f *fakeTx) written() []string {
+	var out []string
+	for _, c := range f.commits {
+		out = append(out, c...)
+	}
+	return out
+}
+
+// fakeSteps แทน run ของแต่ละขั้นด้วยการเขียนชื่อขั้นลง staging — fail(i, attempt) != nil = ขั้น i ล้ม
+func fakeSteps(steps []postStep, fail func(i, attempt int) error) []postStep {
+	attempt := 0
+	out := make([]postStep, len(steps))
+	for i, s := range steps {
+		out[i] = postStep{s.name, func(tx context.Context) error {
+			if i == 0 {
+				attempt++
+			}
+			if err := fail(i, attempt); err != nil {
+				return err
+			}
+			staged := tx.Value(stagedKey{}).(*[]string)
+			*staged = append(*staged, s.name)
+			return nil
+		}}
+	}
+	return out
+}
+
+func stepNames(steps []postStep) []string {
+	names := make([]string, len(steps))
+	for i, s := range steps {
+		names[i] = s.name
+	}
+	return names
+}
+
+var transientErr = mongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+
+// ขั้นจริงของ create/update/delete (ไม่ถูกรัน ใช้แค่ชื่อและลำดับ)
+func postTxOps() map[string][]postStep {
+	var ok bool
+	return map[string][]postStep{
+		"create post": createPostSteps(nil,
+			models.Post{ID: bson.NewObjectID(), Status: models.PostStatusActive},
+			dto.CreatePostDTO{CategoryIDs: []string{"c"}, Visibility: dto.Visibility{Access: "private"}},
+		),
+		"update post": (&postUpdate{}).steps(nil),
+		"delete post": deletePostSteps(nil, bson.NewObjectID(), bson.NewObjectID(), false, &ok),
+	}
+}
+
+func TestPostTxOpsCoverEveryStep(t *testing.T) {
+	want := map[string][]string{
+		"create post": {"insert post", "hashtags", "categories", "role visibility"},
+		"update post": {"original revision", "update post", "categories", "role visibility", "targeting", "hashtags", "revision"},
+		"delete post": {"deactivate post"},
+	}
+	for op, steps := range postTxOps() {
+		if got := stepNames(steps); !slices.Equal(got, want[op]) {
+			t.Errorf("%s steps = %v, want %v", op, got, want[op])
+		}
+	}
+}
+
+func TestRunPostStepsRollsBackOnFailureAtEachStep(t *testing.T) {
+	boom := errors.New("boom")
+	for op, real := range postTxOps() {
+		for failAt := range real {
+			name := op + "/" + real[failAt].name
+			t.Run(name, func(t *testing.T) {
+				ran := map[int]bool{}
+				steps := fakeSteps(real, func(i, _ int) error {
+					ran[i] = true
+					if i == failAt {
+						return boom
+					}
+					return nil
+				})
+				tx := &fakeTx{}
+				err := runPostStepsIn(context.Background(), tx.run, op, steps...)
+
+				if !errors.Is(err, boom) {
+					t.Fatalf("err = %v, want wrapped boom", err)
+				}
+				if want := op + ": " + real[failAt].name + ": "; !strings.HasPrefix(err.Error(), want) {
+					t.Errorf("err = %q, want prefix %q", err, want)
+				}
+				if tx.attempts != 1 {
+					t.Errorf("attempts = %d, want 1 (no retry for non-transient errors)", tx.attempts)
+				}
+				if len(tx.commits) != 0 {
+					t.Errorf("committed %v, want nothing", tx.commits)
+				}
+				for i := failAt + 1; i < len(real); i++ {
+					if ran[i] {
+						t.Errorf("step %q ran after the failure", real[i].name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRunPostStepsGivesUpOnTransientErrors(t *testing.T) {
+	for op, real := range postTxOps() {
+		for failAt := range real {
+			t.Run(op+"/"+real[failAt].name, func(t *testing.T) {
+				steps := fakeSteps(real, func(i, _ int) error {
+					if i == failAt {
+						return transientErr
+					}
+					return nil
+				})
+				tx := &fakeTx{}
+				err := runPostStepsIn(context.Background(), tx.run, op, steps...)
+
+				if err == nil || !strings.Contains(err.Error(), "giving up after") {
+					t.Fatalf("err = %v, want giving up", err)
+				}
+				if isTransientTxError(err) {
+					t.Error("final error still carries TransientTransactionError, WithTransaction would keep retrying")
+				}
+				if tx.attempts != postTxMaxAttempts {
+					t.Errorf("attempts = %d, want %d", tx.attempts, postTxMaxAttempts)
+				}
+				if len(tx.commits) != 0 {
+					t.Errorf("committed %v, want nothing", tx.commits)
+				}
+			})
+		}
+	}
+}
+
+func TestRunPostStepsCommitsOnceAfterRetry(t *testing.T) {
+	for op, real := range postTxOps() {
+		for failAt := range real {
+			t.Run(op+"/"+real[failAt].name, func(t *testing.T) {
+				steps := fakeSteps(real, func(i, attempt int) error {
+					if i == failAt && attempt == 1 {
+						return transientErr
+					}
+					return nil
+				})
+				tx := &fakeTx{}
+				if err := runPostStepsIn(context.Background(), tx.run, op, steps...); err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				if tx.attempts != 2 {
+					t.Errorf("attempts = %d, want 2", tx.attempts)
+				}
+				if len(tx.commits) != 1 {
+					t.Fatalf("commits = %d, want 1", len(tx.commits))
+				}
+				if got, want := tx.written(), stepNames(real); !slices.Equal(got, want) {
+					t.Errorf("written = %v, want each step once: %v", got, want)
+				}
+			})
+		}
+	}
+}
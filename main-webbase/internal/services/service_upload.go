@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/config"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+var ErrUploadNotFound = errors.New("upload not found")
+var ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+var ErrUploadTooLarge = errors.New("upload exceeds maximum size")
+var ErrUploadQuotaExceeded = errors.New("upload quota exceeded")
+var ErrUploadNotVideo = errors.New("only video uploads are supported")
+var ErrUploadNotActive = errors.New("upload is not in progress")
+var ErrUploadNotImage = errors.New("only jpg, png and webp images are supported")
+
+// UploadStore ผูก service เข้ากับ directory และ quota จาก config
+type UploadStore struct {
+	Dir        string
+	QuotaBytes int64
+}
+
+func NewUploadStore(cfg config.Config) *UploadStore {
+	return &UploadStore{Dir: cfg.UploadDir, QuotaBytes: cfg.UploadQuotaBytes}
+}
+
+var videoExts = map[string]string{
+	".mp4":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".m4v":  "video/x-m4v",
+}
+
+var imageExts = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+}
+
+// ImageExt ตรวจว่าไฟล์รูปเป็น jpg/png/webp ทั้งนามสกุล, MIME ที่ client ส่งมา และเนื้อไฟล์ (head = ไบต์ต้นไฟล์)
+// คืนนามสกุลตัวเล็กไว้ตั้งชื่อไฟล์ที่บันทึก
+func ImageExt(original, mimeType string, head []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(original))
+	want, ok := imageExts[ext]
+	if !ok {
+		return "", ErrUploadNotImage
+	}
+	if mimeType != "" && mimeType != want {
+		return "", ErrUploadNotImage
+	}
+	if http.DetectContentType(head) != want {
+		return "", ErrUploadNotImage
+	}
+	return ext, nil
+}
+
+func (s *UploadStore) partPath(up *models.Upload) string {
+	return filepath.Join(s.Dir, up.ID.Hex()+".part")
+}
+
+// FilePath คืน path ของไฟล์ที่อัปโหลดเสร็จแล้ว
+func (s *UploadStore) FilePath(up *models.Upload) string {
+	return filepath.Join(s.Dir, up.FileName)
+}
+
+// Create เปิด upload ใหม่ (tus: POST) หลังตรวจขนาดไฟล์, ชนิดไฟล์ และ quota ของ user
+func (s *UploadStore) Create(ctx context.Context, userID bson.ObjectID, length int64, original, mimeType string) (*models.Upload, error) {
+	if length <= 0 || length > config.MaxUploadSizeBytes {
+		return nil, ErrUploadTooLarge
+	}
+
+	ext := strings.ToLower(filepath.Ext(original))
+	if mimeType == "" {
+		mimeType = videoExts[ext]
+	}
+	if !strings.HasPrefix(mimeType, "video/") {
+		return nil, ErrUploadNotVideo
+	}
+	if _, ok := videoExts[ext]; !ok {
+		ext = ".mp4"
+	}
+
+	used, err := repo.SumUserUploadBytes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if used+length > s.QuotaBytes {
+		return nil, fmt.Errorf("%w: used %d of %d bytes", ErrUploadQuotaExceeded, used, s.QuotaBytes)
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(config.UploadExpiry)
+	up := models.Upload{
+		ID:        bson.NewObjectID(),
+		UserID:    userID,
+		FileName:  fmt.Sprintf("%s_%d%s", userID.Hex(), now.UnixNano()/1e6, ext),
+		Original:  original,
+		MimeType:  mimeType,
+		Length:    length,
+		Offset:    0,
+		Status:    models.UploadStatusUploading,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: &expires,
+	}
+
+	// สร้างไฟล์ .part ไว้ก่อน เพื่อให้ PATCH แรกเขียนต่อได้ทันที
+	f, err := os.OpenFile(s.partPath(&up), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("create part file: %w", err)
+	}
+	f.Close()
+
+	if err := repo.InsertUpload(ctx, up); err != nil {
+		_ = os.Remove(s.partPath(&up))
+		return nil, err
+	}
+	return &up, nil
+}
+
+// Get คืน upload ของ user (ซ่อน upload ที่ยกเลิกแล้ว / หมดอายุ)
+func (s *UploadStore) Get(ctx context.Context, id, userID bson.ObjectID) (*models.Upload, error) {
+	up, err := repo.FindUploadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if up == nil || up.UserID != userID || up.Status == models.UploadStatusAborted {
+		return nil, ErrUploadNotFound
+	}
+	if up.Status == models.UploadStatusUploading && up.ExpiresAt != nil && up.ExpiresAt.Before(time.Now()) {
+		return nil, ErrUploadNotFound
+	}
+	return up, nil
+}
+
+// Append เขียน chunk ต่อท้าย (tus: PATCH) โดย offset ต้องตรงกับที่ commit ไว้ใน DB
+// ใช้ WriteAt เพื่อให้ retry หลัง crash เขียนทับส่วนที่ยังไม่ commit ได้อย่างปลอดภัย
+func (s *UploadStore) Append(ctx context.Context, id, userID bson.ObjectID, offset int64, chunk []byte) (*models.Upload, error) {
+	up, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if up.Status != models.UploadStatusUploading {
+		return nil, ErrUploadNotActive
+	}
+	if offset != up.Offset {
+		return nil, ErrUploadOffsetMismatch
+	}
+	if up.Offset+int64(len(chunk)) > up.Length {
+		return nil, ErrUploadTooLarge
+	}
+
+	f, err := os.OpenFile(s.partPath(up), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open part file: %w", err)
+	}
+	if _, err := f.WriteAt(chunk, offset); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write chunk: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("sync chunk: %w", err)
+	}
+	f.Close()
+
+	newOffset := offset + int64(len(chunk))
+	ok, err := repo.AdvanceUploadOffset(ctx, up.ID, offset, newOffset)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUploadOffsetMismatch
+	}
+	up.Offset = newOffset
+
+	if up.Offset == up.Length {
+		if err := s.finalize(ctx, up); err != nil {
+			return nil, err
+		}
+	}
+	return up, nil
+}
+
+func (s *UploadStore) finalize(ctx context.Context, up *models.Upload) error {
+	part := s.partPath(up)
+	// ตัดส่วนเกินจาก chunk ที่เคยเขียนแต่ไม่ได้ commit
+	if err := os.Truncate(part, up.Length); err != nil {
+		return fmt.Errorf("truncate upload: %w", err)
+	}
+	if err := os.Rename(part, s.FilePath(up)); err != nil {
+		return fmt.Errorf("finalize upload: %w", err)
+	}
+	if err := repo.MarkUploadCompleted(ctx, up.ID); err != nil {
+		return err
+	}
+	up.Status = models.UploadStatusCompleted
+	up.ExpiresAt = nil
+	return nil
+}
+
+// Abort ยกเลิก upload ที่ยังไม่เสร็จ (tus: DELETE) และลบไฟล์ .part
+func (s *UploadStore) Abort(ctx context.Context, id, userID bson.ObjectID) error {
+	up, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if up.Status != models.UploadStatusUploading {
+		return ErrUploadNotActive
+	}
+	if err := repo.MarkUploadAborted(ctx, up.ID); err != nil {
+		return err
+	}
+	_ = os.Remove(s.partPath(up))
+	return nil
+}
+
+// Completed คืน upload ที่เสร็จแล้ว (ใครที่ login ก็ดูได้ เหมือนไฟล์ใน /uploads)
+func (s *UploadStore) Completed(ctx context.Context, id bson.ObjectID) (*models.Upload, error) {
+	up, err := repo.FindUploadByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if up == nil || up.Status != models.UploadStatusCompleted {
+		return nil, ErrUploadNotFound
+	}
+	return up, nil
+}
+
+// Usage คืนจำนวน byte ที่ใช้ไปและ quota ทั้งหมดของ user
+func (s *UploadStore) Usage(ctx context.Context, userID bson.ObjectID) (int64, int64, error) {
+	used, err := repo.SumUserUploadBytes(ctx, userID)
+	return used, s.QuotaBytes, err
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+
+	"main-webbase/database"
+	"main-webbase/dto"
+	"main-webbase/internal/accessctx"
+	"main-webbase/internal/models"
+	repo "main-webbase/internal/repository"
+)
+
+// positionLabel เลือก label จาก Position.Display ตามภาษา → en → th → key
+func positionLabel(p *models.Position, key, lang string) string {
+	if p == nil {
+		return key
+	}
+	for _, l := range []string{lang, "en", "th"} {
+		if l == "" {
+			continue
+		}
+		if v := strings.TrimSpace(p.Display[l]); v != "" {
+			return v
+		}
+	}
+	return key
+}
+
+// UserMembershipsQuery
+// Full = ตัวเองหรือ root: ได้ visible_org_paths และ post_as ด้วย
+// ManagedBy != nil = ผู้ดูแลคนอื่น: เห็นเฉพาะ membership ใน org ที่ตัวเองมี membership:assign
+type UserMembershipsQuery struct {
+	UserID       bson.ObjectID
+	Lang         string
+	IncludeEnded bool
+	Full         bool
+	ManagedBy    []models.Policy
+}
+
+func ListUserMemberships(ctx context.Context, q UserMembershipsQuery) (*dto.UserMembershipsResponse, error) {
+	now := time.Now()
+	filter := bson.M{"user_id": q.UserID}
+	if !q.IncludeEnded {
+		filter["active"] = true
+	}
+	ms, err := repo.ListMembershipHistory(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := accessctx.LoadOrgTree(ctx, database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	type posKey struct{ key, org string }
+	positions := map[posKey]*models.Position{}
+
+	resp := &dto.UserMembershipsResponse{
+		UserID:      q.UserID.Hex(),
+		Memberships: make([]dto.UserMembershipItem, 0, len(ms)),
+	}
+	for _, m := range ms {
+		if !q.Full && CanManageOrg(q.ManagedBy, "membership:assign", m.OrgPath) != nil {
+			continue
+		}
+
+		pk := posKey{m.PositionKey, m.OrgPath}
+		p, ok := positions[pk]
+		if !ok {
+			if p, err = repo.FindPositionInScope(ctx, m.PositionKey, m.OrgPath); err != nil {
+				return nil, err
+			}
+			positions[pk] = p
+		}
+
+		item := dto.UserMembershipItem{
+			MembershipID:  m.ID.Hex(),
+			OrgPath:       m.OrgPath,
+			OrgName:       m.OrgPath,
+			PositionKey:   m.PositionKey,
+			PositionLabel: positionLabel(p, m.PositionKey, q.Lang),
+			Active:        m.Active,
+			JoinedAt:      m.JoinedAt,
+			TermEnd:       m.TermEnd,
+			EndedAt:       m.EndedAt,
+			EndReason:     m.EndReason,
+		}
+		if p != nil {
+			item.Display = p.Display
+			item.PositionRank = p.Rank
+		}
+		node := tree.ByPath[m.OrgPath]
+		if node != nil {
+			item.OrgName = orgDisplayName(node, q.Lang)
+			item.OrgShortName = node.ShortName
+		}
+		item.Current = m.Active &&
+			(m.JoinedAt == nil || !m.JoinedAt.After(now)) &&
+			(m.TermEnd == nil || m.TermEnd.After(now))
+		resp.Memberships = append(resp.Memberships, item)
+
+		// โพสต์ในนามได้เฉพาะวาระปัจจุบันของหน่วยงานที่ยัง active
+		if q.Full && item.Current && node != nil && node.Status != models.OrgStatusArchived {
+			resp.PostAs = append(resp.PostAs, dto.PostAsOption{
+				OrgPath:       m.OrgPath,
+				OrgName:       item.OrgName,
+				PositionKey:   m.PositionKey,
+				PositionLabel: item.PositionLabel,
+			})
+		}
+	}
+
+	// current ก่อน แล้วเรียงตาม rank (สูงก่อน) และ org_path
+	sort.SliceStable(resp.Memberships, func(i, j int) bool {
+		a, b := resp.Memberships[i], resp.Memberships[j]
+		if a.Current != b.Current {
+			return a.Current
+		}
+		if a.PositionRank != b.PositionRank {
+			return a.PositionRank > b.PositionRank
+		}
+		return a.OrgPath < b.OrgPath
+	})
+
+	if q.Full {
+		va, err := accessctx.CachedViewerAccess(ctx, database.DB, q.UserID)
+		if err != nil {
+			return nil, err
+		}
+		resp.VisibleOrgPaths = va.SubtreePaths
+		if resp.VisibleOrgPaths == nil {
+			resp.VisibleOrgPaths = []string{}
+		}
+		if resp.PostAs == nil {
+			resp.PostAs = []dto.PostAsOption{}
+		}
+	}
+	return resp, nil
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 	"main-webbase/dto"
 
 	"main-webbase/internal/models"
@@ -19,71 +21,135 @@ func UpdatePostFull(
 	postID, userID bson.ObjectID,
 	isRoot bool,
 	in dto.UpdatePostFullDTO,
+	editWindow time.Duration,
 	ctx context.Context,
 ) (*models.Post, error) {
 
-	sess, err := client.StartSession()
+	// 1) resolve PostAs (เหมือน create) — อ่านอย่างเดียว ทำก่อนเปิด transaction
+	rolePathID, err := repo.ResolveOrgNodeIDByPath(db, in.PostAs.OrgPath, ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("org_path not found4")
+	}
+	positionID, err := repo.ResolvePositionIDByKey(db, in.PostAs.PositionKey, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("position_key not found")
 	}
-	defer sess.EndSession(ctx)
 
-	var updated *models.Post
-	// fmt.Printf("[Svc] UpdatePostFull start post=%s isRoot=%v\n", postID.Hex(), isRoot)
+	targeting, err := postTargeting(ctx, db, in.Visibility)
+	if err != nil {
+		return nil, err
+	}
 
-	_, err = sess.WithTransaction(ctx, func(tx context.Context) (any, error) {
-		// 1) resolve PostAs (เหมือน create)
-		rolePathID, err := repo.ResolveOrgNodeIDByPath(db, in.PostAs.OrgPath, tx)
-		if err != nil {
-			return nil, fmt.Errorf("org_path not found4")
-		}
-		positionID, err := repo.ResolvePositionIDByKey(db, in.PostAs.PositionKey, tx)
-		if err != nil {
-			return nil, fmt.Errorf("position_key not found")
-		}
+	pu := &postUpdate{
+		postID: postID, userID: userID, isRoot: isRoot, in: in, editWindow: editWindow,
+		rolePathID: rolePathID, positionID: positionID, targeting: targeting,
+	}
+	if err := runPostSteps(ctx, client, "update post", pu.steps(db)...); err != nil {
+		return nil, err
+	}
+	return pu.updated, nil
+}
 
-		// fmt.Printf("[Svc2] UpdatePostFull start post=%s isRoot=%v\n", postID.Hex(), isRoot)
-		// 2) update core post
-		out, err := repo.UpdatePostCore(db, postID, userID, isRoot, in, rolePathID, positionID, tx)
-		if err != nil {
-			return nil, err
-		}
-		if out == nil {
+// postUpdate สถานะของการแก้โพสต์หนึ่งครั้งที่ขั้นต่าง ๆ ใช้ร่วมกัน
+type postUpdate struct {
+	postID, userID         bson.ObjectID
+	isRoot                 bool
+	in                     dto.UpdatePostFullDTO
+	editWindow             time.Duration
+	rolePathID, positionID bson.ObjectID
+	targeting              *models.Visibility
 
-			// fmt.Println("[FORBIDDEN-2] post not found or not owner")
-			return nil, fmt.Errorf("forbidden or not found")
-		}
-		updated = out
+	updated  *models.Post // ผลหลังขั้น update post
+	revision int          // revision ล่าสุดก่อนแก้ครั้งนี้
+}
 
+// steps ขั้นของการแก้โพสต์ ตามลำดับ
+func (pu *postUpdate) steps(db *mongo.Database) []postStep {
+	postID, userID, isRoot, in := pu.postID, pu.userID, pu.isRoot, pu.in
+	return []postStep{
+		// 1.1) edit window (moderator แก้ได้เสมอ) + เก็บต้นฉบับเป็น revision 1 ตอนแก้ครั้งแรก
+		{"original revision", func(tx context.Context) error {
+			current, err := repo.FindPostByID(db.Collection("posts"), postID, tx)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil // ให้ update post ตอบ forbidden or not found
+			}
+			if err != nil {
+				return err
+			}
+			// ร่าง/โพสต์ตั้งเวลายังไม่เผยแพร่ → แก้ได้เสมอจนถึงเวลาเผยแพร่
+			if pu.editWindow > 0 && !isRoot && current.Status == models.PostStatusActive && time.Since(current.CreatedAt) > pu.editWindow {
+				return ErrPostEditWindowClosed
+			}
+			if pu.revision, err = repo.LatestPostRevision(tx, db, postID); err != nil || pu.revision > 0 {
+				return err
+			}
+			orig, err := originalPostRevision(tx, db, current)
+			if err != nil {
+				return err
+			}
+			pu.revision = orig.Revision
+			return repo.InsertPostRevision(tx, db, orig)
+		}},
+		// 2) update core post
+		{"update post", func(tx context.Context) error {
+			out, err := repo.UpdatePostCore(db, postID, userID, isRoot, in, pu.rolePathID, pu.positionID, tx)
+			if err != nil {
+				return err
+			}
+			if out == nil {
+				return fmt.Errorf("forbidden or not found")
+			}
+			pu.updated = out
+			return nil
+		}},
 		// 3) replace categories (ลบเก่า→ใส่ใหม่)
-		if err := repo.ReplaceCategories(db, postID, in.CategoryIDs, tx); err != nil {
-			return nil, err
-		}
-
-		// 4) replace visibility
-		if in.Visibility.Access == "private" {
-			if err := repo.ReplaceRoleVisibility(db, postID, in.Visibility, tx); err != nil {
-				return nil, err
+		{"categories", func(tx context.Context) error {
+			return repo.ReplaceCategories(db, postID, in.CategoryIDs, tx)
+		}},
+		// 4) replace visibility (private → post_role_visibility, org → targeting ในโพสต์)
+		{"role visibility", func(tx context.Context) error {
+			if in.Visibility.Access == "private" {
+				return repo.ReplaceRoleVisibility(db, postID, in.Visibility, tx)
 			}
-		} else {
-			// public → เคลียร์ทิ้ง
-			if _, err := db.Collection("post_role_visibility").
-				DeleteMany(tx, bson.M{"post_id": postID}); err != nil {
-				return nil, err
+			// public/org → เคลียร์ทิ้ง
+			_, err := db.Collection("post_role_visibility").DeleteMany(tx, bson.M{"post_id": postID})
+			return err
+		}},
+		{"targeting", func(tx context.Context) error {
+			pu.updated.Targeting = pu.targeting
+			return repo.SetPostTargeting(tx, db, postID, pu.targeting)
+		}},
+		// 5) rebuild hashtags (เก็บทั้งใน posts และตาราง hashtags)
+		// ยังไม่เผยแพร่ → ไม่ index (ล้างของเดิมถ้ามี) รอ index ตอน publish
+		{"hashtags", func(tx context.Context) error {
+			pu.updated.Hashtag = u.ExtractHashtags(in.PostText)
+			if pu.updated.Status != models.PostStatusActive {
+				return repo.RebuildHashtags(db, *pu.updated, "", tx)
 			}
-		}
+			return repo.RebuildHashtags(db, *pu.updated, in.PostText, tx)
+		}},
+		// 6) revision ของผลหลังแก้ (ผู้แก้ + เวลา)
+		{"revision", func(tx context.Context) error {
+			return repo.InsertPostRevision(tx, db, editedPostRevision(*pu.updated, in, pu.revision+1, userID))
+		}},
+	}
+}
 
-		// 5) rebuild hashtags (เก็บทั้งใน posts และตาราง hashtags)
-		updated.Hashtag = u.ExtractHashtags(in.PostText)
-		if err := repo.RebuildHashtags(db, *updated, in.PostText, tx); err != nil {
-			// จะ treat เป็น non-fatal ก็ได้ แต่ที่นี่ขอให้ fail ตรง ๆ เพื่อความสม่ำเสมอ
-			return nil, err
-		}
+// DeletePost soft delete (status: active -> inactive) ผ่าน transaction/retry เดียวกับ create/update
+// คืน false ถ้าไม่พบ/ไม่ active หรือไม่ใช่เจ้าของ (และไม่ได้รับสิทธิ์ elevated)
+func DeletePost(client *mongo.Client, db *mongo.Database, postID, userID bson.ObjectID, isRoot bool, ctx context.Context) (bool, error) {
+	var ok bool
+	err := runPostSteps(ctx, client, "delete post", deletePostSteps(db, postID, userID, isRoot, &ok)...)
+	return ok, err
+}
 
-		return nil, nil
-	})
-	if err != nil {
-		return nil, err
+// deletePostSteps ขั้นของการลบโพสต์ — ok = มีโพสต์ถูกปิดจริง
+func deletePostSteps(db *mongo.Database, postID, userID bson.ObjectID, isRoot bool, ok *bool) []postStep {
+	return []postStep{
+		{"deactivate post", func(tx context.Context) error {
+			var err error
+			*ok, err = repo.DeletePost(db, postID, tx, userID, isRoot)
+			return err
+		}},
 	}
-	return updated, nil
 }
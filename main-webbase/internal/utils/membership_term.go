@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CurrentMembershipFilter membership ที่ยังอยู่ในวาระ ณ now:
+// active และเริ่มวาระแล้ว (joined_at <= now หรือไม่มี) และยังไม่ถึง term_end
+func CurrentMembershipFilter(now time.Time) bson.M {
+	return bson.M{
+		"active": true,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"joined_at": bson.M{"$lte": now}},
+				bson.M{"joined_at": nil},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"term_end": bson.M{"$gt": now}},
+				bson.M{"term_end": nil},
+			}},
+		},
+	}
+}
+
+// MembershipAsOfFilter membership ที่ดำรงตำแหน่งอยู่ ณ เวลา t (รวมที่จบไปแล้ว)
+// เอกสารเก่าที่ไม่มี joined_at/ended_at ใช้ created_at/updated_at แทน
+func MembershipAsOfFilter(t time.Time) bson.M {
+	return bson.M{
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"joined_at": bson.M{"$lte": t}},
+				bson.M{"joined_at": nil, "created_at": bson.M{"$lte": t}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"ended_at": bson.M{"$gt": t}},
+				bson.M{"ended_at": nil, "active": true},
+				bson.M{"ended_at": nil, "active": false, "updated_at": bson.M{"$gt": t}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"term_end": bson.M{"$gt": t}},
+				bson.M{"term_end": nil},
+			}},
+		},
+	}
+}
+
+// ParseAsOf รับ RFC3339 หรือวันที่ "2006-01-02" (ถือเป็นสิ้นวันตามเวลาไทย)
+func ParseAsOf(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		loc = time.UTC
+	}
+	if d, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return d.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return time.Time{}, errors.New("as_of must be RFC3339 or YYYY-MM-DD")
+}
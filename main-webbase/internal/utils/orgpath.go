@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// OrgAncestors returns every ancestor of an org path, root first.
+// Example: "/faculty/eng/smo" -> ["/", "/faculty", "/faculty/eng"].
+func OrgAncestors(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+	ancestors := []string{"/"}
+	segs := strings.Split(trimmed, "/")
+	for i := 1; i < len(segs); i++ {
+		ancestors = append(ancestors, "/"+strings.Join(segs[:i], "/"))
+	}
+	return ancestors
+}
+
+// OrgParent returns the parent path ("/" for top-level units).
+func OrgParent(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// OrgLeaf returns the last segment of an org path.
+func OrgLeaf(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// IsUnderOrgPath reports whether path equals root or sits below it.
+func IsUnderOrgPath(path, root string) bool {
+	if root == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+	return path == root || strings.HasPrefix(path, root+"/")
+}
+
+// RebaseOrgPath swaps the oldRoot prefix of path for newRoot.
+// Paths outside oldRoot are returned unchanged.
+func RebaseOrgPath(path, oldRoot, newRoot string) string {
+	if !IsUnderOrgPath(path, oldRoot) {
+		return path
+	}
+	return newRoot + path[len(oldRoot):]
+}
+
+// OrgSubtreePattern is a Mongo regex matching root and everything below it.
+func OrgSubtreePattern(root string) string {
+	return "^" + regexp.QuoteMeta(root) + "(/|$)"
+}
+
+var orgSlugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidOrgSlug accepts lowercase letters, digits, '-' and '_'.
+func ValidOrgSlug(slug string) bool {
+	return orgSlugRe.MatchString(slug)
+}